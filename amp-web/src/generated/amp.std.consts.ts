@@ -151,6 +151,7 @@ export const Attr = {
     LawPlanetOrigin                   : { id: [0x6C8CDF082B47A29En, 0xCC572ADCF4282E79n], text: "amp.law.PlanetOrigin" },                     // 3dj-mghhbu7nbg-dsptbvmu2h-cmt
     LawEquivalence                    : { id: [0x99F3808D1F407BE6n, 0x2E656BA55F1738FEn], text: "amp.law.Equivalence" },                      // 4ty-f08u7u0ggm-2wtccnpgjf-f7y
     LawWithdraw                       : { id: [0x850B8DAE8EC87EF2n, 0x228AC81879D663EDn], text: "amp.law.Withdraw" },                         // 451-f6ux3q8gvt-252q831wxd-sze
+    LawChronicleCompact               : { id: [0x676690B113088905n, 0x7CC4040AF65DD11En], text: "amp.law.ChronicleCompact" },                 // 37d-u8c24s8j42-rtj041cv5v-n8y
     // Substrate-agnostic Member Kind (AOM SD-substrate-agnostic-members.md).  MemberEpoch.Kind is a Tag
     // resolving to one of these UIDs.  Communities + apps may register
     // additional Kinds in their own consts.sdl.  Zero UID = unspecified.
@@ -10,9 +10,10 @@ export interface TagName {
 }
 
 export const Crypto = {
-    Poly25519: { id: [0x629134B84734DA6Fn, 0xCC870AD1BB12E1E6n], text: "amp.crypto.poly25519" },  // 32k-4uchjtnv9r-wt1sbu6xj5-sg6
-    P256     : { id: [0x188DC92BB63ACCBDn, 0xE22053F8FFA3A09Fn], text: "amp.crypto.p256" },  // 0sj-r4krejutky-y482mz3zu7-84z
-    Secp256k1: { id: [0x1906FAF4F88AB854n, 0x6328B92D89014254n], text: "amp.crypto.secp256k1" },  // 0t0-vxg9y4br1b-66b5t5q4h2-hkn
+    Poly25519     : { id: [0x629134B84734DA6Fn, 0xCC870AD1BB12E1E6n], text: "amp.crypto.poly25519" },  // 32k-4uchjtnv9r-wt1sbu6xj5-sg6
+    P256          : { id: [0x188DC92BB63ACCBDn, 0xE22053F8FFA3A09Fn], text: "amp.crypto.p256" },  // 0sj-r4krejutky-y482mz3zu7-84z
+    Secp256k1     : { id: [0x1906FAF4F88AB854n, 0x6328B92D89014254n], text: "amp.crypto.secp256k1" },  // 0t0-vxg9y4br1b-66b5t5q4h2-hkn
+    X25519MLKEM768: { id: [0x7C1DB612AAD4C0A5n, 0x59C92A44C2D0C0CEn], text: "amp.crypto.x25519-mlkem768" },  // 3w3-qv15bqns2k-pmk9b8m1e1-h6f
 } satisfies Record<string, TagName>;
 
 // ─── Phrase — the canonical safe.Phrase vocabulary (SD-did-identity §12.1): a ───
@@ -3,6 +3,8 @@ package amp
 import (
 	"bytes"
 	"encoding/binary"
+	"runtime"
+	"sync"
 
 	"github.com/art-media-platform/amp.SDK/stdlib/safe"
 	"github.com/art-media-platform/amp.SDK/stdlib/status"
@@ -205,6 +207,8 @@ func newBlobHashKit(kitID safe.HashKitID) (safe.HashKit, error) {
 // one site holding the grain-then-compose mechanics every verifier shares.
 // The caller streams one chunk's bytes through Write (any segmentation) and
 // takes its entry with SumChunk; grain boundaries are handled internally.
+// Under a tree-mode kit (BLAKE3) the whole grains of a large Write are hashed
+// across cores.
 type BlobChunkHasher struct {
 	fine    safe.HashKit // level 0: raw grain bytes
 	compose safe.HashKit // level 1: tagged fine-digest runs
@@ -235,6 +239,15 @@ func (bch *BlobChunkHasher) Write(chunk []byte) (int, error) {
 	written := len(chunk)
 	grainSize := int64(1) << BlobGrainSizeLog2
 	for len(chunk) > 0 {
+		if bch.inGrain == 0 {
+			if run := blobGrainDigests(bch.fine.HashKitID, chunk); run != nil {
+				for begin := 0; begin < len(run); begin += BlobMetaHashSize {
+					bch.composeGrain(run[begin : begin+BlobMetaHashSize])
+				}
+				chunk = chunk[len(run)/BlobMetaHashSize<<BlobGrainSizeLog2:]
+				continue
+			}
+		}
 		span := min(grainSize-bch.inGrain, int64(len(chunk)))
 		bch.fine.Hasher.Write(chunk[:span])
 		bch.inGrain += span
@@ -251,12 +264,17 @@ func (bch *BlobChunkHasher) finishGrain() {
 	digest := bch.fine.Hasher.Sum(nil)
 	bch.fine.Hasher.Reset()
 	bch.inGrain = 0
+	bch.composeGrain(digest[:BlobMetaHashSize])
+}
+
+// composeGrain feeds one grain digest to the chunk's run.
+func (bch *BlobChunkHasher) composeGrain(digest []byte) {
 	if !bch.tagged {
 		bch.compose.Hasher.Reset()
 		bch.compose.Hasher.Write([]byte{blobMetaComposeTag})
 		bch.tagged = true
 	}
-	bch.compose.Hasher.Write(digest[:BlobMetaHashSize])
+	bch.compose.Hasher.Write(digest)
 }
 
 // SumChunk closes the current chunk — flushing a partial final grain — and
@@ -288,6 +306,8 @@ func BlobGrainRun(kitID safe.HashKitID, chunk []byte) ([]byte, error) {
 	grainSize := int64(1) << BlobGrainSizeLog2
 	grainCount := (int64(len(chunk)) + grainSize - 1) >> BlobGrainSizeLog2
 	run := make([]byte, 0, grainCount*BlobMetaHashSize)
+	run = append(run, blobGrainDigests(fine.HashKitID, chunk)...)
+	chunk = chunk[len(run)/BlobMetaHashSize<<BlobGrainSizeLog2:]
 	for len(chunk) > 0 {
 		span := min(grainSize, int64(len(chunk)))
 		fine.Hasher.Reset()
@@ -317,6 +337,49 @@ func VerifyGrainRun(kitID safe.HashKitID, entry []byte, run []byte) error {
 	return nil
 }
 
+// blobParallelGrains is the fewest whole grains (256 KiB) whose digests a
+// Write fans across cores under a tree-mode HashKit.
+const blobParallelGrains = 64
+
+// blobGrainDigests returns the fine-digest run of stored's whole grains, hashed
+// across cores, when the kit is a tree-mode hash (HashSpec.Parallel — BLAKE3)
+// and there are at least blobParallelGrains of them; otherwise nil, and the
+// caller hashes grain by grain.  Grains are independent, so the run is the one
+// a serial pass derives.
+func blobGrainDigests(kitID safe.HashKitID, stored []byte) []byte {
+	grainCount := len(stored) >> BlobGrainSizeLog2
+	if grainCount < blobParallelGrains {
+		return nil
+	}
+	spec, err := safe.GetHashKit(kitID)
+	if err != nil || !spec.Parallel {
+		return nil
+	}
+	run := make([]byte, grainCount*BlobMetaHashSize)
+	hashGrains := func(begin, end int) {
+		hasher := spec.New()
+		var digest []byte
+		for grain := begin; grain < end; grain++ {
+			hasher.Reset()
+			hasher.Write(stored[grain<<BlobGrainSizeLog2 : (grain+1)<<BlobGrainSizeLog2])
+			digest = hasher.Sum(digest[:0])
+			copy(run[grain*BlobMetaHashSize:], digest[:BlobMetaHashSize])
+		}
+	}
+	workers := min(runtime.GOMAXPROCS(0), grainCount/blobParallelGrains)
+	if workers <= 1 {
+		hashGrains(0, grainCount)
+		return run
+	}
+	per := (grainCount + workers - 1) / workers
+	var wg sync.WaitGroup
+	for begin := 0; begin < grainCount; begin += per {
+		wg.Go(func() { hashGrains(begin, min(begin+per, grainCount)) })
+	}
+	wg.Wait()
+	return run
+}
+
 // ── Streaming meta mint ─────────────────────────────────────────────────
 
 // blobMetaLane accumulates one candidate exponent's entries once the
@@ -338,7 +401,8 @@ type blobMetaLane struct {
 // winning exponent's entries composed at Finish; past blobMetaSpillLen the
 // buffer replays into per-exponent lanes — dead exponents pruned as the
 // length grows — so memory stays bounded and the pass count stays one at
-// any size.
+// any size.  Under a tree-mode kit (BLAKE3) the whole grains of a large
+// Write are hashed across cores.
 type BlobMetaBuilder struct {
 	kitID   safe.HashKitID
 	fine    safe.HashKit   // level 0: raw grain bytes
@@ -370,6 +434,16 @@ func (bld *BlobMetaBuilder) Write(stored []byte) (int, error) {
 	written := len(stored)
 	grainSize := int64(1) << BlobGrainSizeLog2
 	for len(stored) > 0 {
+		if bld.inGrain == 0 {
+			if run := blobGrainDigests(bld.fine.HashKitID, stored); run != nil {
+				for begin := 0; begin < len(run); begin += BlobMetaHashSize {
+					bld.total += grainSize
+					bld.routeGrain(run[begin : begin+BlobMetaHashSize])
+				}
+				stored = stored[len(run)/BlobMetaHashSize<<BlobGrainSizeLog2:]
+				continue
+			}
+		}
 		span := min(grainSize-bld.inGrain, int64(len(stored)))
 		bld.fine.Hasher.Write(stored[:span])
 		bld.inGrain += span
@@ -387,15 +461,20 @@ func (bld *BlobMetaBuilder) absorbGrain() {
 	digest := bld.fine.Hasher.Sum(nil)
 	bld.fine.Hasher.Reset()
 	bld.inGrain = 0
+	bld.routeGrain(digest[:BlobMetaHashSize])
+}
 
+// routeGrain routes one grain digest — into the buffer until spill, then the
+// lanes — once total counts the grain.
+func (bld *BlobMetaBuilder) routeGrain(digest []byte) {
 	if bld.lanes == nil {
-		bld.runBuf = append(bld.runBuf, digest[:BlobMetaHashSize]...)
+		bld.runBuf = append(bld.runBuf, digest...)
 		if bld.total > blobMetaSpillLen {
 			bld.spill()
 		}
 		return
 	}
-	bld.feedLanes(digest[:BlobMetaHashSize])
+	bld.feedLanes(digest)
 	bld.pruneLanes()
 }
 
@@ -672,3 +751,207 @@ func (meta *BlobMeta) VerifyChunk(chunkIndex uint64, chunk []byte, kitID safe.Ha
 	}
 	return nil
 }
+
+// ── Checkpoints: resumable mint ─────────────────────────────────────────
+//
+// A builder or chunk hasher serializes its state at any grain boundary
+// (every chunk boundary is one), so an interrupted ingest resumes from its
+// last checkpoint rather than byte zero and mints the identical meta.  The
+// encoding is proto3 wire form over fixed field numbers; hasher states are
+// the kit's encoding.BinaryMarshaler form, so a checkpoint only resumes
+// under the same kit and Go hash implementation that wrote it.
+
+// Checkpoint serializes the builder's state.  The stream must sit on a grain
+// boundary (stored length a multiple of 4 KiB).
+func (bld *BlobMetaBuilder) Checkpoint() ([]byte, error) {
+	if bld.inGrain != 0 {
+		return nil, status.Code_BadRequest.Errorf("amp: BlobMetaBuilder: checkpoint at %d is not on a grain boundary", bld.total)
+	}
+	ckpt := protowire.AppendTag(nil, 1, protowire.VarintType)
+	ckpt = protowire.AppendVarint(ckpt, uint64(bld.kitID))
+	ckpt = protowire.AppendTag(ckpt, 2, protowire.VarintType)
+	ckpt = protowire.AppendVarint(ckpt, uint64(bld.total))
+	if bld.lanes == nil {
+		ckpt = protowire.AppendTag(ckpt, 3, protowire.BytesType)
+		ckpt = protowire.AppendBytes(ckpt, bld.runBuf)
+		return ckpt, nil
+	}
+	for ii := range bld.lanes {
+		lane := &bld.lanes[ii]
+		state, err := lane.compose.MarshalState()
+		if err != nil {
+			return nil, err
+		}
+		var enc []byte
+		enc = protowire.AppendTag(enc, 1, protowire.VarintType)
+		enc = protowire.AppendVarint(enc, protowire.EncodeBool(lane.dead))
+		enc = protowire.AppendTag(enc, 2, protowire.VarintType)
+		enc = protowire.AppendVarint(enc, lane.grains)
+		enc = protowire.AppendTag(enc, 3, protowire.VarintType)
+		enc = protowire.AppendVarint(enc, protowire.EncodeBool(lane.tagged))
+		enc = protowire.AppendTag(enc, 4, protowire.BytesType)
+		enc = protowire.AppendBytes(enc, state)
+		enc = protowire.AppendTag(enc, 5, protowire.BytesType)
+		enc = protowire.AppendBytes(enc, lane.entries)
+		ckpt = protowire.AppendTag(ckpt, 4, protowire.BytesType)
+		ckpt = protowire.AppendBytes(ckpt, enc)
+	}
+	return ckpt, nil
+}
+
+// Len returns the stored bytes streamed so far.
+func (bld *BlobMetaBuilder) Len() int64 {
+	return bld.total
+}
+
+// ResumeBlobMetaBuilder restores a builder from a Checkpoint; streaming the
+// bytes after the checkpoint then mints what an uninterrupted pass would.
+func ResumeBlobMetaBuilder(checkpoint []byte) (*BlobMetaBuilder, error) {
+	var (
+		kitID  safe.HashKitID
+		total  int64
+		runBuf []byte
+		lanes  [][]byte
+	)
+	err := consumeFields(checkpoint, func(num protowire.Number, varint uint64, field []byte) {
+		switch num {
+		case 1:
+			kitID = safe.HashKitID(varint)
+		case 2:
+			total = int64(varint)
+		case 3:
+			runBuf = bytes.Clone(field)
+		case 4:
+			lanes = append(lanes, field)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	bld, err := NewBlobMetaBuilder(kitID)
+	if err != nil {
+		return nil, err
+	}
+	if total < 0 || total > BlobLenMax || total&(int64(1)<<BlobGrainSizeLog2-1) != 0 {
+		return nil, status.Code_DataFailure.Errorf("amp: BlobMetaBuilder checkpoint: bad stored length %d", total)
+	}
+	bld.total = total
+	if lanes == nil {
+		if int64(len(runBuf)) != total>>BlobGrainSizeLog2*BlobMetaHashSize {
+			return nil, status.Code_DataFailure.Error("amp: BlobMetaBuilder checkpoint: digest run does not match the stored length")
+		}
+		bld.runBuf = runBuf
+		return bld, nil
+	}
+
+	if len(lanes) != BlobChunkSizeLog2Max-BlobChunkSizeLog2Min+1 {
+		return nil, status.Code_DataFailure.Errorf("amp: BlobMetaBuilder checkpoint: %d lanes", len(lanes))
+	}
+	bld.lanes = make([]blobMetaLane, len(lanes))
+	for ii, enc := range lanes {
+		lane := &bld.lanes[ii]
+		if lane.compose, err = newBlobHashKit(kitID); err != nil {
+			return nil, err
+		}
+		var state []byte
+		err := consumeFields(enc, func(num protowire.Number, varint uint64, field []byte) {
+			switch num {
+			case 1:
+				lane.dead = protowire.DecodeBool(varint)
+			case 2:
+				lane.grains = varint
+			case 3:
+				lane.tagged = protowire.DecodeBool(varint)
+			case 4:
+				state = field
+			case 5:
+				lane.entries = bytes.Clone(field)
+			}
+		})
+		if err == nil {
+			err = lane.compose.UnmarshalState(state)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return bld, nil
+}
+
+// Checkpoint serializes the hasher's state within the current chunk.  The
+// chunk bytes written so far must end on a grain boundary.
+func (bch *BlobChunkHasher) Checkpoint() ([]byte, error) {
+	if bch.inGrain != 0 {
+		return nil, status.Code_BadRequest.Error("amp: BlobChunkHasher: checkpoint is not on a grain boundary")
+	}
+	ckpt := protowire.AppendTag(nil, 1, protowire.VarintType)
+	ckpt = protowire.AppendVarint(ckpt, uint64(bch.compose.HashKitID))
+	if bch.tagged {
+		state, err := bch.compose.MarshalState()
+		if err != nil {
+			return nil, err
+		}
+		ckpt = protowire.AppendTag(ckpt, 2, protowire.BytesType)
+		ckpt = protowire.AppendBytes(ckpt, state)
+	}
+	return ckpt, nil
+}
+
+// ResumeBlobChunkHasher restores a chunk hasher from a Checkpoint.
+func ResumeBlobChunkHasher(checkpoint []byte) (*BlobChunkHasher, error) {
+	var kitID safe.HashKitID
+	var state []byte
+	err := consumeFields(checkpoint, func(num protowire.Number, varint uint64, field []byte) {
+		switch num {
+		case 1:
+			kitID = safe.HashKitID(varint)
+		case 2:
+			state = field
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	bch, err := NewBlobChunkHasher(kitID)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		if err := bch.compose.UnmarshalState(state); err != nil {
+			return nil, err
+		}
+		bch.tagged = true
+	}
+	return bch, nil
+}
+
+// consumeFields walks proto3 wire fields, handing each varint or bytes field
+// to fn (the other's argument zero).
+func consumeFields(buf []byte, fn func(num protowire.Number, varint uint64, field []byte)) error {
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return status.Code_DataFailure.Wrap(protowire.ParseError(n))
+		}
+		buf = buf[n:]
+		switch typ {
+		case protowire.VarintType:
+			val, n := protowire.ConsumeVarint(buf)
+			if n < 0 {
+				return status.Code_DataFailure.Wrap(protowire.ParseError(n))
+			}
+			fn(num, val, nil)
+			buf = buf[n:]
+		case protowire.BytesType:
+			field, n := protowire.ConsumeBytes(buf)
+			if n < 0 {
+				return status.Code_DataFailure.Wrap(protowire.ParseError(n))
+			}
+			fn(num, 0, field)
+			buf = buf[n:]
+		default:
+			return status.Code_DataFailure.Errorf("amp: checkpoint field %d has unexpected wire type %d", num, typ)
+		}
+	}
+	return nil
+}
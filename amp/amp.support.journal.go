@@ -0,0 +1,74 @@
+package amp
+
+import (
+	"encoding/binary"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// ── TxJournal range fingerprint ───────────────────────────────────────────────
+//
+// RangeHash is the Merkle-sync probe two peers compare before streaming any
+// entries, so every TxJournal implementation must derive it identically.  The
+// digest binds entry *bytes*, not just TxTimeIDs: a corrupted or substituted
+// entry surfaces as a mismatch and re-heals rather than spreading.
+//
+// Canonical form, fed in ascending TxTimeID order (quarantined entries included):
+//
+//	for each entry:  TxTimeID (16, big-endian) ‖ H(raw) (32)
+//	RangeHash      = H(entries...)[:16]   — nil UID for an empty range
+//
+// H is the default HashKit (Blake2s_256).
+
+// TxRangeHashKit is the HashKit every TxJournal derives RangeHash under.
+const TxRangeHashKit = safe.HashKitID_Blake2s_256
+
+// TxRangeDigest accumulates the RangeHash fingerprint over journal entries fed
+// in ascending TxTimeID order.  Not threadsafe; one digest per range scan.
+type TxRangeDigest struct {
+	outer safe.HashKit
+	inner safe.HashKit
+	scrap []byte
+	count int
+}
+
+// NewTxRangeDigest returns an empty digest ready for Add.
+func NewTxRangeDigest() *TxRangeDigest {
+	outer, _ := safe.NewHashKit(TxRangeHashKit)
+	inner, _ := safe.NewHashKit(TxRangeHashKit)
+	return &TxRangeDigest{
+		outer: outer,
+		inner: inner,
+	}
+}
+
+// Add folds one journal entry into the digest.  Callers feed entries in
+// ascending TxTimeID order; the digest does not reorder.
+func (d *TxRangeDigest) Add(txTimeID tag.UID, raw []byte) {
+	d.inner.Hasher.Reset()
+	d.inner.Hasher.Write(raw)
+
+	d.scrap = txTimeID.AppendTo(d.scrap[:0])
+	d.scrap = d.inner.Hasher.Sum(d.scrap)
+	d.outer.Hasher.Write(d.scrap)
+	d.count++
+}
+
+// Count returns the number of entries folded so far.
+func (d *TxRangeDigest) Count() int {
+	return d.count
+}
+
+// Sum returns the 128-bit RangeHash fingerprint — the leading 16 bytes of the
+// digest — or the nil UID when no entries were added, so two empty ranges agree.
+func (d *TxRangeDigest) Sum() tag.UID {
+	if d.count == 0 {
+		return tag.UID{}
+	}
+	digest := d.outer.Hasher.Sum(nil)
+	return tag.UID{
+		binary.BigEndian.Uint64(digest[0:8]),
+		binary.BigEndian.Uint64(digest[8:16]),
+	}
+}
@@ -144,9 +144,12 @@ func (me *MemberEpoch) VerifyReKeyQuorum(planetID tag.UID, hashKit safe.HashKitI
 
 // ── did:key fold ──────────────────────────────────────────────────────────────
 
-// multicodecEd25519Pub is the multicodec registry code for ed25519-pub; its
-// unsigned-LEB128 varint (ED 01) prefixes the key bytes inside a did:key.
-const multicodecEd25519Pub = 0xED
+// Multicodec registry codes for the did:key key types; a code's unsigned-LEB128
+// varint (ED 01, E7 01) prefixes the key bytes inside a did:key.
+const (
+	multicodecEd25519Pub   = 0xED // 32-byte Ed25519 key
+	multicodecSecp256k1Pub = 0xE7 // 33-byte SEC1-compressed key
+)
 
 // base58btcAlphabet is the Bitcoin / multibase 'z' alphabet (no 0, O, I, l).
 const base58btcAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
@@ -183,15 +186,21 @@ func base58btcEncode(raw []byte) string {
 // DIDKeyUID returns the MemberID the SD-did-identity §2 fold mints for a
 // signing key — tag.HashName over the canonical did:key URI ("did:key:z" +
 // base58btc(multicodec-varint ‖ pubkey)) — and whether the kit has a did:key
-// form at all (Ed25519 via Poly25519 is the shipped form; a kit with no
-// did:key encoding reads false).  A re-key verifier uses it structurally:
+// form at all (Ed25519 via Poly25519 and compressed secp256k1 are the shipped
+// forms; a kit with no did:key encoding reads false).  A re-key verifier uses it structurally:
 // when the fold of the key being retired IS the MemberID, the identity cannot
 // outlive the key and re-key is refused (SD-did-identity §2, §12.1).
 func DIDKeyUID(kit safe.CryptoKitID, pubKey []byte) (tag.UID, bool) {
-	if kit != safe.Crypto.Poly25519.ID || len(pubKey) != 32 {
+	var codec uint64
+	switch {
+	case kit == safe.Crypto.Poly25519.ID && len(pubKey) == 32:
+		codec = multicodecEd25519Pub
+	case kit == safe.Crypto.Secp256k1.ID && len(pubKey) == 33 && (pubKey[0] == 0x02 || pubKey[0] == 0x03):
+		codec = multicodecSecp256k1Pub
+	default:
 		return tag.UID{}, false
 	}
-	payload := binary.AppendUvarint(make([]byte, 0, 2+len(pubKey)), multicodecEd25519Pub)
+	payload := binary.AppendUvarint(make([]byte, 0, 2+len(pubKey)), codec)
 	payload = append(payload, pubKey...)
 	uri := "did:key:z" + base58btcEncode(payload)
 	return tag.HashName(uri).ID, true
@@ -267,3 +267,24 @@ func TestDIDKeyUID_W3CVector(t *testing.T) {
 		t.Error("a non-32-byte key must have no did:key fold")
 	}
 }
+
+// TestDIDKeyUID_Secp256k1Vector pins the secp256k1 fold to the first
+// secp256k1 example of the W3C-CCG did:key test vectors (compressed key, E7 01).
+func TestDIDKeyUID_Secp256k1Vector(t *testing.T) {
+	pub, err := hex.DecodeString("03874c15c7fda20e539c6e5ba573c139884c351188799f5458b4b41f7924f235cd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, ok := amp.DIDKeyUID(safe.Crypto.Secp256k1.ID, pub)
+	if !ok {
+		t.Fatal("compressed secp256k1 must have a did:key form")
+	}
+	if want := tag.HashName("did:key:zQ3shokFTS3brHcDQrn82RUDfCZESWL1ZdCEJwekUDPQiYBme").ID; uid != want {
+		t.Errorf("fold mismatch vs the W3C URI: got %v want %v", uid, want)
+	}
+	// Only the compressed encoding folds; an uncompressed key is not a did:key.
+	uncompressed := append([]byte{0x04}, make([]byte, 64)...)
+	if _, ok := amp.DIDKeyUID(safe.Crypto.Secp256k1.ID, uncompressed); ok {
+		t.Error("an uncompressed secp256k1 key must have no did:key fold")
+	}
+}
@@ -0,0 +1,339 @@
+package amp
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/data"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// ── Sealed blobs: frame-granular AEAD ───────────────────────────────────
+//
+// A sealed blob's stored bytes are a fixed header followed by the plaintext
+// cut into frames of 2^FrameLog2 bytes, each sealed on its own, so a reader
+// decrypts only the frames a range touches:
+//
+//	header  = "ampB" | version (1) | FrameLog2 (1) | EpochID (16) | salt (16)
+//	frame i = AEAD(key, nonce = salt ‖ i (8, big-endian),
+//	               plaintext[i·2^FrameLog2 : (i+1)·2^FrameLog2],
+//	               AAD = header ‖ i (8) ‖ final (1))
+//	key     = HKDF(epoch ContentKey, "sealed-blob")
+//
+// The random per-blob salt makes every (blob, frame) nonce unique under one
+// epoch key; the AAD binds each frame to its blob, its index, and whether it
+// ends the blob, so frames cannot be reordered, spliced between blobs, or
+// truncated away undetected.  An empty blob is one empty final frame.
+//
+// Frames align to the plaintext chunk grid (FrameLog2 defaults to
+// BlobChunkSizeLog2Min); in STORED bytes the frame pitch carries the AEAD
+// overhead, so BlobMeta chunks over the ciphertext stay frame-blind and
+// verify exactly as for any other stored bytes (§13.10).
+
+const (
+	// SealedBlobHeaderSize is the stored length of a sealed blob's header.
+	SealedBlobHeaderSize = 4 + 1 + 1 + 16 + 16
+
+	// SealedBlobOverhead is the AEAD tag each frame adds to its plaintext.
+	SealedBlobOverhead = 16
+
+	// SealedBlobFrameLog2 is the default plaintext frame exponent.
+	SealedBlobFrameLog2 = BlobChunkSizeLog2Min
+
+	sealedBlobMagic   = "ampB"
+	sealedBlobVersion = 1
+	sealedBlobPurpose = "sealed-blob"
+)
+
+// SealedBlobLen returns the stored length of a plaintext of plainLen bytes
+// sealed at the given frame exponent (0 selects SealedBlobFrameLog2).
+func SealedBlobLen(plainLen int64, frameLog2 uint32) int64 {
+	if frameLog2 == 0 {
+		frameLog2 = SealedBlobFrameLog2
+	}
+	frames := max(1, (plainLen+(1<<frameLog2)-1)>>frameLog2)
+	return SealedBlobHeaderSize + frames*SealedBlobOverhead + plainLen
+}
+
+// sealedBlobCipher is the per-blob AEAD state shared by sealer and reader.
+type sealedBlobCipher struct {
+	aead   cipher.AEAD
+	header [SealedBlobHeaderSize]byte
+	nonce  [safe.NonceSize]byte
+	aad    [SealedBlobHeaderSize + 9]byte
+}
+
+func newSealedBlobCipher(epochKey safe.SymKey, header []byte) (*sealedBlobCipher, error) {
+	subKey, err := safe.DeriveSubKey(epochKey.Bytes, sealedBlobPurpose)
+	if err != nil {
+		return nil, err
+	}
+	defer safe.Zero(subKey)
+	aead, err := safe.NewAEAD(subKey)
+	if err != nil {
+		return nil, err
+	}
+	blob := &sealedBlobCipher{aead: aead}
+	copy(blob.header[:], header)
+	copy(blob.nonce[:16], header[SealedBlobHeaderSize-16:])
+	copy(blob.aad[:], header)
+	return blob, nil
+}
+
+// bind sets the nonce and AAD for frame index.
+func (blob *sealedBlobCipher) bind(index uint64, final bool) {
+	binary.BigEndian.PutUint64(blob.nonce[16:], index)
+	binary.BigEndian.PutUint64(blob.aad[SealedBlobHeaderSize:], index)
+	blob.aad[SealedBlobHeaderSize+8] = 0
+	if final {
+		blob.aad[SealedBlobHeaderSize+8] = 1
+	}
+}
+
+func (blob *sealedBlobCipher) frameLog2() uint32 {
+	return uint32(blob.header[5])
+}
+
+// BlobSealer streams plaintext into the sealed-blob format.  Write any
+// segmentation, then Close to seal the final frame.  Not threadsafe.
+type BlobSealer struct {
+	cipher *sealedBlobCipher
+	dst    io.Writer
+	frame  []byte // plaintext of the frame in progress
+	index  uint64
+	sealed []byte
+	err    error
+}
+
+// NewBlobSealer writes the header of a blob sealed under epochKey (an epoch
+// ContentKey carrying its EpochID) to dst and returns the sealer for its
+// plaintext.  frameLog2 0 selects SealedBlobFrameLog2.
+func NewBlobSealer(rand io.Reader, epochKey safe.SymKey, frameLog2 uint32, dst io.Writer) (*BlobSealer, error) {
+	if epochKey.EpochID.IsNil() || len(epochKey.Bytes) == 0 {
+		return nil, status.Code_BadRequest.Error("amp: NewBlobSealer: epochKey must carry EpochID and key bytes")
+	}
+	if frameLog2 == 0 {
+		frameLog2 = SealedBlobFrameLog2
+	}
+	if frameLog2 < BlobGrainSizeLog2 || frameLog2 > BlobChunkSizeLog2Max {
+		return nil, status.Code_BadRequest.Errorf("amp: NewBlobSealer: frame exponent %d out of bounds [%d,%d]", frameLog2, BlobGrainSizeLog2, BlobChunkSizeLog2Max)
+	}
+	header := make([]byte, 0, SealedBlobHeaderSize)
+	header = append(header, sealedBlobMagic...)
+	header = append(header, sealedBlobVersion, byte(frameLog2))
+	header = epochKey.EpochID.AppendTo(header)
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	header = append(header, salt...)
+
+	blobCipher, err := newSealedBlobCipher(epochKey, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return &BlobSealer{
+		cipher: blobCipher,
+		dst:    dst,
+		frame:  make([]byte, 0, 1<<frameLog2),
+	}, nil
+}
+
+// Write seals plaintext, emitting each frame once the next one begins.
+func (bs *BlobSealer) Write(plaintext []byte) (int, error) {
+	if bs.err != nil {
+		return 0, bs.err
+	}
+	written := len(plaintext)
+	for len(plaintext) > 0 {
+		if len(bs.frame) == cap(bs.frame) {
+			if bs.flush(false); bs.err != nil {
+				return written - len(plaintext), bs.err
+			}
+		}
+		span := min(cap(bs.frame)-len(bs.frame), len(plaintext))
+		bs.frame = append(bs.frame, plaintext[:span]...)
+		plaintext = plaintext[span:]
+	}
+	return written, nil
+}
+
+// Close seals the final frame.  It does not close the destination.
+func (bs *BlobSealer) Close() error {
+	if bs.err == nil {
+		bs.flush(true)
+		if bs.err == nil {
+			bs.err = status.Code_ShuttingDown.Error("amp: BlobSealer is closed")
+			return nil
+		}
+	}
+	return bs.err
+}
+
+func (bs *BlobSealer) flush(final bool) {
+	bs.cipher.bind(bs.index, final)
+	bs.sealed = bs.cipher.aead.Seal(bs.sealed[:0], bs.cipher.nonce[:], bs.frame, bs.cipher.aad[:])
+	safe.Zero(bs.frame)
+	bs.frame = bs.frame[:0]
+	bs.index++
+	if _, err := bs.dst.Write(bs.sealed); err != nil {
+		bs.err = status.Code_StorageFailure.Wrap(err)
+	}
+}
+
+// SealedBlobReader is a seekable plaintext view of a sealed blob's stored
+// bytes: each Read decrypts only the frames it touches, keeping the most
+// recent one.  It satisfies data.AssetReader.  Not threadsafe.
+type SealedBlobReader struct {
+	cipher   *sealedBlobCipher
+	stored   io.ReaderAt
+	plainLen int64
+	frames   uint64
+	pos      int64
+	cached   int64 // frame index held in plain, or -1
+	plain    []byte
+	scrap    []byte
+}
+
+var _ data.AssetReader = (*SealedBlobReader)(nil)
+
+// OpenSealedBlob opens the sealed blob in stored[0:storedLen] under epochKey,
+// which must name the EpochID the header carries.  Frames are authenticated
+// as they are read; Close closes stored when it is an io.Closer.
+func OpenSealedBlob(epochKey safe.SymKey, stored io.ReaderAt, storedLen int64) (*SealedBlobReader, error) {
+	header := make([]byte, SealedBlobHeaderSize)
+	if storedLen < SealedBlobHeaderSize+SealedBlobOverhead {
+		return nil, status.Code_DataFailure.Error("amp: OpenSealedBlob: too short for a sealed blob")
+	}
+	if _, err := stored.ReadAt(header, 0); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	if string(header[:4]) != sealedBlobMagic || header[4] != sealedBlobVersion {
+		return nil, status.Code_DataFailure.Error("amp: OpenSealedBlob: not a sealed blob")
+	}
+	frameLog2 := uint32(header[5])
+	if frameLog2 < BlobGrainSizeLog2 || frameLog2 > BlobChunkSizeLog2Max {
+		return nil, status.Code_DataFailure.Errorf("amp: OpenSealedBlob: frame exponent %d out of bounds", frameLog2)
+	}
+	epochID := tag.UID{binary.BigEndian.Uint64(header[6:14]), binary.BigEndian.Uint64(header[14:22])}
+	if epochID != epochKey.EpochID {
+		return nil, status.Code_BadRequest.Errorf("amp: OpenSealedBlob: blob is sealed under epoch %v, key is %v", epochID, epochKey.EpochID)
+	}
+
+	pitch := int64(1)<<frameLog2 + SealedBlobOverhead
+	body := storedLen - SealedBlobHeaderSize
+	frames, tail := body/pitch, body%pitch
+	switch {
+	case tail == 0:
+	case tail < SealedBlobOverhead:
+		return nil, status.Code_DataFailure.Error("amp: OpenSealedBlob: stored length ends inside a frame tag")
+	default:
+		frames++
+	}
+	blobCipher, err := newSealedBlobCipher(epochKey, header)
+	if err != nil {
+		return nil, err
+	}
+	return &SealedBlobReader{
+		cipher:   blobCipher,
+		stored:   stored,
+		plainLen: body - frames*SealedBlobOverhead,
+		frames:   uint64(frames),
+		cached:   -1,
+	}, nil
+}
+
+// Size is the blob's plaintext length.
+func (rd *SealedBlobReader) Size() int64 {
+	return rd.plainLen
+}
+
+// Read implements io.Reader.
+func (rd *SealedBlobReader) Read(buf []byte) (int, error) {
+	n, err := rd.ReadAt(buf, rd.pos)
+	rd.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// ReadAt implements io.ReaderAt over the plaintext.
+func (rd *SealedBlobReader) ReadAt(buf []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, status.Code_BadRequest.Error("amp: SealedBlobReader: negative offset")
+	}
+	frameLog2 := rd.cipher.frameLog2()
+	n := 0
+	for n < len(buf) && offset < rd.plainLen {
+		index := offset >> frameLog2
+		if err := rd.load(index); err != nil {
+			return n, err
+		}
+		copied := copy(buf[n:], rd.plain[offset-index<<frameLog2:])
+		n += copied
+		offset += int64(copied)
+	}
+	if n < len(buf) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Seek implements io.Seeker over the plaintext.
+func (rd *SealedBlobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += rd.pos
+	case io.SeekEnd:
+		offset += rd.plainLen
+	}
+	if offset < 0 {
+		return rd.pos, status.Code_BadRequest.Error("amp: SealedBlobReader: seek before start")
+	}
+	rd.pos = offset
+	return offset, nil
+}
+
+// Close zeroes the cached plaintext and closes the stored reader if it can be.
+func (rd *SealedBlobReader) Close() error {
+	safe.Zero(rd.plain)
+	rd.cached = -1
+	if closer, ok := rd.stored.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// load decrypts frame index into the cache.
+func (rd *SealedBlobReader) load(index int64) error {
+	if index == rd.cached {
+		return nil
+	}
+	frameLog2 := rd.cipher.frameLog2()
+	pitch := int64(1)<<frameLog2 + SealedBlobOverhead
+	plainLen := min(int64(1)<<frameLog2, rd.plainLen-index<<frameLog2)
+	sealedLen := plainLen + SealedBlobOverhead
+	if int64(cap(rd.scrap)) < sealedLen {
+		rd.scrap = make([]byte, sealedLen)
+	}
+	sealed := rd.scrap[:sealedLen]
+	if _, err := rd.stored.ReadAt(sealed, SealedBlobHeaderSize+index*pitch); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	rd.cipher.bind(uint64(index), uint64(index) == rd.frames-1)
+	plain, err := rd.cipher.aead.Open(rd.plain[:0], rd.cipher.nonce[:], sealed, rd.cipher.aad[:])
+	if err != nil {
+		rd.cached = -1
+		return status.Code_DecryptFailed.Errorf("amp: sealed blob frame %d fails authentication", index)
+	}
+	rd.plain = plain
+	rd.cached = index
+	return nil
+}
@@ -696,8 +696,9 @@ type CryptoProvider interface {
 // If crypto is nil, the TxMsg is marshaled without encryption or signing (local session use).
 func SealTx(tx *TxMsg, crypto CryptoProvider, dst *[]byte) error {
 	if crypto == nil {
-		// No crypto — standard marshal (local session traffic)
-		tx.MarshalHeadAndOps(dst)
+		// No crypto — standard marshal (local session traffic), DataStore included
+		// since the preamble declares its length.
+		tx.MarshalToBuffer(dst)
 		return nil
 	}
 
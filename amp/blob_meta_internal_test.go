@@ -82,3 +82,39 @@ func TestBlobMetaBuilder_SpillParity(t *testing.T) {
 		}
 	}
 }
+
+// A spilled builder checkpoints its lanes and resumes to the same meta.
+func TestBlobMetaBuilder_SpilledCheckpoint(t *testing.T) {
+	blob := make([]byte, 7<<20+99)
+	for ii := range blob {
+		blob[ii] = byte(ii*31+5) ^ byte(ii>>12)
+	}
+	builder, err := NewBlobMetaBuilder(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.Write(blob[:3<<20])
+	builder.spill()
+	ckpt, err := builder.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := ResumeBlobMetaBuilder(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.lanes == nil {
+		t.Fatal("resumed builder lost its lanes")
+	}
+	for _, bld := range []*BlobMetaBuilder{builder, resumed} {
+		bld.Write(blob[3<<20:])
+	}
+	want, _ := builder.Finish()
+	got, err := resumed.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.CanonicalBytes(), want.CanonicalBytes()) {
+		t.Error("resumed lanes diverge from the uninterrupted builder")
+	}
+}
@@ -3,6 +3,7 @@ package amp_test
 import (
 	"bytes"
 	"encoding/hex"
+	"runtime"
 	"testing"
 
 	"github.com/art-media-platform/amp.SDK/amp"
@@ -219,12 +220,17 @@ func patternBytes(byteLen int) []byte {
 // computed the obvious way, deliberately independent of the builder (the
 // compose tag 0x01 is restated here on purpose — a tag drift must fail).
 func naiveMeta(t *testing.T, blob []byte) *amp.BlobMeta {
+	t.Helper()
+	return naiveMetaUnder(t, blob, 0)
+}
+
+func naiveMetaUnder(t *testing.T, blob []byte, kitID safe.HashKitID) *amp.BlobMeta {
 	t.Helper()
 	grainSize := 1 << amp.BlobGrainSizeLog2
 	if len(blob) <= grainSize {
 		return nil
 	}
-	kit, err := safe.NewHashKit(0)
+	kit, err := safe.NewHashKit(kitID)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -342,6 +348,50 @@ func TestBlobMetaBuilder_WritePatternIndependence(t *testing.T) {
 	}
 }
 
+// Under BLAKE3 the builder, chunk hasher and grain run hash a large write's
+// whole grains across cores; every path must still match the serial reference.
+func TestBlobMeta_Blake3Parallel(t *testing.T) {
+	const kitID = safe.HashKitID_Blake3_256
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4)) // fan out even on one core
+	blob := patternBytes((5 << 20) / 2)
+	want := naiveMetaUnder(t, blob, kitID)
+	for _, sliceLen := range []int{4096 + 1, 300_000, len(blob)} {
+		builder, err := amp.NewBlobMetaBuilder(kitID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for begin := 0; begin < len(blob); begin += sliceLen {
+			builder.Write(blob[begin:min(begin+sliceLen, len(blob))])
+		}
+		meta, err := builder.Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(meta.CanonicalBytes(), want.CanonicalBytes()) {
+			t.Errorf("write slice %d: BLAKE3 meta diverges from the serial reference", sliceLen)
+		}
+	}
+	for chunkIndex := uint64(0); chunkIndex < want.NumChunks(); chunkIndex++ {
+		offset, length := want.ChunkSpan(chunkIndex)
+		chunk := blob[offset : offset+length]
+		if err := want.VerifyChunk(chunkIndex, chunk, kitID); err != nil {
+			t.Errorf("chunk %d: %v", chunkIndex, err)
+		}
+		run, err := amp.BlobGrainRun(kitID, chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := amp.VerifyGrainRun(kitID, want.ChunkHash(chunkIndex), run); err != nil {
+			t.Errorf("chunk %d grain run: %v", chunkIndex, err)
+		}
+	}
+
+	// A distinct kit is a distinct commitment.
+	if bytes.Equal(want.CanonicalBytes(), naiveMeta(t, blob).CanonicalBytes()) {
+		t.Error("BLAKE3 and Blake2s metas coincide")
+	}
+}
+
 // Grain-run primitives: the narrow-link gate accepts a derived run and
 // rejects a corrupted, truncated, or empty one.
 func TestBlobGrainRun_Primitives(t *testing.T) {
@@ -404,3 +454,67 @@ func TestBlobMeta_SubMiBSingleEntry(t *testing.T) {
 		t.Error("ChunkUID is not the entry's leading 16 bytes")
 	}
 }
+
+// A builder checkpointed at a chunk boundary and resumed mints the meta an
+// uninterrupted pass mints; a mid-grain checkpoint is refused.
+func TestBlobMetaBuilder_CheckpointResume(t *testing.T) {
+	blob := patternBytes((5 << 20) + 777)
+	want := buildMeta(t, blob).CanonicalBytes()
+
+	builder, err := amp.NewBlobMetaBuilder(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder.Write(blob[:100])
+	if _, err := builder.Checkpoint(); err == nil {
+		t.Fatal("mid-grain checkpoint accepted")
+	}
+	builder.Write(blob[100 : 2<<20])
+	ckpt, err := builder.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := amp.ResumeBlobMetaBuilder(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Len() != 2<<20 {
+		t.Fatalf("resumed at %d, want %d", resumed.Len(), 2<<20)
+	}
+	resumed.Write(blob[2<<20:])
+	meta, err := resumed.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(meta.CanonicalBytes(), want) {
+		t.Error("resumed builder diverges from an uninterrupted pass")
+	}
+	if _, err := amp.ResumeBlobMetaBuilder(ckpt[:len(ckpt)-7]); err == nil {
+		t.Error("truncated checkpoint accepted")
+	}
+}
+
+// A chunk hasher resumed mid-chunk at a grain boundary sums the same entry.
+func TestBlobChunkHasher_CheckpointResume(t *testing.T) {
+	chunk := patternBytes(1 << 20)
+	meta := buildMeta(t, append(chunk, 0))
+
+	hasher, err := amp.NewBlobChunkHasher(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher.Write(chunk[:75<<amp.BlobGrainSizeLog2])
+	ckpt, err := hasher.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := amp.ResumeBlobChunkHasher(ckpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed.Write(chunk[75<<amp.BlobGrainSizeLog2:])
+	if !bytes.Equal(resumed.SumChunk(), meta.ChunkHash(0)) {
+		t.Error("resumed chunk hasher diverges")
+	}
+}
@@ -0,0 +1,209 @@
+package amp_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	mrand "math/rand/v2"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// countingReaderAt records the stored spans a SealedBlobReader pulls.
+type countingReaderAt struct {
+	*bytes.Reader
+	reads int
+	bytes int64
+}
+
+func (rd *countingReaderAt) ReadAt(buf []byte, offset int64) (int, error) {
+	rd.reads++
+	rd.bytes += int64(len(buf))
+	return rd.Reader.ReadAt(buf, offset)
+}
+
+func sealBlob(t *testing.T, plain []byte, frameLog2 uint32, epochKey safe.SymKey) []byte {
+	t.Helper()
+	var stored bytes.Buffer
+	sealer, err := amp.NewBlobSealer(rand.Reader, epochKey, frameLog2, &stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uneven write segmentation must not change the framing.
+	for rest := plain; len(rest) > 0; {
+		span := min(len(rest), 1+mrand.IntN(9000))
+		if _, err := sealer.Write(rest[:span]); err != nil {
+			t.Fatal(err)
+		}
+		rest = rest[span:]
+	}
+	if err := sealer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := int64(stored.Len()), amp.SealedBlobLen(int64(len(plain)), frameLog2); got != want {
+		t.Fatalf("stored %d bytes, SealedBlobLen says %d", got, want)
+	}
+	return stored.Bytes()
+}
+
+func TestSealedBlob_RoundTripAndSeek(t *testing.T) {
+	const frameLog2 = 12
+	epochID := tag.UID{0x51, 0xE1}
+	epochKey := testSymKey(t, epochID)
+
+	for _, plainLen := range []int{0, 1, 4095, 4096, 4097, 3*4096 + 77} {
+		plain := make([]byte, plainLen)
+		rand.Read(plain)
+		stored := sealBlob(t, plain, frameLog2, epochKey)
+
+		rd, err := amp.OpenSealedBlob(epochKey, bytes.NewReader(stored), int64(len(stored)))
+		if err != nil {
+			t.Fatalf("len %d: %v", plainLen, err)
+		}
+		if rd.Size() != int64(plainLen) {
+			t.Fatalf("len %d: Size() = %d", plainLen, rd.Size())
+		}
+		got, err := io.ReadAll(rd)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("len %d: round trip mismatch (err %v)", plainLen, err)
+		}
+		if plainLen > 0 {
+			if _, err := rd.Seek(-1, io.SeekEnd); err != nil {
+				t.Fatal(err)
+			}
+			var last [1]byte
+			if n, err := rd.Read(last[:]); n != 1 || err != nil || last[0] != plain[plainLen-1] {
+				t.Fatalf("len %d: Seek end read (%d, %v)", plainLen, n, err)
+			}
+		}
+		rd.Close()
+	}
+}
+
+func TestSealedBlob_RangeReadsTouchOnlyTheirFrames(t *testing.T) {
+	const frameLog2 = 12
+	const frameSize = 1 << frameLog2
+	epochID := tag.UID{0x51, 0xE2}
+	epochKey := testSymKey(t, epochID)
+	plain := make([]byte, 20*frameSize+123)
+	rand.Read(plain)
+	stored := sealBlob(t, plain, frameLog2, epochKey)
+
+	src := &countingReaderAt{Reader: bytes.NewReader(stored)}
+	rd, err := amp.OpenSealedBlob(epochKey, src, int64(len(stored)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 200 {
+		offset := mrand.Int64N(int64(len(plain)))
+		length := 1 + mrand.Int64N(3*frameSize)
+		src.reads, src.bytes = 0, 0
+
+		buf := make([]byte, length)
+		n, err := rd.ReadAt(buf, offset)
+		if want := min(length, int64(len(plain))-offset); int64(n) != want || (err != nil && err != io.EOF) {
+			t.Fatalf("ReadAt(%d, %d) = %d, %v", offset, length, n, err)
+		}
+		if !bytes.Equal(buf[:n], plain[offset:offset+int64(n)]) {
+			t.Fatalf("ReadAt(%d, %d) returned wrong bytes", offset, length)
+		}
+		touched := (offset+int64(n)-1)>>frameLog2 - offset>>frameLog2 + 1
+		if int64(src.reads) > touched || src.bytes > touched*(frameSize+amp.SealedBlobOverhead) {
+			t.Fatalf("ReadAt(%d, %d) touched %d frames but pulled %d reads / %d bytes", offset, length, touched, src.reads, src.bytes)
+		}
+	}
+}
+
+func TestSealedBlob_Rejects(t *testing.T) {
+	const frameLog2 = 12
+	const pitch = 1<<frameLog2 + amp.SealedBlobOverhead
+	epochID := tag.UID{0x51, 0xE3}
+	epochKey := testSymKey(t, epochID)
+	plain := make([]byte, 3<<frameLog2)
+	rand.Read(plain)
+	stored := sealBlob(t, plain, frameLog2, epochKey)
+
+	open := func(stored []byte, epochKey safe.SymKey) error {
+		rd, err := amp.OpenSealedBlob(epochKey, bytes.NewReader(stored), int64(len(stored)))
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(rd)
+		return err
+	}
+	if err := open(stored, epochKey); err != nil {
+		t.Fatalf("intact blob: %v", err)
+	}
+
+	flipped := bytes.Clone(stored)
+	flipped[amp.SealedBlobHeaderSize+pitch+9] ^= 1
+	if err := open(flipped, epochKey); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("tampered frame: got %v", err)
+	}
+
+	salted := bytes.Clone(stored)
+	salted[amp.SealedBlobHeaderSize-1] ^= 1
+	if err := open(salted, epochKey); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("tampered header: got %v", err)
+	}
+
+	// Dropping whole trailing frames leaves a well-formed length, but the new
+	// last frame was not sealed as final.
+	if err := open(stored[:len(stored)-pitch], epochKey); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("truncated blob: got %v", err)
+	}
+
+	swapped := bytes.Clone(stored)
+	frame0 := swapped[amp.SealedBlobHeaderSize : amp.SealedBlobHeaderSize+pitch]
+	frame1 := swapped[amp.SealedBlobHeaderSize+pitch : amp.SealedBlobHeaderSize+2*pitch]
+	tmp := bytes.Clone(frame0)
+	copy(frame0, frame1)
+	copy(frame1, tmp)
+	if err := open(swapped, epochKey); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("reordered frames: got %v", err)
+	}
+
+	if err := open(stored, testSymKey(t, epochID)); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("wrong key: got %v", err)
+	}
+	if err := open(stored, testSymKey(t, tag.UID{0x51, 0xE4})); err == nil {
+		t.Fatal("wrong epoch accepted")
+	}
+}
+
+// BlobMeta covers the STORED (sealed) bytes: a relay verifies every chunk of
+// a sealed blob without holding its epoch key.
+func TestSealedBlob_MetaOverCiphertext(t *testing.T) {
+	epochID := tag.UID{0x51, 0xE5}
+	epochKey := testSymKey(t, epochID)
+	plain := make([]byte, 3<<20+5)
+	rand.Read(plain)
+	stored := sealBlob(t, plain, 0, epochKey)
+
+	ref := &amp.BlobRef{
+		BlobTag:   amp.TagFromUID(tag.UID{0xB1, 0x0B}),
+		EpochID_0: epochID[0],
+		EpochID_1: epochID[1],
+	}
+	ref.BlobTag.I = int64(len(stored))
+	meta := buildMeta(t, stored)
+	if err := ref.SetBlobMeta(meta, ref.HashKitID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ref.VerifyBlobMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	if ref.IsPublic() {
+		t.Fatal("sealed ref reads as public")
+	}
+	for idx := range meta.NumChunks() {
+		offset, length := meta.ChunkSpan(idx)
+		if err := meta.VerifyChunk(idx, stored[offset:offset+length], ref.HashKitID); err != nil {
+			t.Fatalf("sealed chunk %d: %v", idx, err)
+		}
+	}
+}
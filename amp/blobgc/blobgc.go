@@ -0,0 +1,240 @@
+// Package blobgc finds which stored blobs are still referenced and sweeps the
+// rest.
+//
+// Mark: a Walker scans a planet's TxJournal — and a Codex, when one is kept —
+// for BlobRef values in attrs registered as blob-bearing, collecting each
+// referenced (PlanetID, StorageUID) into a LiveSet.  The journal is scanned
+// whole, not folded: an edit since overwritten or deleted still names its blob,
+// since history stays replayable until compaction retires it.
+//
+// Sweep: Sweep enumerates a store's blobs for a planet and removes those the
+// LiveSet does not hold, sparing any published within the grace period — an
+// upload whose referencing TxMsg has not yet landed (or landed after the mark)
+// must not be swept out from under it.  A dry run reports without removing.
+package blobgc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/chronicle"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+// Extractor lists the blobs one serialized attr value references, calling
+// mark with a BlobRef for each.  A ref without a PlanetID names a blob of the
+// planet being scanned.
+type Extractor func(value []byte, mark func(ref *amp.BlobRef)) error
+
+// LiveSet is the set of referenced blobs, keyed by planet.  Threadsafe.
+type LiveSet struct {
+	mu    sync.Mutex
+	blobs map[tag.UID]map[tag.UID]struct{}
+}
+
+// NewLiveSet returns an empty LiveSet.
+func NewLiveSet() *LiveSet {
+	return &LiveSet{
+		blobs: make(map[tag.UID]map[tag.UID]struct{}),
+	}
+}
+
+// Mark adds a blob to the set.
+func (live *LiveSet) Mark(planetID, blobID tag.UID) {
+	if blobID.IsNil() {
+		return
+	}
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	blobs := live.blobs[planetID]
+	if blobs == nil {
+		blobs = make(map[tag.UID]struct{})
+		live.blobs[planetID] = blobs
+	}
+	blobs[blobID] = struct{}{}
+}
+
+// Has reports whether a blob is in the set.
+func (live *LiveSet) Has(planetID, blobID tag.UID) bool {
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	_, has := live.blobs[planetID][blobID]
+	return has
+}
+
+// Len returns the number of blobs held for planetID.
+func (live *LiveSet) Len(planetID tag.UID) int {
+	live.mu.Lock()
+	defer live.mu.Unlock()
+	return len(live.blobs[planetID])
+}
+
+// Walker marks the blobs referenced by registered attrs.
+type Walker struct {
+	attrs  map[tag.UID]Extractor
+	crypto amp.CryptoProvider
+}
+
+// NewWalker returns a Walker with the standard blob-bearing attrs registered:
+// std.Attr.BlobRef and std.Attr.NodeBlobs (BlobRef values) and
+// std.Attr.MediaSources (Tags values).  crypto opens sealed journal entries
+// (see amp.OpenTxSansVerify); nil reads the unsealed local-session format only.
+func NewWalker(crypto amp.CryptoProvider) *Walker {
+	walker := &Walker{
+		attrs:  make(map[tag.UID]Extractor),
+		crypto: crypto,
+	}
+	walker.Register(std.Attr.BlobRef.ID, BlobRefValue)
+	walker.Register(std.Attr.NodeBlobs.ID, BlobRefValue)
+	walker.Register(std.Attr.MediaSources.ID, TagsValue)
+	return walker
+}
+
+// Register sets (or with nil, clears) the Extractor for attrID.
+func (walker *Walker) Register(attrID tag.UID, extract Extractor) {
+	if extract == nil {
+		delete(walker.attrs, attrID)
+	} else {
+		walker.attrs[attrID] = extract
+	}
+}
+
+// WalkJournal marks every blob referenced by an op on a registered attr in
+// planetID's journal.  Delete ops carry no value and mark nothing.
+func (walker *Walker) WalkJournal(live *LiveSet, journal amp.TxJournal, planetID tag.UID) error {
+	var walkErr error
+	err := journal.ReadSince(planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		tx, err := amp.OpenTxSansVerify(raw, walker.crypto)
+		if err != nil {
+			walkErr = status.Code_DecryptFailed.Errorf("blobgc: TxMsg %v: %v", txTimeID, err)
+			return false
+		}
+		walkErr = walker.WalkTx(tx, planetID, func(ref *amp.BlobRef) {
+			live.Mark(refPlanet(ref, planetID), ref.StorageUID())
+		})
+		return walkErr == nil
+	})
+	if err == nil {
+		err = walkErr
+	}
+	return err
+}
+
+// WalkTx calls mark for each blob referenced by an op of tx on a registered
+// attr; a ref without a PlanetID is completed with planetID.
+func (walker *Walker) WalkTx(tx *amp.TxMsg, planetID tag.UID, mark func(ref *amp.BlobRef)) error {
+	for i := range tx.Ops {
+		op := &tx.Ops[i]
+		extract := walker.attrs[op.Addr.AttrID]
+		if extract == nil || op.Flags&(amp.TxOpFlags_Delete|amp.TxOpFlags_MetaOp) != 0 {
+			continue
+		}
+		value, err := tx.OpValueBytes(i)
+		if err == nil {
+			err = extract(value, completeRef(planetID, mark))
+		}
+		if err != nil {
+			return status.Code_ParseFailed.Errorf("blobgc: TxMsg %v op %d: %v", tx.TxID(), i, err)
+		}
+	}
+	return nil
+}
+
+// WalkCodex marks the blobs a codex references: every spilled BlobValue, and
+// the blobs named by Artifacts of registered attrs.  A spilled value of a
+// registered attr is read back from blobs; with blobs nil it is only marked.
+func (walker *Walker) WalkCodex(live *LiveSet, codex io.Reader, blobs amp.BlobStore) error {
+	cr, err := chronicle.NewCodexReader(codex)
+	if err != nil {
+		return err
+	}
+	planetID := cr.Header().SourcePlanet.UID()
+	for {
+		art, err := cr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ref := art.BlobValue; ref != nil {
+			live.Mark(refPlanet(ref, planetID), ref.StorageUID())
+		}
+		extract := walker.attrs[tag.UID{art.AttrID_0, art.AttrID_1}]
+		if extract == nil || art.BlobValue != nil && blobs == nil {
+			continue
+		}
+		value, err := chronicle.ArtifactValue(art, blobs)
+		if err == nil {
+			err = extract(value, completeRef(planetID, func(ref *amp.BlobRef) {
+				live.Mark(refPlanet(ref, planetID), ref.StorageUID())
+			}))
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// completeRef wraps mark to fill in planetID on refs that lack one.
+func completeRef(planetID tag.UID, mark func(ref *amp.BlobRef)) func(ref *amp.BlobRef) {
+	return func(ref *amp.BlobRef) {
+		if ref.PlanetID_0 == 0 && ref.PlanetID_1 == 0 {
+			ref.PlanetID_0, ref.PlanetID_1 = planetID[0], planetID[1]
+		}
+		mark(ref)
+	}
+}
+
+// BlobRefValue is the Extractor for attrs holding an amp.BlobRef.
+func BlobRefValue(value []byte, mark func(ref *amp.BlobRef)) error {
+	ref := &amp.BlobRef{}
+	if err := proto.Unmarshal(value, ref); err != nil {
+		return err
+	}
+	mark(ref)
+	return nil
+}
+
+// TagsValue is the Extractor for attrs holding an amp.Tags tree: every Tag in
+// it with Units == Bytes names a stored blob by its UID (the BlobTag shape),
+// marked as a ref under the default HashKit.
+func TagsValue(value []byte, mark func(ref *amp.BlobRef)) error {
+	tags := &amp.Tags{}
+	if err := proto.Unmarshal(value, tags); err != nil {
+		return err
+	}
+	markTags(tags, mark)
+	return nil
+}
+
+func markTags(tags *amp.Tags, mark func(ref *amp.BlobRef)) {
+	if tags == nil {
+		return
+	}
+	markTag(tags.Head, mark)
+	for _, sub := range tags.SubTags {
+		markTag(sub, mark)
+	}
+	for _, child := range tags.Children {
+		markTags(child, mark)
+	}
+}
+
+func markTag(t *amp.Tag, mark func(ref *amp.BlobRef)) {
+	if t != nil && t.Units == amp.Units_Bytes && !t.NoUID() {
+		mark(&amp.BlobRef{BlobTag: t})
+	}
+}
+
+// refPlanet is the planet a BlobRef's blob is stored under.
+func refPlanet(ref *amp.BlobRef, fallback tag.UID) tag.UID {
+	if planetID := (tag.UID{ref.PlanetID_0, ref.PlanetID_1}); !planetID.IsNil() {
+		return planetID
+	}
+	return fallback
+}
@@ -0,0 +1,128 @@
+package blobgc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/amp/chronicle"
+	"github.com/art-media-platform/amp.SDK/amp/journal"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+func storeBlob(t *testing.T, store *blobstore.Dir, planetID tag.UID, text string) *amp.BlobRef {
+	t.Helper()
+	ref := &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1]}
+	if err := store.StoreHashed(ref, bytes.NewReader([]byte(text)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return ref
+}
+
+func appendTx(t *testing.T, j amp.TxJournal, planetID tag.UID, build func(tx *amp.TxMsg)) {
+	t.Helper()
+	txID := tag.NowID()
+	tx := amp.TxNew()
+	tx.SetTxID(txID)
+	tx.SetPlanetID(planetID)
+	build(tx)
+	var raw []byte
+	tx.MarshalToBuffer(&raw)
+	if err := j.Append(planetID, txID, raw); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkAndSweep(t *testing.T) {
+	planetID, nodeID := tag.NewID(), tag.NewID()
+	store, err := blobstore.OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeBlob := storeBlob(t, store, planetID, "node blob")
+	overwritten := storeBlob(t, store, planetID, "overwritten blob")
+	mediaBlob := storeBlob(t, store, planetID, "media source")
+	codexBlob := storeBlob(t, store, planetID, "named by a codex")
+	orphan := storeBlob(t, store, planetID, "abandoned upload")
+
+	j := journal.NewMemory()
+	defer j.Close()
+	appendTx(t, j, planetID, func(tx *amp.TxMsg) {
+		tx.Upsert(nodeID, std.Attr.NodeBlobs.ID, nodeBlob.StorageUID(), nodeBlob)
+		tx.Upsert(nodeID, std.Attr.BlobRef.ID, tag.UID{1}, overwritten)
+		tx.Upsert(nodeID, std.Attr.MediaSources.ID, tag.UID{2}, &amp.Tags{
+			Head:    &amp.Tag{Text: "sources"},
+			SubTags: []*amp.Tag{mediaBlob.BlobTag, {UID_0: 7, UID_1: 7}}, // a Tag without Units names no blob
+		})
+	})
+	appendTx(t, j, planetID, func(tx *amp.TxMsg) {
+		tx.Upsert(nodeID, std.Attr.BlobRef.ID, tag.UID{1}, nodeBlob) // history still names the overwritten blob
+	})
+
+	// A codex whose NodeBlobs artifact spills its value to the store.
+	var codex bytes.Buffer
+	cw, err := chronicle.NewCodexWriter(&codex, &amp.CodexHeader{SourcePlanet: amp.TagFromUID(planetID)}, chronicle.CodexOpts{
+		Blobs:           store,
+		InlineThreshold: 16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	refBytes, _ := proto.Marshal(codexBlob)
+	addr := tag.Address{}
+	addr.NodeID, addr.AttrID, addr.ItemID = nodeID, std.Attr.NodeBlobs.ID, codexBlob.StorageUID()
+	if err := cw.WriteValue(addr, refBytes); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	walker := NewWalker(nil)
+	live := NewLiveSet()
+	if err := walker.WalkJournal(live, j, planetID); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, live.Len(planetID), 3)
+	if err := walker.WalkCodex(live, bytes.NewReader(codex.Bytes()), store); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, live.Len(planetID), 5) // + codexBlob and the spilled value itself
+	status.Require(t, live.Has(planetID, orphan.StorageUID()), false)
+
+	// Everything was just published: the orphan is young, nothing is swept.
+	report, err := Sweep(store, planetID, live, SweepOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, report.Scanned, 6)
+	status.Require(t, report.Live, 5)
+	status.Require(t, len(report.Young), 1)
+	status.Require(t, len(report.Swept), 0)
+
+	// Past the grace period, a dry run reports the orphan and keeps it.
+	later := time.Now().Add(2 * DefaultGrace)
+	report, err = Sweep(store, planetID, live, SweepOpts{DryRun: true, Now: later})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(report.Swept), 1)
+	status.Require(t, report.Swept[0].BlobID, orphan.StorageUID())
+	status.Require(t, report.SweptBytes, int64(len("abandoned upload")))
+	status.Require(t, store.Has(planetID, orphan.StorageUID()), true)
+
+	report, err = Sweep(store, planetID, live, SweepOpts{Now: later})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(report.Swept), 1)
+	status.Require(t, store.Has(planetID, orphan.StorageUID()), false)
+	for _, ref := range []*amp.BlobRef{nodeBlob, overwritten, mediaBlob, codexBlob} {
+		status.Require(t, store.Has(planetID, ref.StorageUID()), true)
+	}
+}
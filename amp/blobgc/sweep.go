@@ -0,0 +1,86 @@
+package blobgc
+
+import (
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// DefaultGrace is how long a freshly published blob is spared from a sweep.
+const DefaultGrace = 24 * time.Hour
+
+// Store is a blob store that can be swept; *blobstore.Dir satisfies it.
+type Store interface {
+	EnumBlobs(planetID tag.UID, fn func(info blobstore.BlobInfo) bool) error
+	Remove(planetID tag.UID, blobID tag.UID) error
+}
+
+var _ Store = (*blobstore.Dir)(nil)
+
+// SweepOpts tunes a Sweep.
+type SweepOpts struct {
+	Grace  time.Duration // spare blobs published this recently (0 = DefaultGrace, < 0 = none)
+	DryRun bool          // report what would be swept; remove nothing
+	Now    time.Time     // reference time for Grace (zero = time.Now())
+}
+
+// Report is what a Sweep found and did.
+type Report struct {
+	PlanetID   tag.UID
+	DryRun     bool
+	Scanned    int                  // blobs enumerated
+	Live       int                  // blobs held by the LiveSet
+	Young      []blobstore.BlobInfo // unreferenced, but within the grace period
+	Swept      []blobstore.BlobInfo // unreferenced and removed (or, for a dry run, removable)
+	SweptBytes int64
+}
+
+// Sweep removes planetID's blobs that live does not hold and that were
+// published before the grace period.  live must be complete for planetID —
+// every journal and codex that can reference its blobs walked — since anything
+// it misses is swept.  An error stops the sweep; the report covers the blobs
+// handled so far.
+func Sweep(store Store, planetID tag.UID, live *LiveSet, opts SweepOpts) (*Report, error) {
+	if planetID.IsNil() || live == nil {
+		return nil, status.Code_BadRequest.Error("blobgc: Sweep requires a PlanetID and LiveSet")
+	}
+	grace := opts.Grace
+	if grace == 0 {
+		grace = DefaultGrace
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cutoff := now.Add(-max(grace, 0))
+
+	report := &Report{
+		PlanetID: planetID,
+		DryRun:   opts.DryRun,
+	}
+	var removeErr error
+	err := store.EnumBlobs(planetID, func(info blobstore.BlobInfo) bool {
+		report.Scanned++
+		switch {
+		case live.Has(planetID, info.BlobID):
+			report.Live++
+		case info.ModTime.After(cutoff):
+			report.Young = append(report.Young, info)
+		default:
+			if !opts.DryRun {
+				if removeErr = store.Remove(planetID, info.BlobID); removeErr != nil {
+					return false
+				}
+			}
+			report.Swept = append(report.Swept, info)
+			report.SweptBytes += info.Size
+		}
+		return true
+	})
+	if err == nil {
+		err = removeErr
+	}
+	return report, err
+}
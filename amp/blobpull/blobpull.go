@@ -0,0 +1,336 @@
+// Package blobpull fetches a blob from several peers at once: the receiver
+// pulls the blob's BlobMeta, verifies it against the ref's signed MetaRoot,
+// then spreads chunk spans across the peers with a bounded in-flight window
+// each (SD-planet-storage §13.10).
+//
+// Every arriving chunk is checked with BlobMeta.VerifyChunk before it is
+// kept; a chunk that fails — bad bytes, a short span, or a peer error — is
+// re-queued for a peer that has not yet failed it, so a transfer survives
+// any peer dropping out as long as another holds the blob.  Verified chunks
+// assemble in a temp file that is published through BlobStore.StoreValidated,
+// which re-checks the whole stream against BlobTag.UID.
+//
+// A blob at or under one grain carries no meta: it is pulled whole from the
+// first peer whose bytes StoreValidated accepts.
+package blobpull
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultWindow is the in-flight pull requests allowed per peer.
+	DefaultWindow = 4
+
+	// DefaultSpanChunks is the chunks asked for in one BlobPullRequest.
+	DefaultSpanChunks = 1
+)
+
+// Peer is one holder a blob can be pulled from.  PullBlob answers one
+// BlobPullRequest: the meta's canonical bytes for Kind=Meta, or the stored
+// bytes of the requested chunk span for Kind=Chunks.  It is called from
+// several goroutines at once (up to the window).
+type Peer interface {
+	PeerID() tag.UID
+	PullBlob(ctx context.Context, planetID tag.UID, req *amp.BlobPullRequest) ([]byte, error)
+}
+
+// Opts tunes a Fetch.
+type Opts struct {
+	Window     int    // in-flight requests per peer (0 = DefaultWindow)
+	SpanChunks int    // chunks per request (0 = DefaultSpanChunks)
+	TempDir    string // where chunks assemble ("" = os.TempDir())
+}
+
+// Stats reports how a Fetch went.
+type Stats struct {
+	Chunks   uint64            // chunks in the blob's meta (0 for a blob without one)
+	Retried  uint64            // chunk deliveries rejected and re-queued
+	ByteFrom map[tag.UID]int64 // verified stored bytes taken from each peer
+}
+
+// Fetch pulls the blob ref names from peers and publishes it to store under
+// planetID.  A blob already in store returns at once.
+func Fetch(ctx context.Context, store amp.BlobStore, planetID tag.UID, ref *amp.BlobRef, peers []Peer, opts Opts) (Stats, error) {
+	stats := Stats{ByteFrom: make(map[tag.UID]int64)}
+	if ref == nil || ref.BlobTag.NoUID() {
+		return stats, status.Code_BadRequest.Error("blobpull: Fetch requires a BlobRef with a BlobTag")
+	}
+	if store.Has(planetID, ref.BlobTag.UID()) {
+		return stats, nil
+	}
+	if len(peers) == 0 {
+		return stats, status.Code_NotConnected.Error("blobpull: no peers to pull from")
+	}
+	if !ref.HasBlobMeta() {
+		return stats, fetchWhole(ctx, store, planetID, ref, peers, &stats)
+	}
+
+	meta, err := fetchMeta(ctx, planetID, ref, peers)
+	if err != nil {
+		return stats, err
+	}
+	stats.Chunks = meta.NumChunks()
+
+	tmp, err := os.CreateTemp(opts.TempDir, "blobpull-*")
+	if err != nil {
+		return stats, status.Code_StorageFailure.Wrap(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sched := newScheduler(ctx, planetID, ref, meta, tmp, opts)
+	sched.run(peers)
+	stats.Retried = sched.retried
+	for peerID, n := range sched.byteFrom {
+		stats.ByteFrom[peerID] = n
+	}
+	if sched.err != nil {
+		return stats, sched.err
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return stats, status.Code_StorageFailure.Wrap(err)
+	}
+	return stats, store.StoreValidated(planetID, ref, tmp)
+}
+
+// fetchWhole pulls a meta-less blob in one request, peer by peer.
+func fetchWhole(ctx context.Context, store amp.BlobStore, planetID tag.UID, ref *amp.BlobRef, peers []Peer, stats *Stats) error {
+	req := &amp.BlobPullRequest{Ref: ref, Kind: amp.BlobPullKind_Chunks}
+	var lastErr error
+	for _, peer := range peers {
+		if err := ctx.Err(); err != nil {
+			return status.Code_Cancelled.Wrap(err)
+		}
+		buf, err := peer.PullBlob(ctx, planetID, req)
+		if err == nil {
+			err = store.StoreValidated(planetID, ref, bytes.NewReader(buf))
+		}
+		if err == nil {
+			stats.ByteFrom[peer.PeerID()] += int64(len(buf))
+			return nil
+		}
+		stats.Retried++
+		lastErr = err
+	}
+	return status.Code_ItemNotFound.Errorf("blobpull: no peer supplied blob %v: %v", ref.BlobTag.UID(), lastErr)
+}
+
+// fetchMeta pulls the BlobMeta from the first peer whose copy verifies
+// against the ref's MetaRoot.
+func fetchMeta(ctx context.Context, planetID tag.UID, ref *amp.BlobRef, peers []Peer) (*amp.BlobMeta, error) {
+	req := &amp.BlobPullRequest{Ref: ref, Kind: amp.BlobPullKind_Meta}
+	wantLen := amp.BlobMetaWireSize(ref.BlobTag.GetI(), ref.ChunkSizeLog2)
+	var lastErr error
+	for _, peer := range peers {
+		if err := ctx.Err(); err != nil {
+			return nil, status.Code_Cancelled.Wrap(err)
+		}
+		buf, err := peer.PullBlob(ctx, planetID, req)
+		if err == nil && ref.BlobTag.GetI() > 0 && int64(len(buf)) != wantLen {
+			err = status.Code_AuthFailed.Errorf("blobpull: meta is %d bytes, ref implies %d", len(buf), wantLen)
+		}
+		meta := &amp.BlobMeta{}
+		if err == nil {
+			err = proto.Unmarshal(buf, meta)
+		}
+		if err == nil {
+			err = ref.VerifyBlobMeta(meta)
+		}
+		if err == nil {
+			return meta, nil
+		}
+		lastErr = err
+	}
+	return nil, status.Code_ItemNotFound.Errorf("blobpull: no peer supplied a valid BlobMeta: %v", lastErr)
+}
+
+// scheduler hands chunk spans to per-peer workers and collects verified chunks.
+type scheduler struct {
+	ctx        context.Context
+	planetID   tag.UID
+	ref        *amp.BlobRef
+	meta       *amp.BlobMeta
+	out        *os.File
+	window     int
+	spanChunks uint64
+
+	mu       sync.Mutex
+	wake     *sync.Cond
+	pending  []uint64                        // chunk indexes awaiting a pull, ascending
+	failed   map[uint64]map[tag.UID]struct{} // chunk → peers that failed it
+	inFlight int                             // requests outstanding
+	done     uint64                          // chunks verified and written
+	retried  uint64
+	byteFrom map[tag.UID]int64
+	err      error
+}
+
+func newScheduler(ctx context.Context, planetID tag.UID, ref *amp.BlobRef, meta *amp.BlobMeta, out *os.File, opts Opts) *scheduler {
+	sched := &scheduler{
+		ctx:        ctx,
+		planetID:   planetID,
+		ref:        ref,
+		meta:       meta,
+		out:        out,
+		window:     opts.Window,
+		spanChunks: DefaultSpanChunks,
+		failed:     make(map[uint64]map[tag.UID]struct{}),
+		byteFrom:   make(map[tag.UID]int64),
+	}
+	if sched.window <= 0 {
+		sched.window = DefaultWindow
+	}
+	if opts.SpanChunks > 0 {
+		sched.spanChunks = uint64(opts.SpanChunks)
+	}
+	sched.wake = sync.NewCond(&sched.mu)
+	for idx := range meta.NumChunks() {
+		sched.pending = append(sched.pending, idx)
+	}
+	return sched
+}
+
+// run drives every peer's workers until each chunk is verified or no peer is
+// left to try for some chunk.
+func (sched *scheduler) run(peers []Peer) {
+	stop := context.AfterFunc(sched.ctx, func() {
+		sched.mu.Lock()
+		sched.fail(status.Code_Cancelled.Wrap(sched.ctx.Err()))
+		sched.mu.Unlock()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		for range sched.window {
+			wg.Go(func() { sched.work(peer) })
+		}
+	}
+	wg.Wait()
+
+	if sched.err == nil && sched.done < sched.meta.NumChunks() {
+		sched.err = status.Code_ItemNotFound.Errorf("blobpull: chunks %v of blob %v failed at every peer", sched.pending, sched.ref.BlobTag.UID())
+	}
+}
+
+// work pulls spans for one peer until nothing is left it could help with.
+func (sched *scheduler) work(peer Peer) {
+	peerID := peer.PeerID()
+	for {
+		begin, count, ok := sched.claim(peerID)
+		if !ok {
+			return
+		}
+		buf, err := peer.PullBlob(sched.ctx, sched.planetID, &amp.BlobPullRequest{
+			Ref:        sched.ref,
+			Kind:       amp.BlobPullKind_Chunks,
+			ChunkBegin: begin,
+			ChunkCount: count,
+		})
+		sched.absorb(peerID, begin, count, buf, err)
+	}
+}
+
+// claim takes the next run of pending chunks peerID has not failed, waiting
+// while only in-flight requests could still yield one.
+func (sched *scheduler) claim(peerID tag.UID) (begin, count uint64, ok bool) {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	for {
+		if sched.err != nil || len(sched.pending) == 0 && sched.inFlight == 0 {
+			return 0, 0, false
+		}
+		for ii, idx := range sched.pending {
+			if sched.hasFailed(idx, peerID) {
+				continue
+			}
+			end := ii + 1
+			for end < len(sched.pending) && uint64(end-ii) < sched.spanChunks &&
+				sched.pending[end] == idx+uint64(end-ii) && !sched.hasFailed(sched.pending[end], peerID) {
+				end++
+			}
+			sched.pending = slices.Delete(sched.pending, ii, end)
+			sched.inFlight++
+			return idx, uint64(end - ii), true
+		}
+		if sched.inFlight == 0 {
+			return 0, 0, false // every pending chunk already failed at this peer
+		}
+		sched.wake.Wait()
+	}
+}
+
+// absorb verifies and writes the chunks of one answered span; chunks that fail
+// return to pending, barred from this peer.
+func (sched *scheduler) absorb(peerID tag.UID, begin, count uint64, buf []byte, pullErr error) {
+	var verified int64
+	var rejected []uint64
+	for idx := begin; idx < begin+count; idx++ {
+		if pullErr != nil {
+			rejected = append(rejected, idx)
+			continue
+		}
+		offset, length := sched.meta.ChunkSpan(idx)
+		rel := offset - amp.BlobChunkOffset(begin, sched.meta.ChunkSizeLog2)
+		if rel+length > int64(len(buf)) {
+			rejected = append(rejected, idx)
+			continue
+		}
+		chunk := buf[rel : rel+length]
+		if err := sched.meta.VerifyChunk(idx, chunk, sched.ref.HashKitID); err != nil {
+			rejected = append(rejected, idx)
+			continue
+		}
+		if _, err := sched.out.WriteAt(chunk, offset); err != nil {
+			sched.mu.Lock()
+			sched.fail(status.Code_StorageFailure.Wrap(err))
+			sched.inFlight--
+			sched.mu.Unlock()
+			return
+		}
+		verified += length
+	}
+
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	sched.inFlight--
+	sched.done += count - uint64(len(rejected))
+	sched.byteFrom[peerID] += verified
+	for _, idx := range rejected {
+		peers := sched.failed[idx]
+		if peers == nil {
+			peers = make(map[tag.UID]struct{})
+			sched.failed[idx] = peers
+		}
+		peers[peerID] = struct{}{}
+		pos, _ := slices.BinarySearch(sched.pending, idx)
+		sched.pending = slices.Insert(sched.pending, pos, idx)
+		sched.retried++
+	}
+	sched.wake.Broadcast()
+}
+
+func (sched *scheduler) hasFailed(idx uint64, peerID tag.UID) bool {
+	_, failed := sched.failed[idx][peerID]
+	return failed
+}
+
+// fail records the first fatal error and wakes every worker; mu must be held.
+func (sched *scheduler) fail(err error) {
+	if sched.err == nil {
+		sched.err = err
+	}
+	sched.wake.Broadcast()
+}
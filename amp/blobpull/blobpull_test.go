@@ -0,0 +1,177 @@
+package blobpull
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// testPeer serves pulls from a store, optionally misbehaving.
+type testPeer struct {
+	id       tag.UID
+	store    *blobstore.Dir
+	corrupt  func(req *amp.BlobPullRequest) bool // flip a byte of this answer
+	failFrom int64                               // chunk pulls after this many fail (0 = never)
+	pulls    atomic.Int64
+}
+
+func (peer *testPeer) PeerID() tag.UID { return peer.id }
+
+func (peer *testPeer) PullBlob(ctx context.Context, planetID tag.UID, req *amp.BlobPullRequest) ([]byte, error) {
+	if req.Kind == amp.BlobPullKind_Chunks {
+		if n := peer.pulls.Add(1); peer.failFrom > 0 && n > peer.failFrom {
+			return nil, status.Code_NotConnected.Error("peer went away")
+		}
+	}
+	buf, err := Serve(peer.store, planetID, req)
+	if err == nil && peer.corrupt != nil && peer.corrupt(req) {
+		buf[len(buf)/2] ^= 0xFF
+	}
+	return buf, err
+}
+
+func openStore(t *testing.T) *blobstore.Dir {
+	t.Helper()
+	store, err := blobstore.OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// seed stores a random blob of the given size in each holder and returns its ref.
+func seed(t *testing.T, planetID tag.UID, size int, holders ...*blobstore.Dir) (*amp.BlobRef, []byte) {
+	t.Helper()
+	blob := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(blob)
+	var ref *amp.BlobRef
+	for _, store := range holders {
+		ref = &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1]}
+		if err := store.StoreHashed(ref, bytes.NewReader(blob), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ref, blob
+}
+
+func requireBlob(t *testing.T, store *blobstore.Dir, planetID tag.UID, ref *amp.BlobRef, want []byte) {
+	t.Helper()
+	r, err := store.Retrieve(planetID, ref.BlobTag.UID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	got, _ := io.ReadAll(r)
+	if !bytes.Equal(got, want) {
+		t.Fatal("fetched blob differs from the original")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	planetID := tag.NewID()
+	a, b, c, local := openStore(t), openStore(t), openStore(t), openStore(t)
+	ref, blob := seed(t, planetID, 6<<20+123, a, b, c)
+
+	peers := []Peer{
+		// A serves a forged meta and a bad chunk 2.
+		&testPeer{id: tag.UID{0, 1}, store: a, corrupt: func(req *amp.BlobPullRequest) bool {
+			return req.Kind == amp.BlobPullKind_Meta || req.ChunkBegin == 2
+		}},
+		// B drops out after two chunk pulls.
+		&testPeer{id: tag.UID{0, 2}, store: b, failFrom: 2},
+		&testPeer{id: tag.UID{0, 3}, store: c},
+	}
+	stats, err := Fetch(context.Background(), local, planetID, ref, peers, Opts{Window: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireBlob(t, local, planetID, ref, blob)
+	status.Require(t, stats.Chunks, uint64(7))
+
+	total := int64(0)
+	for _, n := range stats.ByteFrom {
+		total += n
+	}
+	status.Require(t, total, int64(len(blob)))
+	if stats.ByteFrom[tag.UID{0, 3}] == 0 {
+		t.Fatal("the reliable peer supplied nothing")
+	}
+
+	// Already held: nothing to pull.
+	stats, err = Fetch(context.Background(), local, planetID, ref, nil, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(stats.ByteFrom), 0)
+}
+
+func TestFetchSpans(t *testing.T) {
+	planetID := tag.NewID()
+	a, local := openStore(t), openStore(t)
+	ref, blob := seed(t, planetID, 5<<20, a)
+
+	peer := &testPeer{id: tag.NewID(), store: a}
+	if _, err := Fetch(context.Background(), local, planetID, ref, []Peer{peer}, Opts{SpanChunks: 2, Window: 1}); err != nil {
+		t.Fatal(err)
+	}
+	requireBlob(t, local, planetID, ref, blob)
+	status.Require(t, peer.pulls.Load(), int64(3))
+}
+
+func TestFetchFails(t *testing.T) {
+	planetID := tag.NewID()
+	a, b, local := openStore(t), openStore(t), openStore(t)
+	ref, _ := seed(t, planetID, 3<<20, a, b)
+
+	// Both holders corrupt chunk 1: the fetch fails and nothing is published.
+	badChunk := func(req *amp.BlobPullRequest) bool {
+		return req.Kind == amp.BlobPullKind_Chunks && req.ChunkBegin == 1
+	}
+	peers := []Peer{
+		&testPeer{id: tag.NewID(), store: a, corrupt: badChunk},
+		&testPeer{id: tag.NewID(), store: b, corrupt: badChunk},
+	}
+	stats, err := Fetch(context.Background(), local, planetID, ref, peers, Opts{})
+	if !status.IsError(err, status.Code_ItemNotFound) {
+		t.Fatalf("expected ItemNotFound, got %v", err)
+	}
+	status.Require(t, stats.Retried, uint64(2))
+	status.Require(t, local.Has(planetID, ref.BlobTag.UID()), false)
+
+	// A cancelled fetch stops.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Fetch(ctx, local, planetID, ref, peers[:1], Opts{})
+	if !status.IsError(err, status.Code_Cancelled) {
+		t.Fatalf("expected Cancelled, got %v", err)
+	}
+}
+
+func TestFetchWhole(t *testing.T) {
+	planetID := tag.NewID()
+	a, b, local := openStore(t), openStore(t), openStore(t)
+	ref, blob := seed(t, planetID, 1000, a, b)
+	if ref.HasBlobMeta() {
+		t.Fatal("a one-grain blob carries no meta")
+	}
+
+	peers := []Peer{
+		&testPeer{id: tag.UID{0, 1}, store: a, corrupt: func(*amp.BlobPullRequest) bool { return true }},
+		&testPeer{id: tag.UID{0, 2}, store: b},
+	}
+	stats, err := Fetch(context.Background(), local, planetID, ref, peers, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireBlob(t, local, planetID, ref, blob)
+	status.Require(t, stats.Retried, uint64(1))
+	status.Require(t, stats.ByteFrom[tag.UID{0, 2}], int64(1000))
+}
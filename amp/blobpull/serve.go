@@ -0,0 +1,57 @@
+package blobpull
+
+import (
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Serve answers one BlobPullRequest from a local store — the holder side of
+// Peer.PullBlob.  Kind=Meta returns the BlobMeta's canonical bytes; Kind=Chunks
+// returns the stored bytes of the span BlobPullSpan resolves.
+func Serve(store *blobstore.Dir, planetID tag.UID, req *amp.BlobPullRequest) ([]byte, error) {
+	if req.Ref == nil || req.Ref.BlobTag.NoUID() {
+		return nil, status.Code_BadRequest.Error("blobpull: pull request names no blob")
+	}
+	blobID := req.Ref.BlobTag.UID()
+
+	if req.Kind == amp.BlobPullKind_Meta {
+		meta, err := store.Meta(planetID, blobID)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil {
+			return nil, status.Code_ItemNotFound.Errorf("blobpull: blob %v carries no BlobMeta", blobID)
+		}
+		return meta.CanonicalBytes(), nil
+	}
+
+	src, err := store.Retrieve(planetID, blobID)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	file, ok := src.(io.ReadSeeker)
+	if !ok {
+		return nil, status.Code_Unimplemented.Error("blobpull: store reader is not seekable")
+	}
+	blobLen, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	offset, spanLen, ok := amp.BlobPullSpan(blobLen, req.Ref.ChunkSizeLog2, req.ChunkBegin, req.ChunkCount)
+	if !ok {
+		return nil, status.Code_BadRequest.Errorf("blobpull: chunk span %d+%d is outside blob %v", req.ChunkBegin, req.ChunkCount, blobID)
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	buf := make([]byte, spanLen)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,370 @@
+// Package blobstore provides a directory-backed amp.BlobStore.
+//
+// Blobs are content-addressed under their storage identity (BlobTag.UID — the
+// leading 16 bytes of the HashKit digest over the stored bytes):
+//
+//	<root>/<PlanetID>/<shard>/<BlobID>         stored bytes
+//	<root>/<PlanetID>/<shard>/<BlobID>.meta    BlobMeta companion
+//	<root>/<PlanetID>/.tmp-*                   ingest in flight
+//	<root>/<PlanetID>/.partial-<key>[.ckpt]    resumable ingest (see Resumable)
+//
+// UIDs render as tag.UID.Base16; a shard is the BlobID's low byte in two hex
+// digits.
+//
+// Every write path streams into a temp file under the planet dir — hashing and
+// minting the blob's BlobMeta in the same single pass, in O(1) memory — and
+// publishes by atomic rename, so a reader never sees a partial blob and a failed
+// or invalid transfer never lands at a content address.  Re-storing a blob that
+// already exists discards the temp and succeeds.
+//
+// The BlobMeta companion (SD-planet-storage §13.10) is written beside any blob
+// over one grain that arrives with its HashKit known — StoreHashed,
+// StoreValidated, StoreResumable — so the store can serve chunk pulls and the
+// meta itself.  A companion that differs from the one minted under the arriving
+// ref's kit is rewritten, even when the blob itself is already published.
+package blobstore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/platform"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metaExt   = ".meta"
+	tmpPrefix = platform.AtomicTempPrefix
+
+	// copyBufSize is the ingest buffer — the only per-transfer allocation.
+	copyBufSize = 256 << 10
+)
+
+// Dir is a directory-backed amp.BlobStore.  All methods are threadsafe: the
+// store holds no mutable state beyond the filesystem, and every publish is an
+// atomic rename of identical content.
+type Dir struct {
+	root string
+}
+
+var _ amp.BlobStore = (*Dir)(nil)
+
+// OpenDir opens (creating if needed) a blob store rooted at root and discards
+// temp files left by interrupted ingests.
+func OpenDir(root string) (*Dir, error) {
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	store := &Dir{
+		root: root,
+	}
+	// Ingest temps stage in the planet dir; meta companions (WriteFileAtomic)
+	// stage beside their blob, in its shard.
+	for _, pattern := range []string{
+		filepath.Join(root, "*", tmpPrefix+"*"),
+		filepath.Join(root, "*", "*", tmpPrefix+"*"),
+	} {
+		stale, _ := filepath.Glob(pattern)
+		for _, path := range stale {
+			os.Remove(path)
+		}
+	}
+	return store, nil
+}
+
+// Store writes data under a caller-supplied blobID without deriving or
+// validating it.  A positive byteSize must match the streamed length.  No BlobMeta
+// companion is written — the kit a puller verifies chunks under is unknown here —
+// until the blob arrives again through a path that names its ref.
+func (store *Dir) Store(planetID tag.UID, blobID tag.UID, data io.Reader, byteSize int64) error {
+	if planetID.IsNil() || blobID.IsNil() {
+		return status.Code_BadRequest.Error("blobstore: Store requires a PlanetID and BlobID")
+	}
+	staged, err := store.ingest(planetID, safe.HashKitID_Blake2s_256, data, nil)
+	if err != nil {
+		return err
+	}
+	defer staged.discard()
+	staged.meta = nil
+
+	if byteSize > 0 && staged.size != byteSize {
+		return status.Code_DataFailure.Errorf("blobstore: Store: streamed %d bytes, expected %d", staged.size, byteSize)
+	}
+	return store.publish(staged, planetID, blobID)
+}
+
+// Retrieve opens the stored bytes of a blob.  The returned reader is an
+// *os.File, so it also satisfies data.AssetReader (seekable).
+func (store *Dir) Retrieve(planetID tag.UID, blobID tag.UID) (io.ReadCloser, error) {
+	file, err := os.Open(store.blobPath(planetID, blobID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, status.Code_ItemNotFound.Errorf("blobstore: blob %v not found", blobID)
+		}
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return file, nil
+}
+
+// Has reports whether the blob is published.
+func (store *Dir) Has(planetID tag.UID, blobID tag.UID) bool {
+	_, err := os.Stat(store.blobPath(planetID, blobID))
+	return err == nil
+}
+
+// Meta returns the BlobMeta companion of a published blob, or nil when the blob
+// carries none (it is at or under one grain, or arrived only via Store).
+func (store *Dir) Meta(planetID tag.UID, blobID tag.UID) (*amp.BlobMeta, error) {
+	buf, err := os.ReadFile(store.blobPath(planetID, blobID) + metaExt)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		if !store.Has(planetID, blobID) {
+			return nil, status.Code_ItemNotFound.Errorf("blobstore: blob %v not found", blobID)
+		}
+		return nil, nil
+	}
+	meta := &amp.BlobMeta{}
+	if err := proto.Unmarshal(buf, meta); err != nil {
+		return nil, status.Code_DataFailure.Wrap(err)
+	}
+	return meta, nil
+}
+
+// StoreHashed hashes and stores planet-public data in a single streaming pass,
+// then populates ref's content addresses: Hash_0..3, AssetTag (UID, byte length;
+// ContentType and Text kept as given), BlobTag, and the BlobMeta commitment.
+func (store *Dir) StoreHashed(ref *amp.BlobRef, data io.Reader, onProgress func(bytesWritten int64)) error {
+	if ref == nil {
+		return status.Code_BadRequest.Error("blobstore: StoreHashed requires a BlobRef")
+	}
+	planetID := tag.UID{ref.PlanetID_0, ref.PlanetID_1}
+	if planetID.IsNil() {
+		return status.Code_BadRequest.Error("blobstore: StoreHashed requires ref.PlanetID")
+	}
+	if !ref.IsPublic() {
+		return status.Code_BadRequest.Error("blobstore: StoreHashed stores planet-public data; a sealed blob arrives via StoreValidated")
+	}
+	staged, err := store.ingest(planetID, ref.HashKitID, data, onProgress)
+	if err != nil {
+		return err
+	}
+	defer staged.discard()
+
+	blobID := staged.uid()
+	if err := store.publish(staged, planetID, blobID); err != nil {
+		return err
+	}
+	return fillRef(ref, staged)
+}
+
+// fillRef populates a planet-public ref from its published stream: Hash_0..3,
+// AssetTag (UID, byte length), BlobTag, and the BlobMeta commitment.
+func fillRef(ref *amp.BlobRef, st *staged) error {
+	blobID := st.uid()
+	ref.Hash_0 = binary.BigEndian.Uint64(st.digest[0:8])
+	ref.Hash_1 = binary.BigEndian.Uint64(st.digest[8:16])
+	ref.Hash_2 = binary.BigEndian.Uint64(st.digest[16:24])
+	ref.Hash_3 = binary.BigEndian.Uint64(st.digest[24:32])
+	if ref.AssetTag == nil {
+		ref.AssetTag = &amp.Tag{}
+	}
+	ref.AssetTag.SetID(blobID)
+	ref.AssetTag.I = st.size
+	ref.AssetTag.Units = amp.Units_Bytes
+	ref.BlobTag = &amp.Tag{
+		I:     st.size,
+		Units: amp.Units_Bytes,
+	}
+	ref.BlobTag.SetID(blobID)
+
+	ref.MetaRoot_0, ref.MetaRoot_1, ref.ChunkSizeLog2 = 0, 0, 0
+	if st.meta != nil {
+		return ref.SetBlobMeta(st.meta, ref.HashKitID)
+	}
+	return nil
+}
+
+// StoreValidated streams a peer-supplied blob into a temp file, hashing it with
+// ref.HashKitID, and publishes it only when hash(stream)[:16] == ref.BlobTag.UID
+// (and, when BlobTag.I is set, the length matches).  The asset identity is not
+// recomputed — no epoch key is needed.
+func (store *Dir) StoreValidated(planetID tag.UID, ref *amp.BlobRef, data io.Reader) error {
+	if ref == nil || ref.BlobTag.NoUID() {
+		return status.Code_BadRequest.Error("blobstore: StoreValidated requires a BlobRef with a BlobTag")
+	}
+	if planetID.IsNil() {
+		return status.Code_BadRequest.Error("blobstore: StoreValidated requires a PlanetID")
+	}
+	staged, err := store.ingest(planetID, ref.HashKitID, data, nil)
+	if err != nil {
+		return err
+	}
+	defer staged.discard()
+
+	blobID := ref.BlobTag.UID()
+	if staged.uid() != blobID {
+		return status.Code_AuthFailed.Errorf("blobstore: StoreValidated: stream hashes to %v, ref names %v", staged.uid(), blobID)
+	}
+	if ref.BlobTag.I > 0 && staged.size != ref.BlobTag.I {
+		return status.Code_AuthFailed.Errorf("blobstore: StoreValidated: streamed %d bytes, ref names %d", staged.size, ref.BlobTag.I)
+	}
+	return store.publish(staged, planetID, blobID)
+}
+
+// staged is one ingested stream awaiting publish: a synced temp file plus the
+// digest and BlobMeta minted in the same pass.
+type staged struct {
+	tmpPath string
+	size    int64
+	digest  []byte
+	meta    *amp.BlobMeta
+}
+
+// uid is the stream's content address — the leading 16 bytes of its digest.
+func (st *staged) uid() tag.UID {
+	return tag.UID{
+		binary.BigEndian.Uint64(st.digest[0:8]),
+		binary.BigEndian.Uint64(st.digest[8:16]),
+	}
+}
+
+// discard removes the temp file; a no-op once published.
+func (st *staged) discard() {
+	if st.tmpPath != "" {
+		os.Remove(st.tmpPath)
+	}
+}
+
+// ingest streams src into a temp file under the planet dir, hashing it and
+// minting its BlobMeta in one pass with a fixed-size buffer.
+func (store *Dir) ingest(planetID tag.UID, kitID safe.HashKitID, src io.Reader, onProgress func(int64)) (*staged, error) {
+	if src == nil {
+		return nil, status.Code_BadRequest.Error("blobstore: nil data reader")
+	}
+	kit, err := safe.NewHashKit(kitID)
+	if err != nil {
+		return nil, err
+	}
+	builder, err := amp.NewBlobMetaBuilder(kitID)
+	if err != nil {
+		return nil, err
+	}
+
+	planetDir := store.planetDir(planetID)
+	if err := os.MkdirAll(planetDir, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	tmp, err := os.CreateTemp(planetDir, tmpPrefix+"*")
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	st := &staged{
+		tmpPath: tmp.Name(),
+	}
+	fail := func(err error) (*staged, error) {
+		tmp.Close()
+		st.discard()
+		return nil, err
+	}
+
+	kit.Hasher.Reset()
+	sink := io.MultiWriter(tmp, kit.Hasher, builder)
+	buf := make([]byte, copyBufSize)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := sink.Write(buf[:n]); err != nil {
+				return fail(status.Code_StorageFailure.Wrap(err))
+			}
+			st.size += int64(n)
+			if onProgress != nil {
+				onProgress(st.size)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fail(status.Code_StorageFailure.Wrap(readErr))
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(status.Code_StorageFailure.Wrap(err))
+	}
+	if err := tmp.Close(); err != nil {
+		return fail(status.Code_StorageFailure.Wrap(err))
+	}
+
+	st.digest = kit.Hasher.Sum(nil)
+	if st.meta, err = builder.Finish(); err != nil {
+		st.discard()
+		return nil, err
+	}
+	return st, nil
+}
+
+// publish renames a staged temp to its content address, companion meta first so
+// a published blob over one grain never lacks one.  An existing blob wins: the
+// temp is discarded, the blob's mtime is refreshed (so a re-stored blob earns a
+// fresh sweep grace period), its companion is brought in line with st.meta, and
+// publish succeeds.
+func (store *Dir) publish(st *staged, planetID, blobID tag.UID) error {
+	final := store.blobPath(planetID, blobID)
+	if _, err := os.Stat(final); err == nil {
+		now := time.Now()
+		os.Chtimes(final, now, now)
+		return store.publishMeta(final, st.meta)
+	}
+	if err := os.MkdirAll(filepath.Dir(final), 0700); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if err := store.publishMeta(final, st.meta); err != nil {
+		return err
+	}
+	if err := os.Rename(st.tmpPath, final); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	st.tmpPath = ""
+	return nil
+}
+
+// publishMeta writes meta as the companion of the blob at blobPath unless an
+// identical one is already there.  A nil meta leaves any companion as is.
+func (store *Dir) publishMeta(blobPath string, meta *amp.BlobMeta) error {
+	if meta == nil {
+		return nil
+	}
+	canonical := meta.CanonicalBytes()
+	if held, err := os.ReadFile(blobPath + metaExt); err == nil && bytes.Equal(held, canonical) {
+		return nil
+	}
+	if err := platform.WriteFileAtomic(blobPath+metaExt, canonical); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+func (store *Dir) planetDir(planetID tag.UID) string {
+	return filepath.Join(store.root, planetID.Base16())
+}
+
+func (store *Dir) blobPath(planetID, blobID tag.UID) string {
+	return filepath.Join(store.planetDir(planetID), shardName(blobID), blobID.Base16())
+}
+
+// shardName spreads a planet's blobs over 256 subdirectories by the low byte of
+// the BlobID (a digest, so uniformly distributed).
+func shardName(blobID tag.UID) string {
+	return fmt.Sprintf("%02X", byte(blobID[1]))
+}
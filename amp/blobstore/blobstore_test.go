@@ -0,0 +1,352 @@
+package blobstore
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+func openTest(t *testing.T) *Dir {
+	t.Helper()
+	store, err := OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func testBlob(size int) []byte {
+	buf := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(buf)
+	return buf
+}
+
+func readBlob(t *testing.T, store *Dir, planetID, blobID tag.UID) []byte {
+	t.Helper()
+	r, err := store.Retrieve(planetID, blobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+// requireNoTemps fatals if any ingest temp survived.
+func requireNoTemps(t *testing.T, store *Dir, planetID tag.UID) {
+	t.Helper()
+	stale, _ := filepath.Glob(filepath.Join(store.planetDir(planetID), tmpPrefix+"*"))
+	if len(stale) != 0 {
+		t.Fatalf("ingest left %d temp files", len(stale))
+	}
+}
+
+func TestStoreHashed(t *testing.T) {
+	store := openTest(t)
+	planetID := tag.NewID()
+
+	for _, size := range []int{0, 1, 4096, 3<<20 + 17} {
+		blob := testBlob(size)
+		ref := &amp.BlobRef{
+			PlanetID_0: planetID[0],
+			PlanetID_1: planetID[1],
+			AssetTag:   &amp.Tag{Text: "photo.jpg"},
+		}
+		var progress []int64
+		err := store.StoreHashed(ref, bytes.NewReader(blob), func(n int64) {
+			progress = append(progress, n)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		blobID := ref.BlobTag.UID()
+		status.Require(t, blobID, ref.AssetTag.UID())
+		status.Require(t, blobID, tag.UID{ref.Hash_0, ref.Hash_1})
+		status.Require(t, ref.BlobTag.I, int64(size))
+		status.Require(t, ref.AssetTag.I, int64(size))
+		status.Require(t, ref.AssetTag.Text, "photo.jpg")
+		status.Require(t, store.Has(planetID, blobID), true)
+		if !bytes.Equal(readBlob(t, store, planetID, blobID), blob) {
+			t.Fatalf("size %d: retrieved bytes differ", size)
+		}
+		if size > 0 {
+			status.Require(t, progress[len(progress)-1], int64(size))
+		}
+
+		// Blobs over one grain carry a BlobMeta that verifies against the ref.
+		meta, err := store.Meta(planetID, blobID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, meta != nil, size > 4096)
+		status.Require(t, ref.HasBlobMeta(), size > 4096)
+		if meta != nil {
+			if err := ref.VerifyBlobMeta(meta); err != nil {
+				t.Fatal(err)
+			}
+			offset, length := meta.ChunkSpan(1)
+			if err := meta.VerifyChunk(1, blob[offset:offset+length], ref.HashKitID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		requireNoTemps(t, store, planetID)
+	}
+}
+
+func TestStoreIdempotent(t *testing.T) {
+	store := openTest(t)
+	planetID := tag.NewID()
+	blob := testBlob(100 << 10)
+
+	var refs [2]*amp.BlobRef
+	for i := range refs {
+		refs[i] = &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1]}
+		if err := store.StoreHashed(refs[i], bytes.NewReader(blob), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status.Require(t, refs[1].BlobTag.UID(), refs[0].BlobTag.UID())
+	status.Require(t, refs[1].MetaRoot_0, refs[0].MetaRoot_0)
+	requireNoTemps(t, store, planetID)
+
+	// A sealed ref is not StoreHashed's to address.
+	sealed := &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1], EpochID_0: 1}
+	err := store.StoreHashed(sealed, bytes.NewReader(blob), nil)
+	if !status.IsError(err, status.Code_BadRequest) {
+		t.Fatalf("expected BadRequest, got %v", err)
+	}
+}
+
+func TestStoreValidated(t *testing.T) {
+	store := openTest(t)
+	planetID := tag.NewID()
+	blob := testBlob(200 << 10)
+
+	ref := &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1]}
+	origin := openTest(t)
+	if err := origin.StoreHashed(ref, bytes.NewReader(blob), nil); err != nil {
+		t.Fatal(err)
+	}
+	blobID := ref.BlobTag.UID()
+
+	// A tampered stream never lands at the content address.
+	tampered := bytes.Clone(blob)
+	tampered[1000] ^= 1
+	err := store.StoreValidated(planetID, ref, bytes.NewReader(tampered))
+	if !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed, got %v", err)
+	}
+	err = store.StoreValidated(planetID, ref, bytes.NewReader(blob[:len(blob)-1]))
+	if !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed for a short stream, got %v", err)
+	}
+	status.Require(t, store.Has(planetID, blobID), false)
+	requireNoTemps(t, store, planetID)
+
+	for range 2 {
+		if err := store.StoreValidated(planetID, ref, bytes.NewReader(blob)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(readBlob(t, store, planetID, blobID), blob) {
+		t.Fatal("retrieved bytes differ")
+	}
+	meta, err := store.Meta(planetID, blobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ref.VerifyBlobMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreMetaFollowsKit(t *testing.T) {
+	store := openTest(t)
+	planetID := tag.NewID()
+	blob := testBlob(300 << 10)
+
+	ref := &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1], HashKitID: safe.HashKitID_Blake3_256}
+	if err := openTest(t).StoreHashed(ref, bytes.NewReader(blob), nil); err != nil {
+		t.Fatal(err)
+	}
+	blobID := ref.BlobTag.UID()
+
+	// Store can't know the kit, so it writes no meta for pullers to trip on.
+	if err := store.Store(planetID, blobID, bytes.NewReader(blob), 0); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := store.Meta(planetID, blobID)
+	status.Require(t, meta == nil && err == nil, true)
+
+	// A companion minted under another kit is replaced once the ref arrives.
+	stale, err := amp.NewBlobMetaBuilder(safe.HashKitID_Blake2s_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.Write(blob)
+	staleMeta, _ := stale.Finish()
+	os.WriteFile(store.blobPath(planetID, blobID)+metaExt, staleMeta.CanonicalBytes(), 0600)
+	if err := store.StoreValidated(planetID, ref, bytes.NewReader(blob)); err != nil {
+		t.Fatal(err)
+	}
+	if meta, err = store.Meta(planetID, blobID); err != nil {
+		t.Fatal(err)
+	}
+	if err := ref.VerifyBlobMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	offset, length := meta.ChunkSpan(0)
+	if err := meta.VerifyChunk(0, blob[offset:offset+length], ref.HashKitID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreAndRetrieve(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planetID, blobID := tag.NewID(), tag.NewID()
+
+	_, err = store.Retrieve(planetID, blobID)
+	if !status.IsError(err, status.Code_ItemNotFound) {
+		t.Fatalf("expected ItemNotFound, got %v", err)
+	}
+	err = store.Store(planetID, blobID, bytes.NewReader([]byte("hello")), 6)
+	if !status.IsError(err, status.Code_DataFailure) {
+		t.Fatalf("expected DataFailure, got %v", err)
+	}
+	if err := store.Store(planetID, blobID, bytes.NewReader([]byte("hello")), 5); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, string(readBlob(t, store, planetID, blobID)), "hello")
+
+	// Temps left by an interrupted ingest or meta write are discarded at open.
+	stale := filepath.Join(store.planetDir(planetID), tmpPrefix+"crashed")
+	os.WriteFile(stale, []byte("torn"), 0600)
+	staleMeta := filepath.Join(filepath.Dir(store.blobPath(planetID, blobID)), tmpPrefix+"meta-crashed")
+	os.WriteFile(staleMeta, []byte("torn"), 0600)
+	if _, err := OpenDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{stale, staleMeta} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("stale temp %s not discarded", filepath.Base(path))
+		}
+	}
+	status.Require(t, string(readBlob(t, store, planetID, blobID)), "hello")
+}
+
+// failingReader yields src until limit bytes, then fails.
+type failingReader struct {
+	*bytes.Reader
+	limit int64
+}
+
+func (r *failingReader) Read(buf []byte) (int, error) {
+	pos, _ := r.Seek(0, io.SeekCurrent)
+	if pos >= r.limit {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return r.Reader.Read(buf[:min(int64(len(buf)), r.limit-pos)])
+}
+
+func TestStoreResumable(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := OpenDir(dir)
+	planetID := tag.NewID()
+	blob := testBlob(5<<20 + 4321)
+	newRef := func() *amp.BlobRef {
+		return &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1], AssetTag: &amp.Tag{Text: "take.mov"}}
+	}
+	want := newRef()
+	if err := openTest(t).StoreHashed(want, bytes.NewReader(blob), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, corrupt := range []bool{false, true} {
+		key := tag.NewID()
+		interrupted := &failingReader{bytes.NewReader(blob), 3 << 20}
+		if err := store.StoreResumable(newRef(), key, interrupted, nil); err == nil {
+			t.Fatal("interrupted ingest succeeded")
+		}
+		partial := filepath.Join(store.planetDir(planetID), partialPrefix+key.Base16())
+		if corrupt {
+			file, _ := os.OpenFile(partial, os.O_RDWR, 0)
+			file.WriteAt([]byte{0xEE}, 3<<20-10)
+			file.Close()
+		}
+
+		// A restart keeps the partial; the ingest resumes from its checkpoint,
+		// or from zero when the partial no longer verifies.
+		store, _ = OpenDir(dir)
+		res, err := store.OpenResumable(newRef(), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantOffset := int64(3 << 20)
+		if corrupt {
+			wantOffset = 0
+		}
+		status.Require(t, res.Offset(), wantOffset)
+		res.Close()
+
+		var first int64 = -1
+		ref := newRef()
+		err = store.StoreResumable(ref, key, bytes.NewReader(blob), func(n int64) {
+			if first < 0 {
+				first = n
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first <= wantOffset {
+			t.Fatalf("first progress at %d; expected to resume past %d", first, wantOffset)
+		}
+		status.Require(t, ref.BlobTag.UID(), want.BlobTag.UID())
+		status.Require(t, tag.UID{ref.Hash_2, ref.Hash_3}, tag.UID{want.Hash_2, want.Hash_3})
+		status.Require(t, ref.MetaRootUID(), want.MetaRootUID())
+		status.Require(t, ref.AssetTag.Text, "take.mov")
+		if !bytes.Equal(readBlob(t, store, planetID, ref.BlobTag.UID()), blob) {
+			t.Fatal("retrieved bytes differ")
+		}
+		if _, err := os.Stat(partial); !os.IsNotExist(err) {
+			t.Fatal("Finish left the partial file")
+		}
+		if _, err := os.Stat(partial + ckptExt); !os.IsNotExist(err) {
+			t.Fatal("Finish left the checkpoint")
+		}
+	}
+
+	// Abort discards the partial and its checkpoint.
+	key := tag.NewID()
+	res, err := store.OpenResumable(newRef(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Write(blob[:1<<20])
+	res.Close()
+	if res, err = store.OpenResumable(newRef(), key); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, res.Offset(), int64(1<<20))
+	if err := res.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	leftover, _ := filepath.Glob(filepath.Join(store.planetDir(planetID), partialPrefix+"*"))
+	status.Require(t, len(leftover), 0)
+}
@@ -0,0 +1,73 @@
+package blobstore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// BlobInfo describes one published blob.
+type BlobInfo struct {
+	BlobID  tag.UID
+	Size    int64     // stored bytes
+	ModTime time.Time // when the blob was last published (or re-stored)
+}
+
+// EnumBlobs calls fn for each blob published under planetID, in no particular
+// order, until fn returns false.  Temp, partial, and companion files are skipped.
+func (store *Dir) EnumBlobs(planetID tag.UID, fn func(info BlobInfo) bool) error {
+	shards, err := os.ReadDir(store.planetDir(planetID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	for _, shard := range shards {
+		if !shard.IsDir() || len(shard.Name()) != 2 {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(store.planetDir(planetID), shard.Name()))
+		if err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || strings.HasPrefix(name, ".") {
+				continue
+			}
+			blobID, err := tag.UID_ParseBase16(name)
+			if err != nil || shardName(blobID) != shard.Name() {
+				continue // companion or foreign file
+			}
+			fi, err := entry.Info()
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue // removed since ReadDir
+				}
+				return status.Code_StorageFailure.Wrap(err)
+			}
+			if !fn(BlobInfo{BlobID: blobID, Size: fi.Size(), ModTime: fi.ModTime()}) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Remove deletes a published blob and its BlobMeta companion.  Removing a blob
+// that is not present is not an error.
+func (store *Dir) Remove(planetID tag.UID, blobID tag.UID) error {
+	path := store.blobPath(planetID, blobID)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if err := os.Remove(path + metaExt); err != nil && !os.IsNotExist(err) {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,344 @@
+package blobstore
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/platform"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	partialPrefix = ".partial-"
+	ckptExt       = ".ckpt"
+
+	// IngestCheckpointBytes is how often a Resumable checkpoints — a multiple
+	// of the minimum meta chunk, so every checkpoint sits on a chunk boundary.
+	// Pre-spill the builder's checkpoint carries its grain digests (32 B per
+	// 4 KiB), so the interval trades rewrite volume against redone bytes.
+	IngestCheckpointBytes = 64 << 20
+
+	// resumeTailLen is how much stored data before a checkpoint is re-hashed
+	// on resume to confirm the partial file still holds what was checkpointed.
+	resumeTailLen = 1 << amp.BlobChunkSizeLog2Min
+)
+
+// Resumable is a StoreHashed that survives interruption: the stream lands in
+// a partial file named by a caller-chosen key and checkpoints every
+// IngestCheckpointBytes, so reopening the same key continues from the last
+// checkpoint whose trailing chunk still verifies — and publishes the same
+// BlobRef an uninterrupted run would.  Partial files outlive OpenDir's temp
+// sweep; Abort or Finish removes them.  Not threadsafe.
+type Resumable struct {
+	store    *Dir
+	ref      *amp.BlobRef
+	planetID tag.UID
+	path     string // partial stored bytes
+	file     *os.File
+	kit      safe.HashKit
+	builder  *amp.BlobMetaBuilder
+	size     int64 // stored bytes ingested
+}
+
+// OpenResumable opens (or resumes) the resumable ingest named key for a
+// planet-public blob.  ref carries the PlanetID and HashKitID, as for
+// StoreHashed; Finish fills in the rest.  The caller resumes its source at
+// Offset.
+func (store *Dir) OpenResumable(ref *amp.BlobRef, key tag.UID) (*Resumable, error) {
+	if ref == nil || key.IsNil() {
+		return nil, status.Code_BadRequest.Error("blobstore: OpenResumable requires a BlobRef and key")
+	}
+	planetID := tag.UID{ref.PlanetID_0, ref.PlanetID_1}
+	if planetID.IsNil() {
+		return nil, status.Code_BadRequest.Error("blobstore: OpenResumable requires ref.PlanetID")
+	}
+	if !ref.IsPublic() {
+		return nil, status.Code_BadRequest.Error("blobstore: OpenResumable stores planet-public data")
+	}
+	planetDir := store.planetDir(planetID)
+	if err := os.MkdirAll(planetDir, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	res := &Resumable{
+		store:    store,
+		ref:      ref,
+		planetID: planetID,
+		path:     filepath.Join(planetDir, partialPrefix+key.Base16()),
+	}
+	file, err := os.OpenFile(res.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	res.file = file
+	if err := res.resume(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return res, nil
+}
+
+// Offset is the stored bytes already ingested — where the caller's source
+// continues.
+func (res *Resumable) Offset() int64 {
+	return res.size
+}
+
+// Write appends stored bytes, checkpointing at each IngestCheckpointBytes boundary.
+func (res *Resumable) Write(buf []byte) (int, error) {
+	written := 0
+	for len(buf) > 0 {
+		span := min(int64(len(buf)), IngestCheckpointBytes-res.size%IngestCheckpointBytes)
+		if _, err := res.file.WriteAt(buf[:span], res.size); err != nil {
+			return written, status.Code_StorageFailure.Wrap(err)
+		}
+		res.kit.Hasher.Write(buf[:span])
+		if _, err := res.builder.Write(buf[:span]); err != nil {
+			return written, err
+		}
+		res.size += span
+		written += int(span)
+		buf = buf[span:]
+		if res.size%IngestCheckpointBytes == 0 {
+			if err := res.checkpoint(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Close stops the ingest, keeping the partial file and its last checkpoint
+// for a later OpenResumable — checkpointing first when the stream sits on a
+// grain boundary, so no whole grain is redone.
+func (res *Resumable) Close() error {
+	var err error
+	if res.size%(1<<amp.BlobGrainSizeLog2) == 0 {
+		err = res.checkpoint()
+	}
+	if closeErr := res.file.Close(); err == nil && closeErr != nil {
+		err = status.Code_StorageFailure.Wrap(closeErr)
+	}
+	return err
+}
+
+// Abort stops the ingest and removes its partial file and checkpoint.
+func (res *Resumable) Abort() error {
+	res.file.Close()
+	os.Remove(res.path + ckptExt)
+	if err := os.Remove(res.path); err != nil && !os.IsNotExist(err) {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+// Finish publishes the ingested stream and fills in ref exactly as
+// StoreHashed would, then removes the checkpoint.
+func (res *Resumable) Finish() error {
+	if err := res.file.Truncate(res.size); err != nil {
+		res.file.Close()
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if err := res.file.Sync(); err != nil {
+		res.file.Close()
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if err := res.file.Close(); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	st := &staged{
+		tmpPath: res.path,
+		size:    res.size,
+		digest:  res.kit.Hasher.Sum(nil),
+	}
+	defer st.discard()
+	var err error
+	if st.meta, err = res.builder.Finish(); err != nil {
+		return err
+	}
+	if err := res.store.publish(st, res.planetID, st.uid()); err != nil {
+		return err
+	}
+	os.Remove(res.path + ckptExt)
+	return fillRef(res.ref, st)
+}
+
+// StoreResumable is StoreHashed over a seekable source, resumable under key:
+// an interrupted call re-run with the same key continues where the last
+// checkpoint left off.
+func (store *Dir) StoreResumable(ref *amp.BlobRef, key tag.UID, src io.ReadSeeker, onProgress func(bytesWritten int64)) error {
+	res, err := store.OpenResumable(ref, key)
+	if err != nil {
+		return err
+	}
+	if _, err := src.Seek(res.Offset(), io.SeekStart); err != nil {
+		res.Close()
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	buf := make([]byte, copyBufSize)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := res.Write(buf[:n]); err != nil {
+				res.Close()
+				return err
+			}
+			if onProgress != nil {
+				onProgress(res.Offset())
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			res.Close()
+			return status.Code_StorageFailure.Wrap(readErr)
+		}
+	}
+	return res.Finish()
+}
+
+// checkpoint syncs the partial file, then records the hash states and a
+// digest of the trailing chunk beside it.
+func (res *Resumable) checkpoint() error {
+	if err := res.file.Sync(); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	hashState, err := res.kit.MarshalState()
+	if err != nil {
+		return err
+	}
+	builderState, err := res.builder.Checkpoint()
+	if err != nil {
+		return err
+	}
+	tail, err := res.tailEntry(res.size)
+	if err != nil {
+		return err
+	}
+	ckpt := protowire.AppendTag(nil, 1, protowire.VarintType)
+	ckpt = protowire.AppendVarint(ckpt, uint64(res.size))
+	ckpt = protowire.AppendTag(ckpt, 2, protowire.VarintType)
+	ckpt = protowire.AppendVarint(ckpt, uint64(res.kit.HashKitID))
+	ckpt = protowire.AppendTag(ckpt, 3, protowire.BytesType)
+	ckpt = protowire.AppendBytes(ckpt, hashState)
+	ckpt = protowire.AppendTag(ckpt, 4, protowire.BytesType)
+	ckpt = protowire.AppendBytes(ckpt, builderState)
+	ckpt = protowire.AppendTag(ckpt, 5, protowire.BytesType)
+	ckpt = protowire.AppendBytes(ckpt, tail)
+	if err := platform.WriteFileAtomic(res.path+ckptExt, ckpt); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+// resume restores the last checkpoint whose trailing chunk still verifies
+// against the partial file, else starts over from byte zero.
+func (res *Resumable) resume() error {
+	var err error
+	if res.kit, err = safe.NewHashKit(res.ref.HashKitID); err != nil {
+		return err
+	}
+	if res.restore() {
+		return nil
+	}
+	res.size = 0
+	res.kit.Hasher.Reset()
+	if res.builder, err = amp.NewBlobMetaBuilder(res.ref.HashKitID); err != nil {
+		return err
+	}
+	if err := res.file.Truncate(0); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	os.Remove(res.path + ckptExt)
+	return nil
+}
+
+// restore loads the checkpoint, reporting whether it is usable.
+func (res *Resumable) restore() bool {
+	ckpt, err := os.ReadFile(res.path + ckptExt)
+	if err != nil {
+		return false
+	}
+	var (
+		size                          int64
+		kitID                         safe.HashKitID
+		hashState, builderState, tail []byte
+	)
+	for len(ckpt) > 0 {
+		num, typ, n := protowire.ConsumeTag(ckpt)
+		if n < 0 {
+			return false
+		}
+		ckpt = ckpt[n:]
+		switch {
+		case typ == protowire.VarintType:
+			val, n := protowire.ConsumeVarint(ckpt)
+			if n < 0 {
+				return false
+			}
+			ckpt = ckpt[n:]
+			switch num {
+			case 1:
+				size = int64(val)
+			case 2:
+				kitID = safe.HashKitID(val)
+			}
+		case typ == protowire.BytesType:
+			field, n := protowire.ConsumeBytes(ckpt)
+			if n < 0 {
+				return false
+			}
+			ckpt = ckpt[n:]
+			switch num {
+			case 3:
+				hashState = field
+			case 4:
+				builderState = field
+			case 5:
+				tail = field
+			}
+		default:
+			return false
+		}
+	}
+
+	info, err := res.file.Stat()
+	if err != nil || kitID != res.kit.HashKitID || size <= 0 || info.Size() < size {
+		return false
+	}
+	if entry, err := res.tailEntry(size); err != nil || !bytes.Equal(entry, tail) {
+		return false
+	}
+	if res.kit.UnmarshalState(hashState) != nil {
+		return false
+	}
+	builder, err := amp.ResumeBlobMetaBuilder(builderState)
+	if err != nil || builder.Len() != size {
+		return false
+	}
+	if err := res.file.Truncate(size); err != nil {
+		return false
+	}
+	res.builder = builder
+	res.size = size
+	return true
+}
+
+// tailEntry hashes the partial file's last resumeTailLen bytes before end.
+func (res *Resumable) tailEntry(end int64) ([]byte, error) {
+	hasher, err := amp.NewBlobChunkHasher(res.kit.HashKitID)
+	if err != nil {
+		return nil, err
+	}
+	begin := max(0, end-resumeTailLen)
+	if _, err := io.Copy(hasher, io.NewSectionReader(res.file, begin, end-begin)); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return hasher.SumChunk(), nil
+}
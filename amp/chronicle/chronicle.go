@@ -0,0 +1,352 @@
+// Package chronicle reads and writes the portable container forms of a planet
+// (AOM DD-chronicle-and-codex.md): chronicle.bin, a verbatim slice of signed
+// TxMsgs whose source authority survives the trip, and codex.bin, a resolved-
+// state snapshot of Artifacts whose authority resets on import.
+//
+// A chronicle embeds the planet's genesis TxMsg in its header, so a receiver
+// holding only the founders' public keys can verify every entry offline — no
+// network, no trust in the carrier — before anything reaches a TxJournal.
+// Export and Import are the backup and sneakernet-restore paths; Writer and
+// Reader are the streaming primitives beneath them.  Compact rebases a planet's
+// chronicle to the minimal signed slice a replay needs.
+package chronicle
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+// ChronicleMagic leads every chronicle.bin.
+const ChronicleMagic = "AMPCHRON"
+
+// Writer streams a chronicle: header first, then TxMsgs in ascending TxID
+// order, then the trailer on Close.
+type Writer struct {
+	rec      *recordWriter
+	planetID tag.UID
+	start    tag.UID
+	end      tag.UID
+	last     tag.UID
+}
+
+// NewWriter writes the preamble and header to w.  The header must name
+// SourcePlanet and embed GenesisEpoch; a zero Range.End leaves the slice
+// unbounded above.
+func NewWriter(w io.Writer, header *amp.ChronicleHeader) (*Writer, error) {
+	if header == nil || header.SourcePlanet.NoUID() {
+		return nil, status.Code_BadRequest.Error("chronicle: header requires SourcePlanet")
+	}
+	if len(header.GenesisEpoch) == 0 {
+		return nil, status.Code_BadRequest.Error("chronicle: header requires the embedded GenesisEpoch TxMsg")
+	}
+	headerBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(header)
+	if err != nil {
+		return nil, status.Code_BadRequest.Wrap(err)
+	}
+	rec, err := newRecordWriter(w, ChronicleMagic, header.DigestHashKit, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	cw := &Writer{
+		rec:      rec,
+		planetID: header.SourcePlanet.UID(),
+	}
+	cw.start, cw.end = headerRange(header)
+	return cw, nil
+}
+
+// WriteTx appends one sealed TxMsg.  TxIDs must strictly ascend, fall within
+// the header's Range, and belong to SourcePlanet.
+func (cw *Writer) WriteTx(raw []byte) error {
+	txID, err := checkEntry(raw, cw.planetID, cw.start, cw.end, cw.last)
+	if err != nil {
+		return err
+	}
+	if err := cw.rec.writeEntry(raw); err != nil {
+		return err
+	}
+	cw.last = txID
+	return nil
+}
+
+// Entries returns the number of TxMsgs written so far.
+func (cw *Writer) Entries() int64 {
+	return cw.rec.entries
+}
+
+// Close writes the trailer (entry count and container digest) and flushes.
+// It does not close the underlying io.Writer.
+func (cw *Writer) Close() error {
+	return cw.rec.finish()
+}
+
+// Reader streams a chronicle written by Writer.  It checks container
+// integrity and entry shape; signature verification is the Verifier's.
+type Reader struct {
+	rec      *recordReader
+	header   *amp.ChronicleHeader
+	planetID tag.UID
+	start    tag.UID
+	end      tag.UID
+	last     tag.UID
+}
+
+// NewReader reads the preamble and header from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	rec, headerBytes, err := openRecords(r, ChronicleMagic)
+	if err != nil {
+		return nil, err
+	}
+	header := &amp.ChronicleHeader{}
+	if err := proto.Unmarshal(headerBytes, header); err != nil {
+		return nil, status.Code_ParseFailed.Wrap(err)
+	}
+	if header.SourcePlanet.NoUID() || len(header.GenesisEpoch) == 0 {
+		return nil, status.Code_ParseFailed.Error("chronicle: header lacks SourcePlanet or GenesisEpoch")
+	}
+	if err := rec.setDigest(header.DigestHashKit); err != nil {
+		return nil, err
+	}
+	cr := &Reader{
+		rec:      rec,
+		header:   header,
+		planetID: header.SourcePlanet.UID(),
+	}
+	cr.start, cr.end = headerRange(header)
+	return cr, nil
+}
+
+// Header returns the chronicle's header.
+func (cr *Reader) Header() *amp.ChronicleHeader {
+	return cr.header
+}
+
+// Next returns the next TxMsg and its TxID.  It returns io.EOF only after the
+// trailer's entry count and container digest have verified.
+func (cr *Reader) Next() (txID tag.UID, raw []byte, err error) {
+	raw, err = cr.rec.nextEntry()
+	if err != nil {
+		return tag.UID{}, nil, err
+	}
+	txID, err = checkEntry(raw, cr.planetID, cr.start, cr.end, cr.last)
+	if err != nil {
+		return tag.UID{}, nil, status.Code_ParseFailed.Wrap(err)
+	}
+	cr.last = txID
+	return txID, raw, nil
+}
+
+// Export writes planetID's journaled TxMsgs within header.Range to w as a
+// chronicle and returns the entry count.  Unset header fields are filled in
+// place: Range.Start defaults to the genesis TxID, Range.End to the journal's
+// HighWater, and ExportTime to now.  Quarantined entries are not exported.
+func Export(w io.Writer, journal amp.TxJournal, header *amp.ChronicleHeader) (int64, error) {
+	if header == nil || header.SourcePlanet.NoUID() {
+		return 0, status.Code_BadRequest.Error("chronicle: Export requires header.SourcePlanet")
+	}
+	planetID := header.SourcePlanet.UID()
+	genesis, err := amp.ParseTxEnvelope(header.GenesisEpoch)
+	if err != nil {
+		return 0, status.Code_BadRequest.Errorf("chronicle: Export: GenesisEpoch: %v", err)
+	}
+	if header.Range == nil {
+		header.Range = &amp.UIDRange{}
+	}
+	start, end := headerRange(header)
+	if start.IsNil() {
+		start = genesis.TxID()
+		header.Range.Start_0, header.Range.Start_1 = start[0], start[1]
+	}
+	if end.IsNil() {
+		if end, err = journal.HighWater(planetID); err != nil {
+			return 0, err
+		}
+		header.Range.End_0, header.Range.End_1 = end[0], end[1]
+	}
+	if header.ExportTime == 0 {
+		header.ExportTime = time.Now().Unix()
+	}
+
+	cw, err := NewWriter(w, header)
+	if err != nil {
+		return 0, err
+	}
+	after := start
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	var writeErr error
+	err = journal.ReadSince(planetID, after, func(txTimeID tag.UID, raw []byte) bool {
+		if txTimeID.CompareTo(end) > 0 {
+			return false
+		}
+		writeErr = cw.WriteTx(raw)
+		return writeErr == nil
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return cw.Entries(), err
+	}
+	return cw.Entries(), cw.Close()
+}
+
+// Import reads a chronicle from r, verifies every TxMsg offline under trust, and
+// appends the verified TxMsgs to journal (nil verifies only).  Nothing reaches
+// journal until the whole container has checked out: verified entries are
+// staged in a temporary file and appended only once the trailer's entry count
+// and digest verify, so a truncated or tampered chronicle imports nothing.
+// Returns the header and the number of entries verified.
+func Import(r io.Reader, journal amp.TxJournal, trust Trust) (*amp.ChronicleHeader, int64, error) {
+	cr, err := NewReader(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	header := cr.Header()
+	verifier, err := NewVerifier(header, trust)
+	if err != nil {
+		return header, 0, err
+	}
+
+	var stage *stagedTxs
+	if journal != nil {
+		if stage, err = newStagedTxs(); err != nil {
+			return header, 0, err
+		}
+		defer stage.close()
+	}
+	var verified int64
+	for {
+		txID, raw, err := cr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return header, verified, err
+		}
+		if _, err := verifier.VerifyTx(raw); err != nil {
+			return header, verified, status.Code_AuthFailed.Errorf("chronicle: TxMsg %v: %v", txID, err)
+		}
+		if stage != nil {
+			if err := stage.add(txID, raw); err != nil {
+				return header, verified, err
+			}
+		}
+		verified++
+	}
+	if stage != nil {
+		planetID := header.SourcePlanet.UID()
+		err = stage.replay(func(txID tag.UID, raw []byte) error {
+			return journal.Append(planetID, txID, raw)
+		})
+	}
+	return header, verified, err
+}
+
+// Verify checks a chronicle end to end under trust without importing it.
+func Verify(r io.Reader, trust Trust) (*amp.ChronicleHeader, int64, error) {
+	return Import(r, nil, trust)
+}
+
+// stagedTxs holds verified TxMsgs in a temporary file until Import commits
+// them, so a chronicle of any size stages without being held in memory.
+type stagedTxs struct {
+	file  *os.File
+	w     *bufio.Writer
+	scrap [4 + tag.UID_Size]byte
+}
+
+func newStagedTxs() (*stagedTxs, error) {
+	file, err := os.CreateTemp("", "chronicle-import-*")
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return &stagedTxs{
+		file: file,
+		w:    bufio.NewWriterSize(file, 64<<10),
+	}, nil
+}
+
+// add stages one TxMsg as u32 BE len ‖ TxID ‖ raw.
+func (st *stagedTxs) add(txID tag.UID, raw []byte) error {
+	binary.BigEndian.PutUint32(st.scrap[:4], uint32(len(raw)))
+	binary.BigEndian.PutUint64(st.scrap[4:], txID[0])
+	binary.BigEndian.PutUint64(st.scrap[12:], txID[1])
+	if _, err := st.w.Write(st.scrap[:]); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if _, err := st.w.Write(raw); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+// replay hands each staged TxMsg to fn in the order staged.
+func (st *stagedTxs) replay(fn func(txID tag.UID, raw []byte) error) error {
+	if err := st.w.Flush(); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if _, err := st.file.Seek(0, io.SeekStart); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	r := bufio.NewReaderSize(st.file, 64<<10)
+	for {
+		if _, err := io.ReadFull(r, st.scrap[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		raw := make([]byte, binary.BigEndian.Uint32(st.scrap[:4]))
+		if _, err := io.ReadFull(r, raw); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		txID := tag.UID{binary.BigEndian.Uint64(st.scrap[4:]), binary.BigEndian.Uint64(st.scrap[12:])}
+		if err := fn(txID, raw); err != nil {
+			return err
+		}
+	}
+}
+
+func (st *stagedTxs) close() {
+	st.file.Close()
+	os.Remove(st.file.Name())
+}
+
+// headerRange returns the header's inclusive TxID bounds; a zero end is unbounded.
+func headerRange(header *amp.ChronicleHeader) (start, end tag.UID) {
+	if rng := header.Range; rng != nil {
+		start = tag.UID{rng.Start_0, rng.Start_1}
+		end = tag.UID{rng.End_0, rng.End_1}
+	}
+	return start, end
+}
+
+// checkEntry validates one TxMsg's envelope against the chronicle's planet,
+// range, and ordering, returning its TxID.
+func checkEntry(raw []byte, planetID, start, end, last tag.UID) (tag.UID, error) {
+	env, err := amp.ParseTxEnvelope(raw)
+	if err != nil {
+		return tag.UID{}, err
+	}
+	txID := env.TxID()
+	switch {
+	case env.PlanetID() != planetID:
+		return txID, status.Code_BadRequest.Errorf("chronicle: TxMsg %v belongs to planet %v", txID, env.PlanetID())
+	case txID.IsNil():
+		return txID, status.Code_BadRequest.Error("chronicle: TxMsg has no TxID")
+	case txID.CompareTo(start) < 0 || (!end.IsNil() && txID.CompareTo(end) > 0):
+		return txID, status.Code_BadRequest.Errorf("chronicle: TxMsg %v outside the header Range", txID)
+	case !last.IsNil() && txID.CompareTo(last) <= 0:
+		return txID, status.Code_BadRequest.Errorf("chronicle: TxMsg %v out of order", txID)
+	}
+	return txID, nil
+}
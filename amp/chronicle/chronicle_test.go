@@ -0,0 +1,408 @@
+package chronicle
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/journal"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/poly25519"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// testAuthor is a member signing key that seals planet-public TxMsgs.
+type testAuthor struct {
+	memberID tag.UID
+	kit      *safe.Kit
+	pair     safe.KeyPair
+	sigSize  int
+}
+
+func newTestAuthor(t *testing.T) *testAuthor {
+	t.Helper()
+	kit, err := safe.CryptoKit(safe.Crypto.Poly25519.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	author := &testAuthor{
+		memberID: tag.NewID(),
+		kit:      kit,
+	}
+	author.pair.Pub = safe.PubKey{CryptoKitID: kit.ID, KeyType: safe.KeyType_SigningKey}
+	if err := kit.Signing.Generate(rand.Reader, &author.pair); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := author.SignDigest(make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	author.sigSize = len(sig)
+	return author
+}
+
+func (author *testAuthor) SignatureSize() int { return author.sigSize }
+
+func (author *testAuthor) HashDigest(parts ...[]byte) ([32]byte, error) {
+	var digest [32]byte
+	kit, err := safe.NewHashKit(safe.HashKitID_Blake2s_256)
+	if err != nil {
+		return digest, err
+	}
+	for _, part := range parts {
+		kit.Hasher.Write(part)
+	}
+	copy(digest[:], kit.Hasher.Sum(nil))
+	return digest, nil
+}
+
+func (author *testAuthor) SignDigest(digest []byte) ([]byte, error) {
+	return author.kit.Signing.Sign(digest, author.pair.Prv)
+}
+
+func (author *testAuthor) VerifyDigest(sig []byte, digest []byte, signerPubKey []byte, cryptoKit safe.CryptoKitID) error {
+	return safe.VerifySignature(cryptoKit, sig, digest, signerPubKey)
+}
+
+func (author *testAuthor) EncryptPayload(plaintext []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, nil
+}
+
+func (author *testAuthor) DecryptPayload(ciphertext []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, nil
+}
+
+func (author *testAuthor) ComputeMemberProof(txID []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, nil
+}
+
+func (author *testAuthor) VerifyMemberProof(proof, txID []byte, env *amp.TxEnvelope) error {
+	return nil
+}
+
+// seal authors tx as this member and returns its sealed wire bytes.
+func (author *testAuthor) seal(t *testing.T, tx *amp.TxMsg) []byte {
+	t.Helper()
+	var raw []byte
+	if err := amp.SealTx(tx, author, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// testPlanet is a public planet with two founders and a journal of sealed TxMsgs.
+type testPlanet struct {
+	planetID tag.UID
+	founders []*testAuthor
+	genesis  []byte
+	journal  *journal.Memory
+	txIDs    []tag.UID
+}
+
+func newTestPlanet(t *testing.T, entries int) *testPlanet {
+	t.Helper()
+	planet := &testPlanet{
+		planetID: tag.NewID(),
+		founders: []*testAuthor{newTestAuthor(t), newTestAuthor(t)},
+		journal:  journal.NewMemory(),
+	}
+	t.Cleanup(func() { planet.journal.Close() })
+
+	genesisID := tag.UID_FromTime(time.Unix(1_700_000_000, 0))
+	charter := &amp.PlanetCharter{
+		CharterSchema: 1,
+		PlanetID:      amp.TagFromUID(planet.planetID),
+		GenesisEpoch:  amp.TagFromUID(genesisID),
+	}
+	for _, founder := range planet.founders {
+		charter.Founders = append(charter.Founders, amp.TagFromUID(founder.memberID))
+	}
+	terms := &amp.EpochTerms{
+		TermsSchema: 1,
+		EpochTag:    amp.TagFromUID(genesisID),
+	}
+	epoch, err := amp.AssembleEpoch(charter, terms, safe.HashKitID_Blake2s_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := epoch.CoSignatureDigest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, founder := range planet.founders {
+		sig, err := founder.SignDigest(digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		epoch.Signatures = append(epoch.Signatures, &amp.CoSignature{
+			MemberTag: amp.TagFromUID(founder.memberID),
+			Signature: sig,
+		})
+	}
+
+	lead := planet.founders[0]
+	genesis := planet.newTx(genesisID, lead)
+	genesis.Upsert(planet.planetID, std.Attr.LawPlanetEpoch.ID, genesisID, epoch)
+	for _, founder := range planet.founders {
+		genesis.Upsert(planet.planetID, std.Attr.LawMemberEpoch.ID, founder.memberID, &amp.MemberEpoch{
+			MemberTag: amp.TagFromUID(founder.memberID),
+			SigningKey: &safe.KeyRef{
+				Kit_0:  founder.pair.Pub.CryptoKitID[0],
+				Kit_1:  founder.pair.Pub.CryptoKitID[1],
+				Type:   safe.KeyType_SigningKey,
+				PubKey: founder.pair.Pub.Bytes,
+			},
+		})
+	}
+	planet.genesis = lead.seal(t, genesis)
+	planet.append(t, genesisID, planet.genesis)
+
+	for i := range entries {
+		author := planet.founders[i%2]
+		txID := tag.UID{genesisID[0] + uint64(i+1)<<16, genesisID[1]}
+		planet.append(t, txID, author.seal(t, planet.entryTx(txID, author, i)))
+	}
+	return planet
+}
+
+func (planet *testPlanet) newTx(txID tag.UID, author *testAuthor) *amp.TxMsg {
+	tx := amp.TxNew()
+	tx.SetTxID(txID)
+	tx.SetPlanetID(planet.planetID)
+	tx.SetFromID(author.memberID)
+	return tx
+}
+
+func (planet *testPlanet) entryTx(txID tag.UID, author *testAuthor, i int) *amp.TxMsg {
+	tx := planet.newTx(txID, author)
+	tx.Upsert(planet.planetID, tag.UID{0x1234, 0x5678}, txID, &amp.Tag{Text: fmt.Sprintf("entry %d", i)})
+	return tx
+}
+
+func (planet *testPlanet) append(t *testing.T, txID tag.UID, raw []byte) {
+	t.Helper()
+	if err := planet.journal.Append(planet.planetID, txID, raw); err != nil {
+		t.Fatal(err)
+	}
+	planet.txIDs = append(planet.txIDs, txID)
+}
+
+func (planet *testPlanet) trust() Trust {
+	trust := Trust{
+		Founders: map[tag.UID]safe.PubKey{},
+	}
+	for _, founder := range planet.founders {
+		trust.Founders[founder.memberID] = founder.pair.Pub
+	}
+	return trust
+}
+
+func (planet *testPlanet) export(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	header := &amp.ChronicleHeader{
+		SourcePlanet: amp.TagFromUID(planet.planetID),
+		GenesisEpoch: planet.genesis,
+		Label:        "nightly-backup",
+	}
+	exported, err := Export(&buf, planet.journal, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, exported, int64(len(planet.txIDs)))
+	return buf.Bytes()
+}
+
+func TestExportImport(t *testing.T) {
+	planet := newTestPlanet(t, 20)
+	file := planet.export(t)
+
+	restored := journal.NewMemory()
+	defer restored.Close()
+	header, imported, err := Import(bytes.NewReader(file), restored, planet.trust())
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, imported, int64(len(planet.txIDs)))
+	status.Require(t, header.Label, "nightly-backup")
+	status.Require(t, header.Range.Start_0, planet.txIDs[0][0])
+	status.Require(t, header.Range.End_0, planet.txIDs[len(planet.txIDs)-1][0])
+
+	want, _ := planet.journal.RangeHash(planet.planetID, tag.UID{}, tag.MaxID())
+	got, _ := restored.RangeHash(planet.planetID, tag.UID{}, tag.MaxID())
+	status.Require(t, got, want)
+
+	// Re-import is idempotent.
+	if _, _, err := Import(bytes.NewReader(file), restored, planet.trust()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportSlice(t *testing.T) {
+	planet := newTestPlanet(t, 10)
+	var buf bytes.Buffer
+	start, end := planet.txIDs[3], planet.txIDs[7]
+	header := &amp.ChronicleHeader{
+		SourcePlanet: amp.TagFromUID(planet.planetID),
+		GenesisEpoch: planet.genesis,
+		Range:        &amp.UIDRange{Start_0: start[0], Start_1: start[1], End_0: end[0], End_1: end[1]},
+	}
+	exported, err := Export(&buf, planet.journal, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, exported, int64(5))
+
+	cr, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 3; ; i++ {
+		txID, _, err := cr.Next()
+		if err == io.EOF {
+			status.Require(t, i, 8)
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, txID, planet.txIDs[i])
+	}
+}
+
+func TestImportUntrusted(t *testing.T) {
+	planet := newTestPlanet(t, 4)
+	file := planet.export(t)
+
+	// A founder key that differs from the one the genesis declares.
+	forged := planet.trust()
+	forged.Founders[planet.founders[1].memberID] = newTestAuthor(t).pair.Pub
+	if _, _, err := Verify(bytes.NewReader(file), forged); !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed for a forged founder key, got %v", err)
+	}
+
+	// An entry from an author outside the trust root fails the import, and
+	// none of the entries verified before it are appended.
+	outsider := newTestAuthor(t)
+	txID := tag.UID{planet.txIDs[len(planet.txIDs)-1][0] + 1, 0}
+	planet.append(t, txID, outsider.seal(t, planet.entryTx(txID, outsider, 99)))
+	file = planet.export(t)
+
+	restored := journal.NewMemory()
+	defer restored.Close()
+	_, imported, err := Import(bytes.NewReader(file), restored, planet.trust())
+	if !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed for an untrusted author, got %v", err)
+	}
+	status.Require(t, imported, int64(len(planet.txIDs)-1))
+	high, _ := restored.HighWater(planet.planetID)
+	status.Require(t, high, tag.UID{})
+
+	// Admitting the author's key lets the chronicle verify.
+	trust := planet.trust()
+	trust.Members = map[tag.UID]safe.PubKey{outsider.memberID: outsider.pair.Pub}
+	if _, _, err := Import(bytes.NewReader(file), restored, trust); err != nil {
+		t.Fatal(err)
+	}
+
+	// An author claiming a trusted FromID cannot borrow its key.
+	impostor := newTestAuthor(t)
+	impostor.memberID = planet.founders[0].memberID
+	txID.Increment()
+	planet.append(t, txID, impostor.seal(t, planet.entryTx(txID, impostor, 100)))
+	if _, _, err := Verify(bytes.NewReader(planet.export(t)), trust); !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed for an impostor, got %v", err)
+	}
+}
+
+func TestImportAdmittedAuthor(t *testing.T) {
+	planet := newTestPlanet(t, 2)
+	founder := planet.founders[0]
+	newcomer := newTestAuthor(t)
+	nextID := func() tag.UID {
+		return tag.UID{planet.txIDs[len(planet.txIDs)-1][0] + 1<<16, 0}
+	}
+	memberTx := func(txID tag.UID, issuer *testAuthor, record *amp.MemberEpoch) []byte {
+		tx := planet.newTx(txID, issuer)
+		tx.Upsert(planet.planetID, std.Attr.LawMemberEpoch.ID, newcomer.memberID, record)
+		return issuer.seal(t, tx)
+	}
+
+	// A founder admits the newcomer, whose entries then verify under the
+	// key the admission declares — Trust names only the founders.
+	txID := nextID()
+	planet.append(t, txID, memberTx(txID, founder, &amp.MemberEpoch{
+		MemberTag: amp.TagFromUID(newcomer.memberID),
+		SigningKey: &safe.KeyRef{
+			Kit_0:  newcomer.pair.Pub.CryptoKitID[0],
+			Kit_1:  newcomer.pair.Pub.CryptoKitID[1],
+			Type:   safe.KeyType_SigningKey,
+			PubKey: newcomer.pair.Pub.Bytes,
+		},
+	}))
+	for i := range 2 {
+		txID = nextID()
+		planet.append(t, txID, newcomer.seal(t, planet.entryTx(txID, newcomer, 10+i)))
+	}
+	restored := journal.NewMemory()
+	defer restored.Close()
+	_, imported, err := Import(bytes.NewReader(planet.export(t)), restored, planet.trust())
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, imported, int64(len(planet.txIDs)))
+	high, _ := restored.HighWater(planet.planetID)
+	status.Require(t, high, txID)
+
+	// Once a founder revokes the newcomer, their later entries are untrusted.
+	txID = nextID()
+	planet.append(t, txID, memberTx(txID, founder, &amp.MemberEpoch{
+		MemberTag: amp.TagFromUID(newcomer.memberID),
+		Status:    amp.MemberStatus_Revoked,
+	}))
+	if _, _, err := Verify(bytes.NewReader(planet.export(t)), planet.trust()); err != nil {
+		t.Fatal(err)
+	}
+	txID = nextID()
+	planet.append(t, txID, newcomer.seal(t, planet.entryTx(txID, newcomer, 20)))
+	if _, _, err := Verify(bytes.NewReader(planet.export(t)), planet.trust()); !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected AuthFailed for a revoked author, got %v", err)
+	}
+}
+
+func TestContainerDamage(t *testing.T) {
+	planet := newTestPlanet(t, 6)
+	file := planet.export(t)
+
+	cases := []struct {
+		name   string
+		damage func([]byte) []byte
+		want   status.Code
+	}{
+		{"Truncated", func(b []byte) []byte { return b[:len(b)-40] }, status.Code_ParseFailed},
+		{"NoTrailer", func(b []byte) []byte { return b[:len(b)-32-8-4] }, status.Code_ParseFailed},
+		{"FlippedDigest", func(b []byte) []byte { b[len(b)-1] ^= 1; return b }, status.Code_DataFailure},
+		{"FlippedCount", func(b []byte) []byte { b[len(b)-33] ^= 1; return b }, status.Code_DataFailure},
+		{"FlippedSignature", func(b []byte) []byte { b[len(b)-32-8-4-1] ^= 1; return b }, status.Code_AuthFailed},
+		{"BadMagic", func(b []byte) []byte { b[0] = 'X'; return b }, status.Code_ParseFailed},
+		{"BadVersion", func(b []byte) []byte { b[magicSize+3] = 9; return b }, status.Code_Unimplemented},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			damaged := tc.damage(bytes.Clone(file))
+			_, _, err := Verify(bytes.NewReader(damaged), planet.trust())
+			if !status.IsError(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+	if _, _, err := Verify(bytes.NewReader(file), planet.trust()); err != nil {
+		t.Fatal(err)
+	}
+}
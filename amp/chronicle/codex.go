@@ -0,0 +1,341 @@
+package chronicle
+
+import (
+	"bytes"
+	"io"
+	"slices"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// CodexMagic leads every codex.bin.
+	CodexMagic = "AMPCODEX"
+
+	// DefaultInlineThreshold is the largest value a CodexWriter stores inline;
+	// larger values spill to the blob store as a BlobValue.
+	DefaultInlineThreshold = 64 << 10
+)
+
+// CodexOpts tunes how a CodexWriter stores values and how ExportCodex reads a journal.
+type CodexOpts struct {
+	InlineThreshold int64              // values over this spill to Blobs (0 = DefaultInlineThreshold)
+	Blobs           amp.BlobStore      // spill target; nil stores every value inline
+	Crypto          amp.CryptoProvider // opens sealed journal entries; nil reads planet-public entries only
+	Registry        amp.Registry       // EditFlow and manifest labels per attr; nil = std.Registry()
+}
+
+// CodexWriter streams a codex: resolved Artifacts, one record each, under a
+// header stamped with the current ContentModelEpoch.
+type CodexWriter struct {
+	rec      *recordWriter
+	opts     CodexOpts
+	planetID tag.UID
+}
+
+// NewCodexWriter writes the preamble and header to w.  header.ContentModelEpoch
+// is stamped with amp.ContentModelEpoch.
+func NewCodexWriter(w io.Writer, header *amp.CodexHeader, opts CodexOpts) (*CodexWriter, error) {
+	if header == nil || header.SourcePlanet.NoUID() {
+		return nil, status.Code_BadRequest.Error("codex: header requires SourcePlanet")
+	}
+	if opts.InlineThreshold <= 0 {
+		opts.InlineThreshold = DefaultInlineThreshold
+	}
+	header.ContentModelEpoch = amp.ContentModelEpoch
+	headerBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(header)
+	if err != nil {
+		return nil, status.Code_BadRequest.Wrap(err)
+	}
+	rec, err := newRecordWriter(w, CodexMagic, header.DigestHashKit, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+	cw := &CodexWriter{
+		rec:      rec,
+		opts:     opts,
+		planetID: header.SourcePlanet.UID(),
+	}
+	return cw, nil
+}
+
+// WriteValue writes the Artifact at addr holding the serialized value, inline
+// or — over the inline threshold, when a blob store is set — as a BlobValue.
+func (cw *CodexWriter) WriteValue(addr tag.Address, value []byte) error {
+	art := &amp.Artifact{
+		NodeID_0: addr.NodeID[0],
+		NodeID_1: addr.NodeID[1],
+		AttrID_0: addr.AttrID[0],
+		AttrID_1: addr.AttrID[1],
+		ItemID_0: addr.ItemID[0],
+		ItemID_1: addr.ItemID[1],
+		EditID_0: addr.EditID[0],
+		EditID_1: addr.EditID[1],
+	}
+	if cw.opts.Blobs == nil || int64(len(value)) <= cw.opts.InlineThreshold {
+		art.InlineValue = value
+	} else {
+		ref := &amp.BlobRef{
+			PlanetID_0: cw.planetID[0],
+			PlanetID_1: cw.planetID[1],
+		}
+		if err := cw.opts.Blobs.StoreHashed(ref, bytes.NewReader(value), nil); err != nil {
+			return err
+		}
+		art.BlobValue = ref
+	}
+	return cw.WriteArtifact(art)
+}
+
+// WriteArtifact writes one Artifact as given.
+func (cw *CodexWriter) WriteArtifact(art *amp.Artifact) error {
+	if len(art.InlineValue) > 0 && art.BlobValue != nil {
+		return status.Code_BadRequest.Error("codex: Artifact sets both InlineValue and BlobValue")
+	}
+	buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(art)
+	if err != nil {
+		return status.Code_BadRequest.Wrap(err)
+	}
+	return cw.rec.writeEntry(buf)
+}
+
+// Entries returns the number of Artifacts written so far.
+func (cw *CodexWriter) Entries() int64 {
+	return cw.rec.entries
+}
+
+// Close writes the trailer and flushes; the underlying io.Writer stays open.
+func (cw *CodexWriter) Close() error {
+	return cw.rec.finish()
+}
+
+// WriteBinding writes every live item of a FoldBinding as an Artifact, in
+// ascending ItemID order.
+func WriteBinding[V proto.Message](cw *CodexWriter, binding *amp.FoldBinding[V]) error {
+	var itemIDs []tag.UID
+	binding.EnumItemIDs(func(itemID tag.UID) bool {
+		itemIDs = append(itemIDs, itemID)
+		return true
+	})
+	slices.SortFunc(itemIDs, tag.UID.CompareTo)
+
+	for _, itemID := range itemIDs {
+		value, _ := binding.GetItem(itemID)
+		addr, _ := binding.ItemAddress(itemID)
+		buf, err := proto.MarshalOptions{Deterministic: true}.Marshal(value)
+		if err != nil {
+			return status.Code_BadRequest.Wrap(err)
+		}
+		if err := cw.WriteValue(addr, buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportCodex folds planetID's journal into its resolved state and writes it
+// to w as a codex, returning the Artifact count.  Each address resolves
+// last-writer-wins by EditID and a delete retires it; governance-law attrs and
+// meta ops are left out, since a codex carries no source authority, as are
+// EditFlow_Tape attrs, whose edits never fold to one value (see Compact).  The fold
+// makes two passes over the journal — one to resolve winners, one to stream
+// their values — so only the address index is held in memory.  The header's
+// Manifest is filled with the attrs present.
+func ExportCodex(w io.Writer, journal amp.TxJournal, planetID tag.UID, header *amp.CodexHeader, opts CodexOpts) (int64, error) {
+	if header == nil {
+		header = &amp.CodexHeader{}
+	}
+	if header.SourcePlanet.NoUID() {
+		header.SourcePlanet = amp.TagFromUID(planetID)
+	}
+	high, err := journal.HighWater(planetID)
+	if err != nil {
+		return 0, err
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = std.Registry()
+	}
+	crypto := opts.Crypto
+	if crypto == nil {
+		crypto = offline{}
+	}
+
+	type winner struct {
+		attrID  tag.UID
+		editID  tag.UID
+		deleted bool
+		written bool
+	}
+	winners := map[tag.ElementLSM]*winner{}
+
+	// forEachOp walks every folded op of the journal up to high.
+	forEachOp := func(fn func(tx *amp.TxMsg, opIndex int, elem tag.ElementLSM)) error {
+		var openErr error
+		err := journal.ReadSince(planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+			if txTimeID.CompareTo(high) > 0 {
+				return false
+			}
+			tx, err := amp.OpenTxSansVerify(raw, crypto)
+			if err != nil {
+				openErr = status.Code_DecryptFailed.Errorf("codex: TxMsg %v: %v", txTimeID, err)
+				return false
+			}
+			for i := range tx.Ops {
+				op := &tx.Ops[i]
+				if op.Flags&amp.TxOpFlags_MetaOp != 0 || std.IsGovernanceLawAttr(op.Addr.AttrID) {
+					continue
+				}
+				if def, _ := registry.FindAttr(op.Addr.AttrID); def.EditFlow == amp.EditFlow_Tape {
+					continue
+				}
+				fn(tx, i, op.Addr.ElementLSM())
+			}
+			return true
+		})
+		if err == nil {
+			err = openErr
+		}
+		return err
+	}
+
+	err = forEachOp(func(tx *amp.TxMsg, i int, elem tag.ElementLSM) {
+		op := &tx.Ops[i]
+		win := winners[elem]
+		if win == nil {
+			win = &winner{}
+			winners[elem] = win
+		} else if win.editID.CompareTo(op.Addr.EditID) >= 0 {
+			return
+		}
+		win.attrID = op.Addr.AttrID
+		win.editID = op.Addr.EditID
+		win.deleted = op.Flags&amp.TxOpFlags_Delete != 0
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if header.Manifest == nil {
+		header.Manifest = &amp.CodexManifest{}
+	}
+	var attrIDs []tag.UID
+	for _, win := range winners {
+		if !win.deleted {
+			attrIDs = append(attrIDs, win.attrID)
+		}
+	}
+	slices.SortFunc(attrIDs, tag.UID.CompareTo)
+	attrIDs = slices.Compact(attrIDs)
+	for _, attrID := range attrIDs {
+		kind := amp.TagFromUID(attrID)
+		if def, ok := registry.FindAttr(attrID); ok {
+			kind.Text = def.Text
+		}
+		header.Manifest.AttributeKinds = append(header.Manifest.AttributeKinds, kind)
+	}
+
+	cw, err := NewCodexWriter(w, header, opts)
+	if err != nil {
+		return 0, err
+	}
+	var writeErr error
+	err = forEachOp(func(tx *amp.TxMsg, i int, elem tag.ElementLSM) {
+		op := &tx.Ops[i]
+		win := winners[elem]
+		if writeErr != nil || win.deleted || win.written || win.editID != op.Addr.EditID {
+			return
+		}
+		var value []byte
+		if value, writeErr = tx.OpValueBytes(i); writeErr == nil {
+			writeErr = cw.WriteValue(op.Addr, value)
+		}
+		win.written = true
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		return cw.Entries(), err
+	}
+	return cw.Entries(), cw.Close()
+}
+
+// CodexReader streams the Artifacts of a codex written by CodexWriter.
+type CodexReader struct {
+	rec    *recordReader
+	header *amp.CodexHeader
+}
+
+// NewCodexReader reads the preamble and header from r, refusing a codex whose
+// ContentModelEpoch differs from amp.ContentModelEpoch — its payloads would
+// otherwise be silently mis-decoded.
+func NewCodexReader(r io.Reader) (*CodexReader, error) {
+	rec, headerBytes, err := openRecords(r, CodexMagic)
+	if err != nil {
+		return nil, err
+	}
+	header := &amp.CodexHeader{}
+	if err := proto.Unmarshal(headerBytes, header); err != nil {
+		return nil, status.Code_ParseFailed.Wrap(err)
+	}
+	if header.ContentModelEpoch != amp.ContentModelEpoch {
+		return nil, status.Code_Unimplemented.Errorf("codex: ContentModelEpoch %d, this SDK reads %d", header.ContentModelEpoch, amp.ContentModelEpoch)
+	}
+	if err := rec.setDigest(header.DigestHashKit); err != nil {
+		return nil, err
+	}
+	cr := &CodexReader{
+		rec:    rec,
+		header: header,
+	}
+	return cr, nil
+}
+
+// Header returns the codex's header.
+func (cr *CodexReader) Header() *amp.CodexHeader {
+	return cr.header
+}
+
+// Next returns the next Artifact.  It returns io.EOF only after the trailer's
+// entry count and container digest have verified.
+func (cr *CodexReader) Next() (*amp.Artifact, error) {
+	buf, err := cr.rec.nextEntry()
+	if err != nil {
+		return nil, err
+	}
+	art := &amp.Artifact{}
+	if err := proto.Unmarshal(buf, art); err != nil {
+		return nil, status.Code_ParseFailed.Wrap(err)
+	}
+	if len(art.InlineValue) > 0 && art.BlobValue != nil {
+		return nil, status.Code_ParseFailed.Error("codex: Artifact sets both InlineValue and BlobValue")
+	}
+	return art, nil
+}
+
+// ArtifactValue returns an Artifact's serialized value, reading a BlobValue
+// from blobs.
+func ArtifactValue(art *amp.Artifact, blobs amp.BlobStore) ([]byte, error) {
+	ref := art.BlobValue
+	if ref == nil {
+		return art.InlineValue, nil
+	}
+	if blobs == nil {
+		return nil, status.Code_BadRequest.Error("codex: Artifact value is a BlobValue and no BlobStore was given")
+	}
+	r, err := blobs.Retrieve(tag.UID{ref.PlanetID_0, ref.PlanetID_1}, ref.StorageUID())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	value, err := io.ReadAll(r)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return value, nil
+}
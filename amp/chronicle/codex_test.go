@@ -0,0 +1,222 @@
+package chronicle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+var testAttrID = tag.UID{0x1234, 0x5678}
+
+// readCodex drains a codex, returning its Artifacts keyed by ItemID.
+func readCodex(t *testing.T, file []byte) (*amp.CodexHeader, map[tag.UID]*amp.Artifact) {
+	t.Helper()
+	cr, err := NewCodexReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arts := map[tag.UID]*amp.Artifact{}
+	for {
+		art, err := cr.Next()
+		if err == io.EOF {
+			return cr.Header(), arts
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		arts[tag.UID{art.ItemID_0, art.ItemID_1}] = art
+	}
+}
+
+func tagText(t *testing.T, value []byte) string {
+	t.Helper()
+	leaf := &amp.Tag{}
+	if err := proto.Unmarshal(value, leaf); err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Text
+}
+
+func TestExportCodex(t *testing.T) {
+	planet := newTestPlanet(t, 6)
+	author := planet.founders[0]
+	items := planet.txIDs[1:]
+
+	// Overwrite items[0] and delete items[1]; items[2] keeps its only edit.
+	last := planet.txIDs[len(planet.txIDs)-1]
+	edit := tag.UID{last[0] + 1, 0}
+	tx := planet.newTx(edit, author)
+	tx.Upsert(planet.planetID, testAttrID, items[0], &amp.Tag{Text: "rewritten"})
+	tx.Delete(tag.ElementID{
+		NodeID: planet.planetID,
+		AttrID: testAttrID,
+		ItemID: items[1],
+	}, nil)
+	planet.append(t, edit, author.seal(t, tx))
+
+	var buf bytes.Buffer
+	exported, err := ExportCodex(&buf, planet.journal, planet.planetID, &amp.CodexHeader{Label: "snapshot"}, CodexOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, exported, int64(len(items)-1))
+
+	header, arts := readCodex(t, buf.Bytes())
+	status.Require(t, header.ContentModelEpoch, amp.ContentModelEpoch)
+	status.Require(t, header.SourcePlanet.UID(), planet.planetID)
+	status.Require(t, len(header.Manifest.AttributeKinds), 1)
+	status.Require(t, header.Manifest.AttributeKinds[0].UID(), testAttrID)
+
+	// Governance records from genesis never enter a codex.
+	status.Require(t, len(arts), len(items)-1)
+	status.Require(t, tagText(t, arts[items[0]].InlineValue), "rewritten")
+	status.Require(t, arts[items[0]].EditID_0, edit[0])
+	if _, ok := arts[items[1]]; ok {
+		t.Fatal("deleted item exported")
+	}
+	status.Require(t, tagText(t, arts[items[2]].InlineValue), "entry 2")
+}
+
+func TestExportCodexSkipsTape(t *testing.T) {
+	planet := newTestPlanet(t, 0)
+	author := planet.founders[0]
+
+	registry := std.NewRegistry()
+	tapeAttr := tag.Name{ID: tag.UID{0xAAAA, 1}, Text: "test.tape.Tag"}
+	if err := registry.RegisterAttr(amp.AttrDef{Name: tapeAttr, Prototype: &amp.Tag{}, EditFlow: amp.EditFlow_Tape}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two frames on one tape cell alongside a folding attr.
+	for _, text := range []string{"frame 1", "frame 2"} {
+		txID := planet.nextTxID()
+		tx := planet.newTx(txID, author)
+		tx.Upsert(planet.planetID, tapeAttr.ID, tag.UID{3, 1}, &amp.Tag{Text: text})
+		tx.Upsert(planet.planetID, testAttrID, tag.UID{3, 2}, &amp.Tag{Text: text})
+		planet.append(t, txID, author.seal(t, tx))
+	}
+
+	var buf bytes.Buffer
+	exported, err := ExportCodex(&buf, planet.journal, planet.planetID, nil, CodexOpts{Registry: registry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, exported, int64(1))
+	header, arts := readCodex(t, buf.Bytes())
+	status.Require(t, len(header.Manifest.AttributeKinds), 1)
+	status.Require(t, header.Manifest.AttributeKinds[0].UID(), testAttrID)
+	status.Require(t, tagText(t, arts[tag.UID{3, 2}].InlineValue), "frame 2")
+
+	// Unregistered, the tape attr folds like any other.
+	buf.Reset()
+	if exported, err = ExportCodex(&buf, planet.journal, planet.planetID, nil, CodexOpts{}); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, exported, int64(2))
+}
+
+func TestCodexBlobValues(t *testing.T) {
+	planet := newTestPlanet(t, 0)
+	author := planet.founders[0]
+	big := strings.Repeat("large value ", 200)
+	txID := tag.UID{planet.txIDs[0][0] + 1, 0}
+	tx := planet.newTx(txID, author)
+	tx.Upsert(planet.planetID, testAttrID, tag.UID{1, 1}, &amp.Tag{Text: "small"})
+	tx.Upsert(planet.planetID, testAttrID, tag.UID{1, 2}, &amp.Tag{Text: big})
+	planet.append(t, txID, author.seal(t, tx))
+
+	blobs, err := blobstore.OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	opts := CodexOpts{InlineThreshold: 1024, Blobs: blobs}
+	if _, err := ExportCodex(&buf, planet.journal, planet.planetID, nil, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	_, arts := readCodex(t, buf.Bytes())
+	small, large := arts[tag.UID{1, 1}], arts[tag.UID{1, 2}]
+	if small.BlobValue != nil || large.BlobValue == nil || len(large.InlineValue) != 0 {
+		t.Fatal("values not split at the inline threshold")
+	}
+	value, err := ArtifactValue(large, blobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, tagText(t, value), big)
+	if _, err := ArtifactValue(large, nil); !status.IsError(err, status.Code_BadRequest) {
+		t.Fatalf("expected BadRequest without a BlobStore, got %v", err)
+	}
+}
+
+func TestCodexWriteBinding(t *testing.T) {
+	planet := newTestPlanet(t, 3)
+	binding := amp.NewFoldBinding[*amp.Tag](tag.Name{ID: testAttrID})
+	planet.journal.ReadSince(planet.planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		tx, err := amp.OpenTxSansVerify(raw, offline{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		binding.OnNodeUpdate(amp.NodeUpdate{NodeID: planet.planetID, Revision: txTimeID, Tx: tx})
+		return true
+	})
+	status.Require(t, binding.ItemCount(), 3)
+
+	var buf bytes.Buffer
+	cw, err := NewCodexWriter(&buf, &amp.CodexHeader{SourcePlanet: amp.TagFromUID(planet.planetID)}, CodexOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBinding(cw, binding); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, arts := readCodex(t, buf.Bytes())
+	status.Require(t, len(arts), 3)
+	for i, itemID := range planet.txIDs[1:] {
+		art := arts[itemID]
+		status.Require(t, tag.UID{art.NodeID_0, art.NodeID_1}, planet.planetID)
+		status.Require(t, tag.UID{art.EditID_0, art.EditID_1}, itemID)
+		want, _ := binding.GetItem(itemID)
+		status.Require(t, tagText(t, art.InlineValue), want.Text)
+		status.Require(t, want.Text, fmt.Sprintf("entry %d", i))
+	}
+}
+
+func TestCodexContentModelEpoch(t *testing.T) {
+	var buf bytes.Buffer
+	header := &amp.CodexHeader{
+		SourcePlanet:      amp.TagFromUID(tag.NewID()),
+		ContentModelEpoch: amp.ContentModelEpoch + 1,
+	}
+	headerBytes, _ := proto.Marshal(header)
+	rec, err := newRecordWriter(&buf, CodexMagic, 0, headerBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.finish()
+
+	_, err = NewCodexReader(bytes.NewReader(buf.Bytes()))
+	if !status.IsError(err, status.Code_Unimplemented) {
+		t.Fatalf("expected a refusal for a foreign ContentModelEpoch, got %v", err)
+	}
+
+	// A chronicle is not a codex.
+	planet := newTestPlanet(t, 1)
+	if _, err := NewCodexReader(bytes.NewReader(planet.export(t))); !status.IsError(err, status.Code_ParseFailed) {
+		t.Fatalf("expected ParseFailed, got %v", err)
+	}
+}
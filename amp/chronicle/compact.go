@@ -0,0 +1,275 @@
+package chronicle
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// CompactOpts tunes how Compact reads a journal and resolves storage policy.
+type CompactOpts struct {
+	Registry amp.Registry       // EditFlow and RetainEdits per attr; nil = std.Registry()
+	Crypto   amp.CryptoProvider // opens sealed journal entries; nil reads planet-public entries only
+}
+
+// Compaction is the rebase of a planet's chronicle up to and including UpTo:
+// the signed TxMsgs that must survive for a replay to reach the same state, and
+// the CompactedDigest that binds them.
+type Compaction struct {
+	PlanetID tag.UID
+	UpTo     tag.UID   // last TxID covered (inclusive)
+	Kept     []tag.UID // surviving TxIDs at or below UpTo, ascending
+	Dropped  int       // superseded TxMsgs now discardable
+	Digest   []byte    // CompactedDigest
+}
+
+// compactEdit is one folded op of a cell, as seen by the rebase.
+type compactEdit struct {
+	editID tag.UID
+	tx     int // ordinal of the carrying TxMsg
+	delete bool
+}
+
+// compactCell gathers the edits to one element (node, attr, item).
+type compactCell struct {
+	retain int
+	edits  []compactEdit
+}
+
+// Compact rebases planetID's journal up to upTo and returns the minimal set of
+// signed TxMsgs whose replay is equivalent to the full prefix.  TxMsgs are kept
+// verbatim — their seals are the chronicle's authority — so a TxMsg survives
+// whole if any one of its ops does:
+//
+//   - governance-law ops always survive, so the authority chain stays verifiable;
+//   - EditFlow_Tape ops always survive, since the journal is their only store;
+//   - a folded cell keeps its RetainEdits most recent edits (by EditID), and a
+//     cell whose latest edit is a delete keeps nothing — unless an older edit of
+//     it rides a surviving TxMsg, in which case the delete survives too;
+//   - meta ops never persist and keep nothing.
+//
+// The result depends only on the journal's bytes and the registered storage
+// policy, so two peers holding the same prefix and registry compute the same
+// Kept set and Digest.  RetainEdits is taken as registered, never clamped to a
+// node-local cap, for the same reason.
+//
+// Digest is the TxRangeDigest (RangeHash) of the kept entries: a peer that has
+// pruned its journal to the compaction reports exactly it for the range ending
+// at UpTo.  Memory is proportional to the number of folded ops below upTo.
+func Compact(journal amp.TxJournal, planetID, upTo tag.UID, opts CompactOpts) (*Compaction, error) {
+	if upTo.IsNil() {
+		return nil, status.Code_BadRequest.Error("compact: UpTo TxID required")
+	}
+	registry := opts.Registry
+	if registry == nil {
+		registry = std.Registry()
+	}
+	crypto := opts.Crypto
+	if crypto == nil {
+		crypto = offline{}
+	}
+
+	var (
+		txIDs   []tag.UID
+		keep    []bool
+		cells   = map[tag.ElementLSM]*compactCell{}
+		openErr error
+	)
+	err := journal.ReadSince(planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		if txTimeID.CompareTo(upTo) > 0 {
+			return false
+		}
+		tx, err := amp.OpenTxSansVerify(raw, crypto)
+		if err != nil {
+			openErr = status.Code_DecryptFailed.Errorf("compact: TxMsg %v: %v", txTimeID, err)
+			return false
+		}
+		ordinal := len(txIDs)
+		txIDs = append(txIDs, txTimeID)
+		keep = append(keep, false)
+
+		for _, op := range tx.Ops {
+			if op.Flags&amp.TxOpFlags_MetaOp != 0 {
+				continue
+			}
+			if std.IsGovernanceLawAttr(op.Addr.AttrID) {
+				keep[ordinal] = true
+				continue
+			}
+			def, _ := registry.FindAttr(op.Addr.AttrID)
+			if def.EditFlow == amp.EditFlow_Tape {
+				keep[ordinal] = true
+				continue
+			}
+			elem := op.Addr.ElementLSM()
+			cell := cells[elem]
+			if cell == nil {
+				cell = &compactCell{
+					retain: max(int(def.RetainEdits), 1),
+				}
+				cells[elem] = cell
+			}
+			cell.edits = append(cell.edits, compactEdit{
+				editID: op.Addr.EditID,
+				tx:     ordinal,
+				delete: op.Flags&amp.TxOpFlags_Delete != 0,
+			})
+		}
+		return true
+	})
+	if err == nil {
+		err = openErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(txIDs) == 0 {
+		return nil, status.Code_BadRequest.Errorf("compact: planet %v has no TxMsgs at or below %v", planetID, upTo)
+	}
+
+	// Each cell keeps its most recent edits; ties in EditID resolve by journal
+	// order, which the stable sort preserves.
+	var deleted []*compactCell
+	for _, cell := range cells {
+		slices.SortStableFunc(cell.edits, func(a, b compactEdit) int {
+			return a.editID.CompareTo(b.editID)
+		})
+		latest := cell.edits[len(cell.edits)-1]
+		if latest.delete {
+			deleted = append(deleted, cell)
+			continue
+		}
+		for _, edit := range cell.edits[max(len(cell.edits)-cell.retain, 0):] {
+			keep[edit.tx] = true
+		}
+	}
+
+	// A deleted cell's stale edit riding a surviving TxMsg would be resurrected
+	// on replay, so its delete must survive as well.  Keeping that TxMsg can
+	// expose another deleted cell, so iterate to a fixed point.
+	for changed := true; changed; {
+		changed = false
+		for i, cell := range deleted {
+			if cell == nil {
+				continue
+			}
+			latest := cell.edits[len(cell.edits)-1]
+			for _, edit := range cell.edits {
+				if keep[edit.tx] {
+					if !keep[latest.tx] {
+						keep[latest.tx] = true
+						changed = true
+					}
+					deleted[i] = nil
+					break
+				}
+			}
+		}
+	}
+
+	c := &Compaction{
+		PlanetID: planetID,
+		UpTo:     upTo,
+	}
+	for i, txID := range txIDs {
+		if keep[i] {
+			c.Kept = append(c.Kept, txID)
+		} else {
+			c.Dropped++
+		}
+	}
+	digest := amp.NewTxRangeDigest()
+	err = c.ReadKept(journal, func(txTimeID tag.UID, raw []byte) bool {
+		digest.Add(txTimeID, raw)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if digest.Count() != len(c.Kept) {
+		return nil, status.Code_DataFailure.Errorf("compact: journal changed beneath the rebase (%d of %d kept entries read)", digest.Count(), len(c.Kept))
+	}
+	c.Digest = digest.Sum().AppendTo(nil)
+	return c, nil
+}
+
+// Retains reports whether txID survives the compaction; TxIDs after UpTo are
+// untouched by it and always survive.
+func (c *Compaction) Retains(txID tag.UID) bool {
+	if txID.CompareTo(c.UpTo) > 0 {
+		return true
+	}
+	_, found := slices.BinarySearchFunc(c.Kept, txID, tag.UID.CompareTo)
+	return found
+}
+
+// ReadKept streams the kept TxMsgs from journal in ascending TxID order until
+// cb returns false — the compacted slice, ready for a chronicle Writer.
+func (c *Compaction) ReadKept(journal amp.TxJournal, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	next := 0
+	return journal.ReadSince(c.PlanetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		if next == len(c.Kept) {
+			return false
+		}
+		if txTimeID != c.Kept[next] {
+			return txTimeID.CompareTo(c.UpTo) <= 0
+		}
+		next++
+		return cb(txTimeID, raw)
+	})
+}
+
+// NewCompactTx returns the governance TxMsg declaring this compaction, authored
+// by fromID at txID (which must follow UpTo).  CompactTime is txID's own time,
+// so the record is a pure function of the compaction and its TxID.  The caller
+// seals it under the governance quorum's authority.
+func (c *Compaction) NewCompactTx(txID, fromID tag.UID, label string) (*amp.TxMsg, error) {
+	if txID.CompareTo(c.UpTo) <= 0 {
+		return nil, status.Code_BadRequest.Errorf("compact: TxID %v does not follow UpTo %v", txID, c.UpTo)
+	}
+	tx := amp.TxNew()
+	tx.SetTxID(txID)
+	tx.SetPlanetID(c.PlanetID)
+	tx.SetFromID(fromID)
+	decl := &amp.ChronicleCompact{
+		UpToTxID_0:      c.UpTo[0],
+		UpToTxID_1:      c.UpTo[1],
+		CompactedDigest: c.Digest,
+		CompactTime:     txID.Unix(),
+		Label:           label,
+	}
+	if err := tx.Upsert(c.PlanetID, std.Attr.LawChronicleCompact.ID, c.UpTo, decl); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Point returns the lineage record of this compaction as declared by the
+// governance TxMsg compactTxID.
+func (c *Compaction) Point(compactTxID tag.UID) *amp.ChronicleCompactPoint {
+	return &amp.ChronicleCompactPoint{
+		UpToTxID_0:      c.UpTo[0],
+		UpToTxID_1:      c.UpTo[1],
+		CompactTime:     compactTxID.Unix(),
+		CompactedDigest: c.Digest,
+		CompactTxID_0:   compactTxID[0],
+		CompactTxID_1:   compactTxID[1],
+	}
+}
+
+// Check compares this compaction against a declared ChronicleCompact, returning
+// a DataFailure if the two rebases diverged.
+func (c *Compaction) Check(decl *amp.ChronicleCompact) error {
+	upTo := tag.UID{decl.UpToTxID_0, decl.UpToTxID_1}
+	if upTo != c.UpTo {
+		return status.Code_BadRequest.Errorf("compact: declaration covers up to %v, not %v", upTo, c.UpTo)
+	}
+	if !bytes.Equal(decl.CompactedDigest, c.Digest) {
+		return status.Code_DataFailure.Errorf("compact: CompactedDigest diverges at %v", c.UpTo)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package chronicle
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/journal"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// nextTxID returns a TxID following every TxMsg in the planet's journal.
+func (planet *testPlanet) nextTxID() tag.UID {
+	last := planet.txIDs[len(planet.txIDs)-1]
+	return tag.UID{last[0] + 1<<16, 0}
+}
+
+// requireKept fatals unless c kept exactly want.
+func requireKept(t *testing.T, c *Compaction, want ...tag.UID) {
+	t.Helper()
+	if !slices.Equal(c.Kept, want) {
+		t.Fatalf("expected Kept %v, got %v", want, c.Kept)
+	}
+}
+
+func compactOrFail(t *testing.T, journal amp.TxJournal, planetID, upTo tag.UID, opts CompactOpts) *Compaction {
+	t.Helper()
+	c, err := Compact(journal, planetID, upTo, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCompactFold(t *testing.T) {
+	planet := newTestPlanet(t, 4)
+	author := planet.founders[0]
+	items := planet.txIDs[1:]
+
+	// Overwrite items[0] and items[1], then delete items[2].
+	rewrite := planet.nextTxID()
+	tx := planet.newTx(rewrite, author)
+	tx.Upsert(planet.planetID, testAttrID, items[0], &amp.Tag{Text: "rewritten 0"})
+	tx.Upsert(planet.planetID, testAttrID, items[1], &amp.Tag{Text: "rewritten 1"})
+	planet.append(t, rewrite, author.seal(t, tx))
+
+	remove := planet.nextTxID()
+	tx = planet.newTx(remove, author)
+	tx.Delete(tag.ElementID{
+		NodeID: planet.planetID,
+		AttrID: testAttrID,
+		ItemID: items[2],
+	}, nil)
+	planet.append(t, remove, author.seal(t, tx))
+
+	c := compactOrFail(t, planet.journal, planet.planetID, remove, CompactOpts{})
+
+	// items[2]'s delete has no stale edit left to cancel, so it goes too.
+	requireKept(t, c, planet.txIDs[0], items[3], rewrite)
+	status.Require(t, c.Dropped, 4)
+	if c.Retains(items[0]) || !c.Retains(rewrite) || !c.Retains(tag.MaxID()) {
+		t.Fatal("Retains disagrees with Kept")
+	}
+
+	// The digest is the RangeHash of a journal pruned to the compaction.
+	pruned := journal.NewMemory()
+	defer pruned.Close()
+	err := c.ReadKept(planet.journal, func(txTimeID tag.UID, raw []byte) bool {
+		if err := pruned.Append(planet.planetID, txTimeID, raw); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rangeHash, _ := pruned.RangeHash(planet.planetID, tag.UID{}, tag.MaxID())
+	if !bytes.Equal(c.Digest, rangeHash.AppendTo(nil)) {
+		t.Fatal("CompactedDigest differs from the pruned journal's RangeHash")
+	}
+
+	// A peer restoring the same chronicle arrives at the same rebase.
+	peer := journal.NewMemory()
+	defer peer.Close()
+	if _, _, err := Import(bytes.NewReader(planet.export(t)), peer, planet.trust()); err != nil {
+		t.Fatal(err)
+	}
+	peerCompaction := compactOrFail(t, peer, planet.planetID, remove, CompactOpts{})
+	requireKept(t, peerCompaction, c.Kept...)
+	if !bytes.Equal(peerCompaction.Digest, c.Digest) {
+		t.Fatal("peers diverged on CompactedDigest")
+	}
+
+	// The pruned journal is already compact.
+	again := compactOrFail(t, pruned, planet.planetID, remove, CompactOpts{})
+	status.Require(t, again.Dropped, 0)
+	if !bytes.Equal(again.Digest, c.Digest) {
+		t.Fatal("recompacting changed the CompactedDigest")
+	}
+}
+
+func TestCompactDeleteSurvives(t *testing.T) {
+	planet := newTestPlanet(t, 0)
+	author := planet.founders[0]
+	x, z := tag.UID{1, 1}, tag.UID{1, 2}
+
+	// z keeps the first TxMsg alive, which still carries x's stale edit, so the
+	// delete of x must survive or a replay would resurrect it.
+	first := planet.nextTxID()
+	tx := planet.newTx(first, author)
+	tx.Upsert(planet.planetID, testAttrID, x, &amp.Tag{Text: "x"})
+	tx.Upsert(planet.planetID, testAttrID, z, &amp.Tag{Text: "z"})
+	planet.append(t, first, author.seal(t, tx))
+
+	remove := planet.nextTxID()
+	tx = planet.newTx(remove, author)
+	tx.Delete(tag.ElementID{
+		NodeID: planet.planetID,
+		AttrID: testAttrID,
+		ItemID: x,
+	}, nil)
+	planet.append(t, remove, author.seal(t, tx))
+
+	c := compactOrFail(t, planet.journal, planet.planetID, remove, CompactOpts{})
+	requireKept(t, c, planet.txIDs[0], first, remove)
+	status.Require(t, c.Dropped, 0)
+}
+
+func TestCompactStoragePolicy(t *testing.T) {
+	planet := newTestPlanet(t, 0)
+	author := planet.founders[0]
+
+	registry := std.NewRegistry()
+	tapeAttr := tag.Name{ID: tag.UID{0xAAAA, 1}, Text: "test.tape.Tag"}
+	deepAttr := tag.Name{ID: tag.UID{0xAAAA, 2}, Text: "test.deep.Tag"}
+	for _, def := range []amp.AttrDef{
+		{Name: tapeAttr, Prototype: &amp.Tag{}, EditFlow: amp.EditFlow_Tape},
+		{Name: deepAttr, Prototype: &amp.Tag{}, RetainEdits: 2},
+	} {
+		if err := registry.RegisterAttr(def); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Three edits to one tape cell and three to a cell folding two deep.
+	var tape, deep []tag.UID
+	for range 3 {
+		txID := planet.nextTxID()
+		tx := planet.newTx(txID, author)
+		tx.Upsert(planet.planetID, tapeAttr.ID, tag.UID{2, 1}, &amp.Tag{Text: "frame"})
+		planet.append(t, txID, author.seal(t, tx))
+		tape = append(tape, txID)
+
+		txID = planet.nextTxID()
+		tx = planet.newTx(txID, author)
+		tx.Upsert(planet.planetID, deepAttr.ID, tag.UID{2, 2}, &amp.Tag{Text: "edit"})
+		planet.append(t, txID, author.seal(t, tx))
+		deep = append(deep, txID)
+	}
+
+	// A TxMsg of meta ops alone never persists.
+	meta := planet.nextTxID()
+	tx := planet.newTx(meta, author)
+	op := amp.TxOp{Flags: amp.TxOpFlags_MetaOp | amp.TxOpFlags_Upsert}
+	op.Addr.NodeID, op.Addr.AttrID, op.Addr.ItemID = planet.planetID, testAttrID, tag.UID{2, 3}
+	if err := tx.MarshalOp(&op, &amp.Tag{Text: "transient"}); err != nil {
+		t.Fatal(err)
+	}
+	planet.append(t, meta, author.seal(t, tx))
+
+	c := compactOrFail(t, planet.journal, planet.planetID, meta, CompactOpts{Registry: registry})
+	requireKept(t, c, planet.txIDs[0], tape[0], tape[1], deep[1], tape[2], deep[2])
+	status.Require(t, c.Dropped, 2)
+
+	// Unregistered, the deep attr folds one deep like any other.
+	c = compactOrFail(t, planet.journal, planet.planetID, meta, CompactOpts{})
+	requireKept(t, c, planet.txIDs[0], tape[2], deep[2])
+}
+
+func TestCompactTx(t *testing.T) {
+	planet := newTestPlanet(t, 3)
+	lead := planet.founders[0]
+	upTo := planet.txIDs[len(planet.txIDs)-1]
+	c := compactOrFail(t, planet.journal, planet.planetID, upTo, CompactOpts{})
+
+	if _, err := c.NewCompactTx(upTo, lead.memberID, ""); !status.IsError(err, status.Code_BadRequest) {
+		t.Fatalf("expected BadRequest for a TxID within the compaction, got %v", err)
+	}
+	compactID := planet.nextTxID()
+	tx, err := c.NewCompactTx(compactID, lead.memberID, "quarterly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The declaration reads back as governance and matches a peer's rebase.
+	sealed := lead.seal(t, tx)
+	opened, err := amp.OpenTxSansVerify(sealed, offline{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := opened.Ops[0]
+	if !std.IsGovernanceLawAttr(op.Addr.AttrID) {
+		t.Fatal("ChronicleCompact is not a governance-law attr")
+	}
+	decl := &amp.ChronicleCompact{}
+	if err := opened.UnmarshalOpValue(0, decl); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, decl.Label, "quarterly")
+	status.Require(t, decl.CompactTime, compactID.Unix())
+	if err := c.Check(decl); err != nil {
+		t.Fatal(err)
+	}
+	point := c.Point(compactID)
+	if !bytes.Equal(point.CompactedDigest, decl.CompactedDigest) {
+		t.Fatal("Point and declaration disagree")
+	}
+	status.Require(t, tag.UID{point.CompactTxID_0, point.CompactTxID_1}, compactID)
+
+	decl.CompactedDigest = bytes.Clone(decl.CompactedDigest)
+	decl.CompactedDigest[0] ^= 1
+	if err := c.Check(decl); !status.IsError(err, status.Code_DataFailure) {
+		t.Fatalf("expected DataFailure for a divergent digest, got %v", err)
+	}
+
+	// A later compaction keeps the declaration as part of the authority chain.
+	planet.append(t, compactID, sealed)
+	later := compactOrFail(t, planet.journal, planet.planetID, compactID, CompactOpts{})
+	if !later.Retains(compactID) {
+		t.Fatal("compaction TxMsg dropped by a later compaction")
+	}
+}
@@ -0,0 +1,210 @@
+package chronicle
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+// Container layout shared by chronicle.bin and codex.bin:
+//
+//	preamble   magic (8 bytes) ‖ u32 BE FormatVersion
+//	header     u32 BE len ‖ header proto
+//	entries    u32 BE len ‖ entry bytes            (len > 0)
+//	end        u32 BE 0
+//	trailer    u64 BE entry count ‖ digest
+//
+// The digest is the header's DigestHashKit run over every byte before it —
+// preamble through the entry count — so truncation, reordering, or a flipped
+// bit anywhere in the file is detected.  The count in the trailer is the
+// authoritative one; headers carry none.
+const (
+	// FormatVersion is the on-disk container layout this package reads and writes.
+	FormatVersion = 1
+
+	// MaxRecordSize bounds one header or entry record, so a damaged length
+	// field cannot drive an unbounded allocation.
+	MaxRecordSize = 64 << 20
+
+	magicSize    = 8
+	preambleSize = magicSize + 4
+)
+
+// recordWriter frames records into a container, digesting as it goes.
+type recordWriter struct {
+	w       *bufio.Writer
+	digest  safe.HashKit
+	entries int64
+	scrap   [8]byte
+}
+
+func newRecordWriter(w io.Writer, magic string, digestKit safe.HashKitID, header []byte) (*recordWriter, error) {
+	kit, err := safe.NewHashKit(digestKit)
+	if err != nil {
+		return nil, err
+	}
+	kit.Hasher.Reset()
+	rw := &recordWriter{
+		w:      bufio.NewWriterSize(w, 64<<10),
+		digest: kit,
+	}
+	preamble := binary.BigEndian.AppendUint32([]byte(magic), FormatVersion)
+	if err := rw.write(preamble); err != nil {
+		return nil, err
+	}
+	if err := rw.writeRecord(header); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+// write emits and digests p.
+func (rw *recordWriter) write(p []byte) error {
+	rw.digest.Hasher.Write(p)
+	_, err := rw.w.Write(p)
+	return err
+}
+
+func (rw *recordWriter) writeRecord(body []byte) error {
+	if len(body) == 0 || len(body) > MaxRecordSize {
+		return status.Code_BadRequest.Errorf("chronicle: record size %d out of range", len(body))
+	}
+	binary.BigEndian.PutUint32(rw.scrap[:4], uint32(len(body)))
+	if err := rw.write(rw.scrap[:4]); err != nil {
+		return err
+	}
+	return rw.write(body)
+}
+
+func (rw *recordWriter) writeEntry(body []byte) error {
+	if err := rw.writeRecord(body); err != nil {
+		return err
+	}
+	rw.entries++
+	return nil
+}
+
+// finish writes the end marker and trailer and flushes.
+func (rw *recordWriter) finish() error {
+	binary.BigEndian.PutUint32(rw.scrap[:4], 0)
+	if err := rw.write(rw.scrap[:4]); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint64(rw.scrap[:8], uint64(rw.entries))
+	if err := rw.write(rw.scrap[:8]); err != nil {
+		return err
+	}
+	if _, err := rw.w.Write(rw.digest.Hasher.Sum(nil)); err != nil {
+		return err
+	}
+	return rw.w.Flush()
+}
+
+// recordReader walks a container's records, verifying the trailer once the end
+// marker is reached.
+type recordReader struct {
+	r       *bufio.Reader
+	digest  safe.HashKit
+	entries int64
+	done    bool
+	scrap   [8]byte
+	prefix  []byte // preamble and header record, digested once the kit is known
+}
+
+// openRecords checks the preamble and returns the raw header record.  The
+// caller parses it and names the digest kit via setDigest before reading entries.
+func openRecords(r io.Reader, magic string) (*recordReader, []byte, error) {
+	rr := &recordReader{
+		r: bufio.NewReaderSize(r, 64<<10),
+	}
+	preamble := make([]byte, preambleSize)
+	if _, err := io.ReadFull(rr.r, preamble); err != nil {
+		return nil, nil, status.Code_ParseFailed.Errorf("chronicle: short preamble: %v", err)
+	}
+	if !bytes.Equal(preamble[:magicSize], []byte(magic)) {
+		return nil, nil, status.Code_ParseFailed.Errorf("chronicle: not a %q container", magic)
+	}
+	if version := binary.BigEndian.Uint32(preamble[magicSize:]); version != FormatVersion {
+		return nil, nil, status.Code_Unimplemented.Errorf("chronicle: unsupported container FormatVersion %d", version)
+	}
+	header, err := rr.readRecord()
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		return nil, nil, status.Code_ParseFailed.Error("chronicle: missing header record")
+	}
+	rr.prefix = append(preamble, rr.scrap[:4]...)
+	rr.prefix = append(rr.prefix, header...)
+	return rr, header, nil
+}
+
+func (rr *recordReader) setDigest(digestKit safe.HashKitID) error {
+	kit, err := safe.NewHashKit(digestKit)
+	if err != nil {
+		return err
+	}
+	kit.Hasher.Reset()
+	kit.Hasher.Write(rr.prefix)
+	rr.digest = kit
+	rr.prefix = nil
+	return nil
+}
+
+// readRecord reads one framed record, returning nil at the end marker.  The
+// length prefix is left in scrap[:4].
+func (rr *recordReader) readRecord() ([]byte, error) {
+	if _, err := io.ReadFull(rr.r, rr.scrap[:4]); err != nil {
+		return nil, status.Code_ParseFailed.Errorf("chronicle: truncated before end marker: %v", err)
+	}
+	size := binary.BigEndian.Uint32(rr.scrap[:4])
+	if size == 0 {
+		return nil, nil
+	}
+	if size > MaxRecordSize {
+		return nil, status.Code_ParseFailed.Errorf("chronicle: record size %d exceeds MaxRecordSize", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(rr.r, body); err != nil {
+		return nil, status.Code_ParseFailed.Errorf("chronicle: truncated record: %v", err)
+	}
+	return body, nil
+}
+
+// nextEntry returns the next entry, or io.EOF once the trailer has verified.
+func (rr *recordReader) nextEntry() ([]byte, error) {
+	if rr.done {
+		return nil, io.EOF
+	}
+	body, err := rr.readRecord()
+	if err != nil {
+		return nil, err
+	}
+	rr.digest.Hasher.Write(rr.scrap[:4])
+	if body != nil {
+		rr.digest.Hasher.Write(body)
+		rr.entries++
+		return body, nil
+	}
+
+	if _, err := io.ReadFull(rr.r, rr.scrap[:8]); err != nil {
+		return nil, status.Code_ParseFailed.Errorf("chronicle: truncated trailer: %v", err)
+	}
+	rr.digest.Hasher.Write(rr.scrap[:8])
+	if count := int64(binary.BigEndian.Uint64(rr.scrap[:8])); count != rr.entries {
+		return nil, status.Code_DataFailure.Errorf("chronicle: trailer counts %d entries, read %d", count, rr.entries)
+	}
+	want := make([]byte, rr.digest.HashSz)
+	if _, err := io.ReadFull(rr.r, want); err != nil {
+		return nil, status.Code_ParseFailed.Errorf("chronicle: truncated trailer digest: %v", err)
+	}
+	if !bytes.Equal(rr.digest.Hasher.Sum(nil), want) {
+		return nil, status.Code_DataFailure.Error("chronicle: container digest mismatch")
+	}
+	rr.done = true
+	return nil, io.EOF
+}
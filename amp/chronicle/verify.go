@@ -0,0 +1,304 @@
+package chronicle
+
+import (
+	"bytes"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Trust is the out-of-band root a chronicle is verified against — nothing in
+// the file itself is trusted until it checks out under these keys.
+type Trust struct {
+
+	// Founders maps each founder's MemberID to their signing key, as obtained
+	// out of band (e.g. corroborated against a FounderFingerprint).  Required.
+	Founders map[tag.UID]safe.PubKey
+
+	// Members optionally admits further authors the caller already trusts (e.g.
+	// keys resolved from the planet's MemberEpochs).  Authors admitted by the
+	// chronicle's own verified MemberEpochs need not be listed; an entry signed
+	// by a key trusted by neither fails verification.
+	Members map[tag.UID]safe.PubKey
+
+	// HashKit is the author-seal digest kit for a confidential planet, whose
+	// genesis EpochTerms cannot be read offline.  A public genesis supplies its
+	// own (EpochTerms.HashKit) and this is ignored.
+	HashKit safe.HashKitID
+}
+
+// Verifier checks TxMsg author seals against a chronicle's trust root.
+// Construct with NewVerifier, which first verifies the embedded genesis.
+//
+// Entries must be verified in TxID order: each verified planet-public TxMsg's
+// MemberEpoch records are folded under the same custody rules as
+// amp.MemberEpochMerger, so an author admitted (or a key rotated) by an earlier
+// entry is trusted for the entries after it, and a member an issuer suspends
+// or revokes is trusted no longer.  Founders remain anchored to Trust.Founders
+// — only a founder's own record rotates their key.  Sealed payloads cannot be
+// read offline, so admissions they carry are not seen.
+type Verifier struct {
+	planetID tag.UID
+	hashKit  safe.HashKitID
+	founders map[tag.UID]safe.PubKey
+	signers  map[tag.UID]safe.PubKey
+	members  map[tag.UID]*amp.MemberEpoch // folded MemberEpoch per member
+	merger   *amp.MemberEpochMerger
+	lastID   tag.UID // most recent sealed-payload signer, tried first
+}
+
+// NewVerifier verifies header.GenesisEpoch under trust and returns a Verifier
+// for the chronicle's entries.
+//
+// A planet-public genesis is opened and checked in full: its PlanetEpoch
+// Charter names this planet and only trusted founders, any founder key it
+// carries matches the trusted one, the founders' co-signatures meet the
+// genesis quorum, and the TxMsg itself is sealed by a founder.  A confidential
+// genesis cannot be opened offline, so only its seal is checked — it must
+// verify under one of the founders' keys.
+func NewVerifier(header *amp.ChronicleHeader, trust Trust) (*Verifier, error) {
+	if len(trust.Founders) == 0 {
+		return nil, status.Code_BadRequest.Error("chronicle: Trust requires the founders' keys")
+	}
+	v := &Verifier{
+		planetID: header.SourcePlanet.UID(),
+		hashKit:  trust.HashKit,
+		founders: trust.Founders,
+		signers:  make(map[tag.UID]safe.PubKey, len(trust.Founders)+len(trust.Members)),
+		members:  make(map[tag.UID]*amp.MemberEpoch),
+		merger:   amp.NewMemberEpochMerger(),
+	}
+	for memberID, key := range trust.Members {
+		v.signers[memberID] = key
+	}
+	for founderID, key := range trust.Founders {
+		v.signers[founderID] = key
+	}
+
+	genesis := header.GenesisEpoch
+	env, err := amp.ParseTxEnvelope(genesis)
+	if err != nil {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch: %v", err)
+	}
+	if env.PlanetID() != v.planetID {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch belongs to planet %v", env.PlanetID())
+	}
+	if start, _ := headerRange(header); !start.IsNil() && start.CompareTo(env.TxID()) < 0 {
+		return nil, status.Code_AuthFailed.Error("chronicle: Range starts before genesis")
+	}
+	if !env.IsPublic() {
+		if _, err := v.verifySeal(genesis, env, trust.Founders); err != nil {
+			return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch: %v", err)
+		}
+		return v, nil
+	}
+
+	tx, err := amp.OpenTxSansVerify(genesis, offline{})
+	if err != nil {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch: %v", err)
+	}
+	epoch := &amp.PlanetEpoch{}
+	founderKeys := map[tag.UID]safe.PubKey{}
+	var genesisKeys []*amp.MemberEpoch
+	for i, op := range tx.Ops {
+		switch op.Addr.AttrID {
+		case std.Attr.LawPlanetEpoch.ID:
+			err = tx.UnmarshalOpValue(i, epoch)
+		case std.Attr.LawMemberEpoch.ID:
+			member := &amp.MemberEpoch{}
+			if err = tx.UnmarshalOpValue(i, member); err == nil {
+				genesisKeys = append(genesisKeys, member)
+			}
+		}
+		if err != nil {
+			return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch op %d: %v", i, err)
+		}
+	}
+	charter, err := epoch.ParsedCharter()
+	if err != nil {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch carries no PlanetEpoch: %v", err)
+	}
+	terms, err := epoch.ParsedTerms()
+	if err != nil {
+		return nil, status.Code_AuthFailed.Wrap(err)
+	}
+	if charter.PlanetID.UID() != v.planetID || !terms.IsGenesis() {
+		return nil, status.Code_AuthFailed.Error("chronicle: GenesisEpoch is not this planet's genesis")
+	}
+	for _, founder := range charter.Founders {
+		key, trusted := trust.Founders[founder.UID()]
+		if !trusted {
+			return nil, status.Code_AuthFailed.Errorf("chronicle: genesis founder %v is not in Trust.Founders", founder.UID())
+		}
+		founderKeys[founder.UID()] = key
+	}
+	for _, member := range genesisKeys {
+		key, isFounder := founderKeys[member.MemberTag.UID()]
+		if !isFounder || member.SigningKey == nil || len(member.SigningKey.PubKey) == 0 {
+			continue
+		}
+		if member.SigningKey.Kit() != key.CryptoKitID || !bytes.Equal(member.SigningKey.PubKey, key.Bytes) {
+			return nil, status.Code_AuthFailed.Errorf("chronicle: genesis declares a different key for founder %v", member.MemberTag.UID())
+		}
+	}
+	digest, err := epoch.CoSignatureDigest()
+	if err != nil {
+		return nil, status.Code_AuthFailed.Wrap(err)
+	}
+	if _, err := amp.VerifyCoSignatureQuorum(epoch.Signatures, digest, founderKeys, int(charter.GenesisRequiredSignatures)); err != nil {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: genesis quorum: %v", err)
+	}
+
+	v.hashKit = terms.EffectiveHashKit()
+	signerID, err := v.verifySeal(genesis, env, founderKeys)
+	if err != nil {
+		return nil, status.Code_AuthFailed.Errorf("chronicle: GenesisEpoch: %v", err)
+	}
+	if signerID != tx.FromID() {
+		return nil, status.Code_AuthFailed.Error("chronicle: GenesisEpoch sealed by a founder other than its author")
+	}
+	v.admit(tx)
+	return v, nil
+}
+
+// VerifyTx checks one sealed TxMsg's author seal and returns the signer's
+// MemberID.  A planet-public TxMsg names its author (FromID), whose key must be
+// trusted; a sealed payload hides its author offline, so the seal must verify
+// under some trusted key.
+func (v *Verifier) VerifyTx(raw []byte) (tag.UID, error) {
+	env, err := amp.ParseTxEnvelope(raw)
+	if err != nil {
+		return tag.UID{}, err
+	}
+	if env.PlanetID() != v.planetID {
+		return tag.UID{}, status.Code_AuthFailed.Errorf("chronicle: TxMsg belongs to planet %v", env.PlanetID())
+	}
+	if !env.IsPublic() {
+		return v.verifySeal(raw, env, v.signers)
+	}
+
+	tx, err := amp.OpenTxSansVerify(raw, offline{})
+	if err != nil {
+		return tag.UID{}, err
+	}
+	authorID := tx.FromID()
+	key, trusted := v.signers[authorID]
+	if !trusted {
+		return tag.UID{}, status.Code_AuthFailed.Errorf("chronicle: author %v is not trusted", authorID)
+	}
+	signerID, err := v.verifySeal(raw, env, map[tag.UID]safe.PubKey{authorID: key})
+	if err != nil {
+		return tag.UID{}, err
+	}
+	v.admit(tx)
+	return signerID, nil
+}
+
+// admit folds a verified planet-public TxMsg's MemberEpoch records and updates
+// the trusted signers to match: a member's current SigningKey is trusted while
+// their Status is Active.  A founder's key follows only their own records, and
+// issuer Status never withdraws it.
+func (v *Verifier) admit(tx *amp.TxMsg) {
+	for i, op := range tx.Ops {
+		if op.Addr.AttrID != std.Attr.LawMemberEpoch.ID || (op.Flags&amp.TxOpFlags_Delete) != 0 {
+			continue
+		}
+		record := &amp.MemberEpoch{}
+		if tx.UnmarshalOpValue(i, record) != nil {
+			continue
+		}
+		memberID := op.Addr.ItemID
+		prev, hasPrev := v.members[memberID]
+		merged, changed := v.merger.MergeItem(amp.AttrItem[*amp.MemberEpoch]{Addr: op.Addr, Value: record, Tx: tx}, prev, hasPrev)
+		if !changed {
+			continue
+		}
+		v.members[memberID] = merged
+
+		if _, isFounder := v.founders[memberID]; isFounder {
+			if tx.FromID() == memberID && op.Addr.EditID == tx.TxID() && declaredKey(merged.SigningKey) {
+				v.signers[memberID] = signingKey(merged.SigningKey)
+			}
+			continue
+		}
+		switch {
+		case merged.Status != amp.MemberStatus_Active:
+			delete(v.signers, memberID)
+		case declaredKey(merged.SigningKey):
+			v.signers[memberID] = signingKey(merged.SigningKey)
+		}
+	}
+}
+
+func declaredKey(key *safe.KeyRef) bool {
+	return key != nil && len(key.PubKey) > 0
+}
+
+func signingKey(key *safe.KeyRef) safe.PubKey {
+	return safe.PubKey{
+		CryptoKitID: key.Kit(),
+		KeyType:     safe.KeyType_SigningKey,
+		Bytes:       key.PubKey,
+	}
+}
+
+// verifySeal checks raw's author seal under the given keys, trying the most
+// recent signer first, and returns the matching signer.
+func (v *Verifier) verifySeal(raw []byte, env *amp.TxEnvelope, keys map[tag.UID]safe.PubKey) (tag.UID, error) {
+	digest, sig, err := amp.TxSignedDigest(raw, v.hashKit)
+	if err != nil {
+		return tag.UID{}, err
+	}
+	if key, ok := keys[v.lastID]; ok {
+		if safe.VerifySignature(key.CryptoKitID, sig, digest, key.Bytes) == nil {
+			return v.lastID, nil
+		}
+	}
+	for signerID, key := range keys {
+		if signerID == v.lastID {
+			continue
+		}
+		if safe.VerifySignature(key.CryptoKitID, sig, digest, key.Bytes) == nil {
+			v.lastID = signerID
+			return signerID, nil
+		}
+	}
+	return tag.UID{}, status.Code_AuthFailed.Errorf("chronicle: TxMsg %v seal verifies under no trusted key", env.TxID())
+}
+
+// offline is the CryptoProvider for offline reads: it opens planet-public
+// TxMsgs (which never touch it) and holds no keys, so anything else fails.
+type offline struct{}
+
+func (offline) SignatureSize() int { return 0 }
+
+func (offline) HashDigest(parts ...[]byte) ([32]byte, error) {
+	return [32]byte{}, status.Code_Unimplemented.Error("chronicle: offline provider")
+}
+
+func (offline) SignDigest(digest []byte) ([]byte, error) {
+	return nil, status.Code_Unimplemented.Error("chronicle: offline provider")
+}
+
+func (offline) VerifyDigest(sig []byte, digest []byte, signerPubKey []byte, cryptoKit safe.CryptoKitID) error {
+	return status.Code_Unimplemented.Error("chronicle: offline provider")
+}
+
+func (offline) EncryptPayload(plaintext []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, status.ErrEpochKeyNotFound
+}
+
+func (offline) DecryptPayload(ciphertext []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, status.ErrEpochKeyNotFound
+}
+
+func (offline) ComputeMemberProof(txID []byte, env *amp.TxEnvelope) ([]byte, error) {
+	return nil, status.ErrEpochKeyNotFound
+}
+
+func (offline) VerifyMemberProof(proof, txID []byte, env *amp.TxEnvelope) error {
+	return status.ErrEpochKeyNotFound
+}
@@ -0,0 +1,323 @@
+// Package handshake authenticates two vault peers to each other by key.
+//
+// A peer's identity is a hash of its signing key (PeerID), so no certificate
+// authority takes part: each side proves possession of the key it names by
+// signing the handshake transcript.  The exchange runs over any io.ReadWriter,
+// once the connection-type prefix amp.VaultAccessMagic has been written by the
+// dialer and consumed by the listener:
+//
+//	initiator → responder   hello_I
+//	responder → initiator   hello_R, sig_R
+//	initiator → responder   sig_I
+//
+//	hello  = version (1) ‖ ephemeral X25519 pubkey (32) ‖ nonce (32) ‖ KeyRef (proto: Kit, Type, PubKey)
+//	sig_X  = SignDomain(SigningDomain_VaultPeer, role_X, hello_I, hello_R)
+//
+// Each message is framed as u32 big-endian length ‖ body.  A signature covers
+// both hellos — both nonces and both ephemeral keys — so it is fresh for this
+// session and cannot be replayed into another, and the role label keeps a
+// responder's proof from being reflected back as an initiator's.  Because the
+// ephemeral keys are signed, the session key derived from their X25519 shared
+// secret is known only to the two authenticated peers, and a recorded
+// handshake stays sealed after either signing key is later compromised.
+package handshake
+
+import (
+	"crypto/ecdh"
+	"encoding/binary"
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Version is the handshake wire version this package speaks.
+	Version = 1
+
+	// MaxMsgSize bounds a single handshake message; a KeyRef with a
+	// post-quantum pubkey still fits with room to spare.
+	MaxMsgSize = 16 << 10
+
+	// SessionKeySize is the length of Result.SessionKey.
+	SessionKeySize = safe.DEKSize
+
+	nonceSize = 32
+
+	// TranscriptHashKit digests the transcript and every proof (fixed for v1).
+	TranscriptHashKit = safe.HashKitID_Blake2s_256
+
+	roleInitiator = "initiator"
+	roleResponder = "responder"
+	sessionInfo   = "amp.handshake.session.v1"
+)
+
+// Config is one side's view of a handshake.
+type Config struct {
+	Enclave safe.Enclave // holds the local signing key
+	KeyRef  *safe.KeyRef // local SigningKey (a PubKey prefix resolves via Enclave.FetchPubKey)
+
+	// ExpectPeer, when set, fails the handshake unless the peer proves this
+	// PeerID — a dialer normally knows whom it is calling.
+	ExpectPeer tag.UID
+
+	// Admit, when set, is consulted once the peer has proven its key; an error
+	// fails the handshake (before the initiator's proof is sent, on that side).
+	Admit func(peerID tag.UID, peerKey *safe.KeyRef) error
+
+	Rand io.Reader // nil = safe.RandReader
+}
+
+// Result is a completed handshake.
+type Result struct {
+	PeerID     tag.UID      // the peer's proven identity (see PeerID)
+	PeerKey    *safe.KeyRef // the peer's signing key: Kit, Type, full PubKey
+	SessionKey []byte       // SessionKeySize bytes shared only with the peer; the caller zeroes it
+	SessionID  []byte       // transcript digest, identical on both sides (channel binding)
+}
+
+// PeerID returns the identity a signing key proves: the did:key fold
+// (amp.DIDKeyUID) for kits that have a did:key form, so a node's PeerID and
+// the MemberID minted from the same key agree; otherwise the kit ID hashed
+// with the pubkey.
+func PeerID(kit safe.CryptoKitID, pubKey []byte) tag.UID {
+	if peerID, ok := amp.DIDKeyUID(kit, pubKey); ok {
+		return peerID
+	}
+	return kit.HashLiteral(pubKey)
+}
+
+// Initiate runs the dialer's side of the handshake over rw.
+func Initiate(rw io.ReadWriter, cfg Config) (*Result, error) {
+	local, err := newSide(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeMsg(rw, local.hello); err != nil {
+		return nil, err
+	}
+	helloR, err := readMsg(rw)
+	if err != nil {
+		return nil, err
+	}
+	sigR, err := readMsg(rw)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := parseHello(helloR)
+	if err != nil {
+		return nil, err
+	}
+	if err := local.checkPeer(peer, roleResponder, sigR, local.hello, helloR); err != nil {
+		return nil, err
+	}
+	sigI, err := local.sign(roleInitiator, local.hello, helloR)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeMsg(rw, sigI); err != nil {
+		return nil, err
+	}
+	return local.result(peer, local.hello, helloR)
+}
+
+// Respond runs the listener's side of the handshake over rw.
+func Respond(rw io.ReadWriter, cfg Config) (*Result, error) {
+	local, err := newSide(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	helloI, err := readMsg(rw)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := parseHello(helloI)
+	if err != nil {
+		return nil, err
+	}
+	sigR, err := local.sign(roleResponder, helloI, local.hello)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeMsg(rw, local.hello); err != nil {
+		return nil, err
+	}
+	if err := writeMsg(rw, sigR); err != nil {
+		return nil, err
+	}
+	sigI, err := readMsg(rw)
+	if err != nil {
+		return nil, err
+	}
+	if err := local.checkPeer(peer, roleInitiator, sigI, helloI, local.hello); err != nil {
+		return nil, err
+	}
+	return local.result(peer, helloI, local.hello)
+}
+
+// side is one end's handshake state.
+type side struct {
+	cfg   Config
+	ref   *safe.KeyRef // resolved local signing KeyRef
+	eph   *ecdh.PrivateKey
+	hello []byte
+}
+
+// peerHello is a parsed hello.
+type peerHello struct {
+	ephPub *ecdh.PublicKey
+	key    *safe.KeyRef
+	id     tag.UID
+}
+
+func newSide(cfg Config) (*side, error) {
+	if cfg.Enclave == nil || cfg.KeyRef == nil {
+		return nil, status.Code_BadRequest.Error("handshake: Config requires an Enclave and KeyRef")
+	}
+	if !cfg.Enclave.CanSign(cfg.KeyRef) {
+		return nil, status.Code_BadRequest.Error("handshake: KeyRef does not name a held SigningKey")
+	}
+	pub, err := cfg.Enclave.FetchPubKey(cfg.KeyRef)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = safe.RandReader
+	}
+	eph, err := ecdh.X25519().GenerateKey(cfg.Rand)
+	if err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+
+	ref := proto.Clone(cfg.KeyRef).(*safe.KeyRef)
+	ref.PubKey = pub.Bytes
+
+	// The wire KeyRef names the key alone; the local keyring stays private.
+	wireRef := &safe.KeyRef{
+		Type:   safe.KeyType_SigningKey,
+		PubKey: pub.Bytes,
+	}
+	wireRef.SetKit(pub.CryptoKitID)
+	refBytes, err := proto.Marshal(wireRef)
+	if err != nil {
+		return nil, err
+	}
+
+	hello := make([]byte, 0, 1+32+nonceSize+len(refBytes))
+	hello = append(hello, Version)
+	hello = append(hello, eph.PublicKey().Bytes()...)
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(cfg.Rand, nonce); err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	hello = append(hello, nonce...)
+	hello = append(hello, refBytes...)
+
+	return &side{
+		cfg:   cfg,
+		ref:   ref,
+		eph:   eph,
+		hello: hello,
+	}, nil
+}
+
+func parseHello(hello []byte) (*peerHello, error) {
+	const fixed = 1 + 32 + nonceSize
+	if len(hello) <= fixed {
+		return nil, status.Code_ParseFailed.Error("handshake: hello truncated")
+	}
+	if hello[0] != Version {
+		return nil, status.Code_ParseFailed.Errorf("handshake: peer speaks version %d", hello[0])
+	}
+	ephPub, err := ecdh.X25519().NewPublicKey(hello[1:33])
+	if err != nil {
+		return nil, status.Code_ParseFailed.Wrap(err)
+	}
+	key := &safe.KeyRef{}
+	if err := proto.Unmarshal(hello[fixed:], key); err != nil {
+		return nil, status.Code_ParseFailed.Wrap(err)
+	}
+	if key.Type != safe.KeyType_SigningKey || len(key.PubKey) == 0 {
+		return nil, status.Code_ParseFailed.Error("handshake: peer KeyRef is not a SigningKey")
+	}
+	return &peerHello{
+		ephPub: ephPub,
+		key:    key,
+		id:     PeerID(key.Kit(), key.PubKey),
+	}, nil
+}
+
+func (local *side) sign(role string, helloI, helloR []byte) ([]byte, error) {
+	return safe.SignDomain(local.cfg.Enclave, local.ref, TranscriptHashKit, safe.SigningDomain_VaultPeer, []byte(role), helloI, helloR)
+}
+
+// checkPeer verifies the peer's proof, then applies ExpectPeer and Admit.
+func (local *side) checkPeer(peer *peerHello, role string, sig, helloI, helloR []byte) error {
+	err := safe.VerifyDomain(peer.key.Kit(), TranscriptHashKit, safe.SigningDomain_VaultPeer, sig, peer.key.PubKey, []byte(role), helloI, helloR)
+	if err != nil {
+		return status.Code_AuthFailed.Errorf("handshake: peer proof rejected: %v", err)
+	}
+	if !local.cfg.ExpectPeer.IsNil() && peer.id != local.cfg.ExpectPeer {
+		return status.Code_AuthFailed.Errorf("handshake: peer proved %v, expected %v", peer.id, local.cfg.ExpectPeer)
+	}
+	if local.cfg.Admit != nil {
+		if err := local.cfg.Admit(peer.id, peer.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// result derives the session key from the ephemeral shared secret, salted with
+// the transcript digest.
+func (local *side) result(peer *peerHello, helloI, helloR []byte) (*Result, error) {
+	shared, err := local.eph.ECDH(peer.ephPub)
+	if err != nil {
+		return nil, status.Code_AuthFailed.Wrap(err)
+	}
+	defer safe.Zero(shared)
+	sessionID, err := safe.SigningDigest(TranscriptHashKit, safe.SigningDomain_VaultPeer, []byte(sessionInfo), helloI, helloR)
+	if err != nil {
+		return nil, err
+	}
+	sessionKey, err := safe.DeriveKey(shared, sessionID, []byte(sessionInfo))
+	if err != nil {
+		return nil, err
+	}
+	return &Result{
+		PeerID:     peer.id,
+		PeerKey:    peer.key,
+		SessionKey: sessionKey,
+		SessionID:  sessionID,
+	}, nil
+}
+
+func writeMsg(w io.Writer, body []byte) error {
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(body)), uint32(len(body)))
+	buf = append(buf, body...)
+	if _, err := w.Write(buf); err != nil {
+		return status.Code_NotConnected.Wrap(err)
+	}
+	return nil
+}
+
+func readMsg(r io.Reader) ([]byte, error) {
+	var head [4]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, status.Code_NotConnected.Wrap(err)
+	}
+	size := binary.BigEndian.Uint32(head[:])
+	if size == 0 || size > MaxMsgSize {
+		return nil, status.Code_ParseFailed.Errorf("handshake: message of %d bytes", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, status.Code_NotConnected.Wrap(err)
+	}
+	return body, nil
+}
@@ -0,0 +1,127 @@
+package handshake
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/p256"
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/poly25519"
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/secp256k1"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// newPeer returns a Config holding a fresh SigningKey in kit, and its PeerID.
+func newPeer(t *testing.T, kit safe.CryptoKitID) (Config, tag.UID) {
+	t.Helper()
+	ctx := context.Background()
+	guard := safe.NewFileGuard([]byte("pass"), []byte("id"))
+	t.Cleanup(func() { guard.Close() })
+	enc, err := safe.OpenEnclave(ctx, safe.NewLocalTomeStore(filepath.Join(t.TempDir(), "peer.tome")), guard, []byte("handshake-test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { enc.Close(ctx) })
+	keyringID := tag.NewID()
+	pub, err := enc.GenerateKey(ctx, keyringID, safe.KeySpec{
+		CryptoKitID: kit,
+		KeyType:     safe.KeyType_SigningKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := &safe.KeyRef{Type: safe.KeyType_SigningKey}
+	ref.SetKeyringID(keyringID)
+	return Config{Enclave: enc, KeyRef: ref}, PeerID(kit, pub.Bytes)
+}
+
+// run performs a handshake over an in-memory connection.
+func run(initCfg, respCfg Config) (initRes, respRes *Result, initErr, respErr error) {
+	connI, connR := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		respRes, respErr = Respond(connR, respCfg)
+		connR.Close()
+	}()
+	initRes, initErr = Initiate(connI, initCfg)
+	connI.Close()
+	<-done
+	return
+}
+
+func TestHandshake(t *testing.T) {
+	for _, kit := range []safe.CryptoKitID{safe.Crypto.Poly25519.ID, safe.Crypto.P256.ID, safe.Crypto.Secp256k1.ID} {
+		alice, aliceID := newPeer(t, kit)
+		bob, bobID := newPeer(t, safe.Crypto.Poly25519.ID)
+		alice.ExpectPeer = bobID
+
+		initRes, respRes, initErr, respErr := run(alice, bob)
+		if initErr != nil || respErr != nil {
+			t.Fatalf("handshake: %v / %v", initErr, respErr)
+		}
+		status.Require(t, initRes.PeerID, bobID)
+		status.Require(t, respRes.PeerID, aliceID)
+		status.Require(t, len(initRes.SessionKey), SessionKeySize)
+		status.Require(t, bytes.Equal(initRes.SessionKey, respRes.SessionKey), true)
+		status.Require(t, bytes.Equal(initRes.SessionID, respRes.SessionID), true)
+
+		// A signing key with a did:key form proves the same UID as the MemberID it mints.
+		if didID, ok := amp.DIDKeyUID(kit, respRes.PeerKey.PubKey); ok {
+			status.Require(t, aliceID, didID)
+		}
+
+		// A second session with the same keys derives a fresh key.
+		again, _, err, _ := run(alice, bob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, bytes.Equal(again.SessionKey, initRes.SessionKey), false)
+	}
+}
+
+func TestHandshakeRejects(t *testing.T) {
+	alice, _ := newPeer(t, safe.Crypto.Poly25519.ID)
+	bob, _ := newPeer(t, safe.Crypto.Poly25519.ID)
+
+	// The dialer reached someone other than whom it called.
+	alice.ExpectPeer = tag.NewID()
+	_, _, initErr, respErr := run(alice, bob)
+	status.Require(t, status.GetCode(initErr), status.Code_AuthFailed)
+	if respErr == nil {
+		t.Fatal("responder completed without the initiator's proof")
+	}
+
+	// The listener's admission policy turns the dialer away.
+	alice.ExpectPeer = tag.UID{}
+	bob.Admit = func(peerID tag.UID, peerKey *safe.KeyRef) error {
+		return status.Code_AuthFailed.Error("not on the list")
+	}
+	_, _, _, respErr = run(alice, bob)
+	status.Require(t, status.GetCode(respErr), status.Code_AuthFailed)
+
+	// A proof over a different transcript does not verify.
+	bob.Admit = nil
+	local, err := newSide(bob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := parseHello(local.hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := local.sign(roleResponder, []byte("hello-a"), local.hello)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = local.checkPeer(peer, roleResponder, sig, []byte("hello-b"), local.hello)
+	status.Require(t, status.GetCode(err), status.Code_AuthFailed)
+	err = local.checkPeer(peer, roleInitiator, sig, []byte("hello-a"), local.hello)
+	status.Require(t, status.GetCode(err), status.Code_AuthFailed)
+	status.Require(t, local.checkPeer(peer, roleResponder, sig, []byte("hello-a"), local.hello), nil)
+}
@@ -0,0 +1,1420 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// File is a durable amp.TxJournal for embedded and edge nodes: raw signed TxMsg
+// bytes in append-only segment files, one directory per planet.
+//
+//	<dir>/<PlanetID>/<seq hex>-<first TxTimeID>.seg   sorted segment
+//	<dir>/<PlanetID>/backfill.log                     late (out-of-order) appends
+//
+// UIDs render as tag.UID.Base16.
+//
+// Segment layout:
+//
+//	00:04  "AMPJ"
+//	04:08  segment format version (uint32 BE)
+//	08:    records, ascending TxTimeID within the segment
+//
+// Record layout (big endian):
+//
+//	00:16  TxTimeID
+//	16:20  len(raw)
+//	20:24  CRC-32C over bytes 00:20 ‖ raw
+//	24:25  flags (recQuarantined, recEvicted)
+//	25:32  reserved (zero)
+//	32:40  quarantine expiry (unix nanoseconds; 0 when not quarantined)
+//	40:    raw TxMsg bytes
+//
+// Only the newest segment of a planet is ever appended to; an append that would
+// overflow SegmentSize rolls a new segment, so every segment is internally sorted
+// and ReadSince / RangeHash are a merge over segments.  Each segment keeps a
+// sparse in-memory index — one checkpoint every IndexStride records — so a read
+// seeks to within a stride of its start rather than scanning from the top.
+//
+// An append that does not extend the newest segment in TxTimeID order (a sync
+// backfill) lands in the planet's backfill log instead: the same preamble and
+// record format in arrival order, indexed in full in memory.  Once the log passes
+// BackfillSize its live records are merged into one new sorted segment — the
+// newest — and the log is deleted, so a backfill costs files in proportion to its
+// bytes rather than to how often it interleaves with live appends.
+// The merged segment is synced and renamed into place before the log is removed;
+// a crash in between leaves records in both, and on open the log's copies are
+// ignored.
+//
+// Sealed segments are indexed by first TxTimeID for lookups, and their files are
+// closed once more than OpenSegments of them are open across the journal, least
+// recently used first; reads open their own handles for the duration of a scan.
+//
+// Quarantine rewrites the 16-byte flags/expiry word of the record in place; the
+// CRC excludes that word so the rewrite never invalidates the record.  Expired
+// quarantine is rewritten as evicted, and a segment whose records are all evicted
+// is deleted on the next open.
+//
+// Crash recovery: on open, the newest segment of each planet and its backfill log
+// — the only files a crash can tear — are verified record by record and truncated
+// after the last whole, CRC-valid record.
+type File struct {
+	dir  string
+	opts FileOpts
+	now  func() time.Time // clock for quarantine expiry (swapped in tests)
+
+	mu      sync.Mutex
+	planets map[tag.UID]*filePlanet
+	files   openFiles
+	closed  bool
+}
+
+// FileOpts tunes a File journal.  The zero value selects the defaults.
+type FileOpts struct {
+	SegmentSize  int64 // roll to a new segment past this many bytes; 0 = DefaultSegmentSize
+	IndexStride  int   // records per sparse-index checkpoint; 0 = DefaultIndexStride
+	BackfillSize int64 // merge the backfill log into a segment past this many bytes; 0 = DefaultBackfillSize
+	OpenSegments int   // sealed segment files held open across all planets; 0 = DefaultOpenSegments
+	SyncWrites   bool  // fsync after every append and flag rewrite
+}
+
+const (
+	// DefaultSegmentSize is the byte size past which a File journal rolls a new segment.
+	DefaultSegmentSize = 64 << 20
+
+	// DefaultIndexStride is the number of records between sparse-index checkpoints.
+	DefaultIndexStride = 64
+
+	// DefaultBackfillSize is the byte size past which a File journal merges a
+	// planet's backfill log into a sorted segment.
+	DefaultBackfillSize = 4 << 20
+
+	// DefaultOpenSegments is the number of sealed segment files a File journal
+	// holds open before closing the least recently used.
+	DefaultOpenSegments = 64
+)
+
+const (
+	segmentMagic    = "AMPJ"
+	segmentVersion  = uint32(1)
+	segPreambleSize = 8
+	segExt          = ".seg"
+	tmpExt          = ".tmp"
+	backfillName    = "backfill.log"
+
+	recHeaderSize = 40
+	recFlagsOfs   = 24 // flags ‖ reserved ‖ expiry — the in-place rewritable word
+	recCRCSpan    = 20 // header bytes covered by the CRC
+
+	recQuarantined byte = 1 << 0
+	recEvicted     byte = 1 << 1
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var _ amp.TxJournal = (*File)(nil)
+
+// filePlanet is one planet's segments and backfill log.
+type filePlanet struct {
+	dir        string
+	files      *openFiles
+	segments   []*segment // oldest first; the last is the active segment
+	sealed     []*segment // segments but the active one, ordered by first TxTimeID
+	reach      []tag.UID  // reach[i] is the greatest tail among sealed[:i+1]
+	backfill   *segment   // late appends in arrival order; nil when none
+	nextSeq    uint64
+	quarantine map[tag.UID]quarantineMark // live quarantined records
+	nextExpiry time.Time                  // earliest quarantine expiry; zero when none
+}
+
+type quarantineMark struct {
+	seg     *segment
+	ofs     int64
+	expires time.Time
+}
+
+type segment struct {
+	path  string
+	file  *os.File     // nil while a sealed segment is closed as cold
+	files *openFiles   // the journal's open-file accounting
+	used  uint64       // files.clock at last use
+	size  int64        // offset just past the last whole record
+	count int          // records (including evicted)
+	live  int          // records not evicted
+	first tag.UID      // first record's TxTimeID (least, in the backfill log)
+	tail  tag.UID      // last record's TxTimeID; an append must exceed it (greatest, in the backfill log)
+	last  tag.UID      // greatest live TxTimeID; nil when no record is live
+	index []checkpoint // one per IndexStride records; every live record, sorted, in the backfill log
+	late  bool         // the backfill log: records in arrival order
+}
+
+// openFiles orders segment use so that cold sealed segment files can be closed.
+// Guarded by the journal lock.
+type openFiles struct {
+	clock  uint64 // bumped on every segment use
+	opened bool   // a file was opened or sealed since the last trim
+}
+
+type checkpoint struct {
+	txTimeID tag.UID
+	ofs      int64
+}
+
+type recHeader struct {
+	txTimeID tag.UID
+	size     uint32
+	crc      uint32
+	flags    byte
+	expires  int64
+}
+
+func (hdr *recHeader) encode(dst []byte) {
+	binary.BigEndian.PutUint64(dst[0:], hdr.txTimeID[0])
+	binary.BigEndian.PutUint64(dst[8:], hdr.txTimeID[1])
+	binary.BigEndian.PutUint32(dst[16:], hdr.size)
+	binary.BigEndian.PutUint32(dst[20:], hdr.crc)
+	encodeFlags(dst[recFlagsOfs:], hdr.flags, hdr.expires)
+}
+
+func (hdr *recHeader) decode(src []byte) {
+	hdr.txTimeID[0] = binary.BigEndian.Uint64(src[0:])
+	hdr.txTimeID[1] = binary.BigEndian.Uint64(src[8:])
+	hdr.size = binary.BigEndian.Uint32(src[16:])
+	hdr.crc = binary.BigEndian.Uint32(src[20:])
+	hdr.flags = src[24]
+	hdr.expires = int64(binary.BigEndian.Uint64(src[32:]))
+}
+
+// encodeFlags writes the 16-byte flags ‖ reserved ‖ expiry word.
+func encodeFlags(dst []byte, flags byte, expires int64) {
+	clear(dst[:16])
+	dst[0] = flags
+	binary.BigEndian.PutUint64(dst[8:], uint64(expires))
+}
+
+// recordCRC returns the CRC-32C a record header carries for the given raw bytes.
+func recordCRC(header, raw []byte) uint32 {
+	crc := crc32.Update(0, crcTable, header[:recCRCSpan])
+	return crc32.Update(crc, crcTable, raw)
+}
+
+// OpenFile opens (creating if needed) a File journal rooted at dir, recovering
+// any torn tail left by a crash.
+func OpenFile(dir string, opts FileOpts) (*File, error) {
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSegmentSize
+	}
+	if opts.IndexStride <= 0 {
+		opts.IndexStride = DefaultIndexStride
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	j := &File{
+		dir:     dir,
+		opts:    opts,
+		now:     time.Now,
+		planets: make(map[tag.UID]*filePlanet),
+	}
+	if j.opts.BackfillSize <= 0 {
+		j.opts.BackfillSize = DefaultBackfillSize
+	}
+	if j.opts.OpenSegments <= 0 {
+		j.opts.OpenSegments = DefaultOpenSegments
+	}
+
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	for _, dirent := range dirents {
+		planetID, err := tag.UID_ParseBase16(dirent.Name())
+		if !dirent.IsDir() || err != nil {
+			continue
+		}
+		planet, err := j.loadPlanet(filepath.Join(dir, dirent.Name()))
+		if err != nil {
+			j.Close()
+			return nil, err
+		}
+		j.planets[planetID] = planet
+	}
+	j.trimFiles()
+	return j, nil
+}
+
+// loadPlanet opens a planet's segments and backfill log, verifying the newest
+// segment and the log (the only files a crash can tear) and deleting any segment
+// left with no live records.
+func (j *File) loadPlanet(dir string) (*filePlanet, error) {
+	planet := &filePlanet{
+		dir:        dir,
+		files:      &j.files,
+		quarantine: make(map[tag.UID]quarantineMark),
+	}
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+
+	type segName struct {
+		seq  uint64
+		path string
+	}
+	var names []segName
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if strings.HasSuffix(name, segExt+tmpExt) {
+			os.Remove(filepath.Join(dir, name)) // a merge cut short
+			continue
+		}
+		seqHex, _, ok := strings.Cut(strings.TrimSuffix(name, segExt), "-")
+		if !ok || !strings.HasSuffix(name, segExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(seqHex, 16, 64)
+		if err != nil {
+			continue
+		}
+		names = append(names, segName{seq, filepath.Join(dir, name)})
+	}
+	slices.SortFunc(names, func(a, b segName) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	for i, name := range names {
+		planet.nextSeq = name.seq + 1
+		seg, err := j.openSegment(planet, name.path, i == len(names)-1)
+		if err != nil {
+			planet.close()
+			return nil, err
+		}
+		planet.segments = append(planet.segments, seg)
+	}
+	planet.reindex()
+	if err := j.loadBackfill(planet); err != nil {
+		planet.close()
+		return nil, err
+	}
+
+	if err := planet.sweep(j.now(), j.opts.SyncWrites); err != nil {
+		planet.close()
+		return nil, err
+	}
+	planet.segments = slices.DeleteFunc(planet.segments, func(seg *segment) bool {
+		if seg.live > 0 {
+			return false
+		}
+		seg.closeFile()
+		os.Remove(seg.path)
+		return true
+	})
+	planet.reindex()
+	if seg := planet.active(); seg != nil {
+		if _, err := seg.handle(); err != nil {
+			planet.close()
+			return nil, err
+		}
+	}
+	return planet, nil
+}
+
+// openSegment opens and indexes one segment.  When active, every record is CRC
+// verified and the file is truncated after the last whole, valid record;
+// otherwise the file is closed again until first use.
+func (j *File) openSegment(planet *filePlanet, path string, active bool) (*segment, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	seg := &segment{
+		path:  path,
+		file:  file,
+		files: planet.files,
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	fileSize := info.Size()
+
+	var preamble [segPreambleSize]byte
+	if _, err := file.ReadAt(preamble[:], 0); err != nil {
+		if !active || fileSize >= segPreambleSize {
+			file.Close()
+			return nil, status.Code_StorageFailure.Errorf("journal: %s: unreadable preamble: %v", path, err)
+		}
+		// Torn while creating: restart the segment empty.
+		if err := seg.writePreamble(); err != nil {
+			file.Close()
+			return nil, err
+		}
+		seg.size = segPreambleSize
+		return seg, nil
+	}
+	if string(preamble[:4]) != segmentMagic || binary.BigEndian.Uint32(preamble[4:]) != segmentVersion {
+		file.Close()
+		return nil, status.Code_DataFailure.Errorf("journal: %s: not a v%d journal segment", path, segmentVersion)
+	}
+
+	seg.size = segPreambleSize
+	var (
+		header [recHeaderSize]byte
+		hdr    recHeader
+		raw    []byte
+		r      = bufio.NewReaderSize(io.NewSectionReader(file, segPreambleSize, fileSize-segPreambleSize), 64<<10)
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break // clean end or torn header
+		}
+		hdr.decode(header[:])
+		recEnd := seg.size + recHeaderSize + int64(hdr.size)
+		if recEnd > fileSize || (seg.count > 0 && hdr.txTimeID.CompareTo(seg.tail) <= 0) {
+			break // torn body or garbage past the tail
+		}
+		if active {
+			raw = slices.Grow(raw[:0], int(hdr.size))[:hdr.size]
+			if _, err := io.ReadFull(r, raw); err != nil || recordCRC(header[:], raw) != hdr.crc {
+				break
+			}
+		} else if _, err := r.Discard(int(hdr.size)); err != nil {
+			break
+		}
+		seg.admit(hdr.txTimeID, hdr.flags, seg.size, j.opts.IndexStride)
+		if hdr.flags&(recQuarantined|recEvicted) == recQuarantined {
+			planet.markQuarantined(hdr.txTimeID, quarantineMark{seg, seg.size, time.Unix(0, hdr.expires)})
+		}
+		seg.size = recEnd
+	}
+
+	if seg.size < fileSize {
+		if !active {
+			file.Close()
+			return nil, status.Code_StorageFailure.Errorf("journal: %s: damaged at offset %d", path, seg.size)
+		}
+		if err := file.Truncate(seg.size); err != nil {
+			file.Close()
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	if !active {
+		seg.closeFile()
+	}
+	return seg, nil
+}
+
+// loadBackfill opens the planet's backfill log, if any.  Every record is CRC
+// verified and the log truncated after the last whole, valid one; records a
+// segment already holds (left by a crash mid-merge) and evicted records are
+// dropped from its index.
+func (j *File) loadBackfill(planet *filePlanet) error {
+	path := filepath.Join(planet.dir, backfillName)
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	log := &segment{
+		path:  path,
+		file:  file,
+		files: planet.files,
+		size:  segPreambleSize,
+		late:  true,
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	fileSize := info.Size()
+
+	var preamble [segPreambleSize]byte
+	if _, err := file.ReadAt(preamble[:], 0); err != nil {
+		// Torn while creating: restart the log empty.
+		if err := log.writePreamble(); err != nil {
+			file.Close()
+			return err
+		}
+		planet.backfill = log
+		return nil
+	}
+	if string(preamble[:4]) != segmentMagic || binary.BigEndian.Uint32(preamble[4:]) != segmentVersion {
+		file.Close()
+		return status.Code_DataFailure.Errorf("journal: %s: not a v%d journal segment", path, segmentVersion)
+	}
+
+	var (
+		header [recHeaderSize]byte
+		hdr    recHeader
+		raw    []byte
+		r      = bufio.NewReaderSize(io.NewSectionReader(file, segPreambleSize, fileSize-segPreambleSize), 64<<10)
+	)
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			break // clean end or torn header
+		}
+		hdr.decode(header[:])
+		recEnd := log.size + recHeaderSize + int64(hdr.size)
+		if recEnd > fileSize {
+			break
+		}
+		raw = slices.Grow(raw[:0], int(hdr.size))[:hdr.size]
+		if _, err := io.ReadFull(r, raw); err != nil || recordCRC(header[:], raw) != hdr.crc {
+			break
+		}
+		if hdr.flags&recEvicted == 0 {
+			if _, _, _, held, err := planet.find(hdr.txTimeID); err != nil {
+				file.Close()
+				return err
+			} else if !held {
+				log.admitLate(hdr.txTimeID, log.size)
+				if hdr.flags&recQuarantined != 0 {
+					planet.markQuarantined(hdr.txTimeID, quarantineMark{log, log.size, time.Unix(0, hdr.expires)})
+				}
+			}
+		}
+		log.size = recEnd
+	}
+	if log.size < fileSize {
+		if err := file.Truncate(log.size); err != nil {
+			file.Close()
+			return status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	planet.backfill = log
+	return nil
+}
+
+// Close closes every segment; subsequent calls return status.ErrClosed.
+func (j *File) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil
+	}
+	j.closed = true
+	for _, planet := range j.planets {
+		planet.close()
+	}
+	j.planets = nil
+	return nil
+}
+
+// Append writes raw at (planetID, txTimeID).  Re-appending identical bytes is a
+// no-op; differing bytes under a live TxTimeID are refused — the first durable
+// write holds.
+func (j *File) Append(planetID tag.UID, txTimeID tag.UID, raw []byte) error {
+	if planetID.IsNil() || txTimeID.IsNil() {
+		return status.Code_BadRequest.Error("journal: Append requires a PlanetID and TxTimeID")
+	}
+	if len(raw) == 0 || int64(len(raw)) > int64(^uint32(0)) {
+		return status.Code_BadRequest.Errorf("journal: Append: bad TxMsg size %d", len(raw))
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	defer j.trimFiles()
+
+	planet, err := j.planet(planetID, true)
+	if err != nil {
+		return err
+	}
+	if err := planet.sweep(j.now(), j.opts.SyncWrites); err != nil {
+		return err
+	}
+
+	if seg, ofs, hdr, found, err := planet.find(txTimeID); err != nil {
+		return err
+	} else if found {
+		file, err := seg.handle()
+		if err != nil {
+			return err
+		}
+		stored := make([]byte, hdr.size)
+		if _, err := file.ReadAt(stored, ofs+recHeaderSize); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		if bytes.Equal(stored, raw) {
+			return nil
+		}
+		return status.Code_AlreadyExists.Errorf("journal: entry %v already holds different bytes", txTimeID)
+	}
+
+	seg := planet.active()
+	late := seg != nil && seg.count > 0 && txTimeID.CompareTo(seg.tail) <= 0
+	if late {
+		if seg, err = planet.openBackfill(); err != nil {
+			return err
+		}
+	} else if seg == nil || seg.size >= j.opts.SegmentSize {
+		if seg, err = planet.roll(txTimeID); err != nil {
+			return err
+		}
+	}
+
+	record := make([]byte, recHeaderSize+len(raw))
+	hdr := recHeader{
+		txTimeID: txTimeID,
+		size:     uint32(len(raw)),
+	}
+	hdr.encode(record)
+	copy(record[recHeaderSize:], raw)
+	hdr.crc = recordCRC(record, raw)
+	binary.BigEndian.PutUint32(record[20:], hdr.crc)
+
+	// A failed write leaves bytes past seg.size that the next append overwrites
+	// (and that recovery truncates), so the segment never admits a partial record.
+	if _, err := seg.file.WriteAt(record, seg.size); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if j.opts.SyncWrites {
+		if err := seg.file.Sync(); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	if late {
+		seg.admitLate(txTimeID, seg.size)
+	} else {
+		seg.admit(txTimeID, 0, seg.size, j.opts.IndexStride)
+	}
+	seg.size += int64(len(record))
+
+	// The entry is durable in the log either way; a failed merge is retried on
+	// the next late append.
+	if late && seg.size >= j.opts.BackfillSize {
+		return j.mergeBackfill(planet)
+	}
+	return nil
+}
+
+// ReadSince calls cb for each live (non-quarantined) entry with TxTimeID > after,
+// in ascending order, until cb returns false.
+func (j *File) ReadSince(planetID tag.UID, after tag.UID, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	return j.scan(planetID, after, tag.MaxID(), func(flags byte) bool {
+		return flags&(recQuarantined|recEvicted) == 0
+	}, cb)
+}
+
+// ReadQuarantined calls cb for each quarantined, unexpired entry with TxTimeID > after,
+// in ascending order, until cb returns false.
+func (j *File) ReadQuarantined(planetID tag.UID, after tag.UID, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	return j.scan(planetID, after, tag.MaxID(), func(flags byte) bool {
+		return flags&(recQuarantined|recEvicted) == recQuarantined
+	}, cb)
+}
+
+// HighWater returns the greatest stored TxTimeID (quarantined entries included),
+// or the nil UID when the planet holds no entries.
+func (j *File) HighWater(planetID tag.UID) (tag.UID, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	defer j.trimFiles()
+
+	planet, err := j.planet(planetID, false)
+	if planet == nil || err != nil {
+		return tag.UID{}, err
+	}
+	if err := planet.sweep(j.now(), j.opts.SyncWrites); err != nil {
+		return tag.UID{}, err
+	}
+	high := tag.UID{}
+	for _, seg := range planet.segments {
+		if seg.last.CompareTo(high) > 0 {
+			high = seg.last
+		}
+	}
+	if log := planet.backfill; log != nil && log.last.CompareTo(high) > 0 {
+		high = log.last
+	}
+	return high, nil
+}
+
+// RangeHash returns the amp.TxRangeDigest fingerprint over entries with
+// start <= TxTimeID <= end, quarantined entries included.
+func (j *File) RangeHash(planetID tag.UID, start, end tag.UID) (tag.UID, error) {
+	after := start
+	if !after.IsNil() {
+		after.Decrement()
+	}
+	digest := amp.NewTxRangeDigest()
+	err := j.scan(planetID, after, end, func(flags byte) bool {
+		return flags&recEvicted == 0
+	}, func(txTimeID tag.UID, raw []byte) bool {
+		digest.Add(txTimeID, raw)
+		return true
+	})
+	if err != nil {
+		return tag.UID{}, err
+	}
+	return digest.Sum(), nil
+}
+
+// Quarantine rewrites the flag word of the entry at (planetID, txTimeID) so it is
+// evicted after ttl (re-arming the TTL if already quarantined).  A non-positive
+// ttl selects amp.DefaultQuarantineRetention.  Returns status.ErrItemNotFound if absent.
+func (j *File) Quarantine(planetID tag.UID, txTimeID tag.UID, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = (*amp.VaultConfig)(nil).QuarantineRetention()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	defer j.trimFiles()
+
+	planet, err := j.planet(planetID, false)
+	if err != nil {
+		return err
+	}
+	if planet == nil {
+		return status.ErrItemNotFound
+	}
+	now := j.now()
+	if err := planet.sweep(now, j.opts.SyncWrites); err != nil {
+		return err
+	}
+	seg, ofs, _, found, err := planet.find(txTimeID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return status.ErrItemNotFound
+	}
+	expires := now.Add(ttl)
+	if err := seg.writeFlags(ofs, recQuarantined, expires.UnixNano(), j.opts.SyncWrites); err != nil {
+		return err
+	}
+	planet.markQuarantined(txTimeID, quarantineMark{seg, ofs, expires})
+	return nil
+}
+
+// planet returns the named planet, creating its directory when create is set.
+// Called under the lock.
+func (j *File) planet(planetID tag.UID, create bool) (*filePlanet, error) {
+	if j.closed {
+		return nil, status.ErrClosed
+	}
+	planet := j.planets[planetID]
+	if planet == nil && create {
+		dir := filepath.Join(j.dir, planetID.Base16())
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		planet = &filePlanet{
+			dir:        dir,
+			files:      &j.files,
+			quarantine: make(map[tag.UID]quarantineMark),
+		}
+		j.planets[planetID] = planet
+	}
+	return planet, nil
+}
+
+// trimFiles closes the least recently used sealed segment files past
+// opts.OpenSegments.  Called under the lock.
+func (j *File) trimFiles() {
+	if !j.files.opened {
+		return
+	}
+	j.files.opened = false
+	var open []*segment
+	for _, planet := range j.planets {
+		for _, seg := range planet.sealed {
+			if seg.file != nil {
+				open = append(open, seg)
+			}
+		}
+	}
+	if excess := len(open) - j.opts.OpenSegments; excess > 0 {
+		slices.SortFunc(open, func(a, b *segment) int {
+			return cmp.Compare(a.used, b.used)
+		})
+		for _, seg := range open[:excess] {
+			seg.closeFile()
+		}
+	}
+}
+
+// scan merges the planet's segments and backfill log, calling cb for each record
+// with after < TxTimeID <= end whose flags pass keep.  The views are captured
+// under the lock and read without it — records are immutable once written but
+// for their flag word — so cb may call back into the journal.  A segment joins
+// the merge only once the merge reaches its first record, so segments that don't
+// overlap are read one open file at a time.
+func (j *File) scan(planetID, after, end tag.UID, keep func(flags byte) bool, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	j.mu.Lock()
+	cursors, err := j.cursors(planetID, after, end)
+	j.trimFiles()
+	j.mu.Unlock()
+
+	defer func() {
+		for _, cur := range cursors {
+			cur.close()
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(cursors, func(a, b *segCursor) int {
+		return a.first.CompareTo(b.first)
+	})
+	waiting := cursors
+	var merging []*segCursor
+	for {
+		var min *segCursor
+		for _, cur := range merging {
+			if cur.ok && (min == nil || cur.hdr.txTimeID.CompareTo(min.hdr.txTimeID) < 0) {
+				min = cur
+			}
+		}
+		if len(waiting) > 0 && (min == nil || waiting[0].first.CompareTo(min.hdr.txTimeID) <= 0) {
+			cur := waiting[0]
+			waiting = waiting[1:]
+			if cur.start(after) {
+				merging = append(merging, cur)
+			} else if cur.err != nil {
+				return cur.err
+			}
+			continue
+		}
+		if min == nil || min.hdr.txTimeID.CompareTo(end) > 0 {
+			return nil
+		}
+		if keep(min.hdr.flags) && !cb(min.hdr.txTimeID, min.raw) {
+			return nil
+		}
+		if !min.next() {
+			if min.err != nil {
+				return min.err
+			}
+			min.close()
+		}
+	}
+}
+
+// cursors captures a cursor over each of the planet's segments and its backfill
+// log that may hold records in (after, end].  Called under the lock.
+func (j *File) cursors(planetID, after, end tag.UID) ([]*segCursor, error) {
+	planet, err := j.planet(planetID, false)
+	if err != nil || planet == nil {
+		return nil, err
+	}
+	if err := planet.sweep(j.now(), j.opts.SyncWrites); err != nil {
+		return nil, err
+	}
+	overlaps := func(seg *segment) bool {
+		return seg.count > 0 && seg.tail.CompareTo(after) > 0 && seg.first.CompareTo(end) <= 0
+	}
+	var cursors []*segCursor
+	for _, seg := range planet.segments {
+		if overlaps(seg) {
+			cursors = append(cursors, seg.cursor(after))
+		}
+	}
+	if log := planet.backfill; log != nil && overlaps(log) {
+		cur, err := log.lateCursor(after)
+		if err != nil {
+			return cursors, err
+		}
+		cursors = append(cursors, cur)
+	}
+	return cursors, nil
+}
+
+// mergeBackfill rewrites the backfill log's live records, in TxTimeID order, as a
+// new segment that becomes the active one, then deletes the log.  The segment is
+// synced and renamed into place before the log is removed, so a crash leaves at
+// worst records in both, which loadBackfill resolves in the segment's favour.
+func (j *File) mergeBackfill(planet *filePlanet) error {
+	log := planet.backfill
+	if log.live == 0 {
+		return planet.dropBackfill()
+	}
+	if err := planet.seal(); err != nil {
+		return err
+	}
+	path := planet.segmentPath(log.index[0].txTimeID)
+	file, err := os.OpenFile(path+tmpExt, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	seg := &segment{
+		path:  path,
+		file:  file,
+		files: planet.files,
+		size:  segPreambleSize,
+	}
+	moved, err := seg.copyFrom(log, j.opts.IndexStride)
+	if err == nil {
+		if err = file.Sync(); err == nil {
+			err = os.Rename(path+tmpExt, path)
+		}
+		if err != nil {
+			err = status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(path + tmpExt)
+		return err
+	}
+	syncDir(planet.dir)
+
+	for txTimeID, ofs := range moved {
+		if mark, ok := planet.quarantine[txTimeID]; ok && mark.seg == log {
+			planet.quarantine[txTimeID] = quarantineMark{seg, ofs, mark.expires}
+		}
+	}
+	planet.nextSeq++
+	planet.segments = append(planet.segments, seg)
+	planet.reindex()
+	return planet.dropBackfill()
+}
+
+// active returns the segment appends go to, or nil when the planet has none.
+func (planet *filePlanet) active() *segment {
+	if n := len(planet.segments); n > 0 {
+		return planet.segments[n-1]
+	}
+	return nil
+}
+
+// segmentPath names the next segment, carrying its first TxTimeID.
+func (planet *filePlanet) segmentPath(first tag.UID) string {
+	name := fmt.Sprintf("%08x-%s%s", planet.nextSeq, first.Base16(), segExt)
+	return filepath.Join(planet.dir, name)
+}
+
+// seal trims the active segment to its last whole record before another takes
+// its place: only the active segment is verified on open, so a sealed one must
+// carry no torn bytes.
+func (planet *filePlanet) seal() error {
+	if prev := planet.active(); prev != nil {
+		if err := prev.file.Truncate(prev.size); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		planet.files.opened = true // one more open sealed file
+	}
+	return nil
+}
+
+// roll creates a new, empty active segment whose name carries its first TxTimeID.
+func (planet *filePlanet) roll(first tag.UID) (*segment, error) {
+	if err := planet.seal(); err != nil {
+		return nil, err
+	}
+	path := planet.segmentPath(first)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	seg := &segment{
+		path:  path,
+		file:  file,
+		files: planet.files,
+		size:  segPreambleSize,
+	}
+	if err := seg.writePreamble(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	planet.nextSeq++
+	planet.segments = append(planet.segments, seg)
+	planet.reindex()
+	return seg, nil
+}
+
+// openBackfill returns the planet's backfill log, creating it on first use.
+func (planet *filePlanet) openBackfill() (*segment, error) {
+	if planet.backfill != nil {
+		return planet.backfill, nil
+	}
+	path := filepath.Join(planet.dir, backfillName)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	log := &segment{
+		path:  path,
+		file:  file,
+		files: planet.files,
+		size:  segPreambleSize,
+		late:  true,
+	}
+	if err := log.writePreamble(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	planet.backfill = log
+	return log, nil
+}
+
+// dropBackfill closes and deletes the backfill log.
+func (planet *filePlanet) dropBackfill() error {
+	log := planet.backfill
+	planet.backfill = nil
+	log.closeFile()
+	if err := os.Remove(log.path); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+// reindex rebuilds the first-TxTimeID order of the sealed segments.
+func (planet *filePlanet) reindex() {
+	planet.sealed = planet.sealed[:0]
+	if n := len(planet.segments); n > 1 {
+		planet.sealed = append(planet.sealed, planet.segments[:n-1]...)
+	}
+	slices.SortFunc(planet.sealed, func(a, b *segment) int {
+		return a.first.CompareTo(b.first)
+	})
+	planet.reach = planet.reach[:0]
+	reach := tag.UID{}
+	for _, seg := range planet.sealed {
+		if seg.tail.CompareTo(reach) > 0 {
+			reach = seg.tail
+		}
+		planet.reach = append(planet.reach, reach)
+	}
+}
+
+// find locates the live (non-evicted) record for txTimeID.
+func (planet *filePlanet) find(txTimeID tag.UID) (seg *segment, ofs int64, hdr recHeader, found bool, err error) {
+	for _, seg = range []*segment{planet.active(), planet.backfill} {
+		if seg == nil || !seg.holds(txTimeID) {
+			continue
+		}
+		if ofs, hdr, found, err = seg.locate(txTimeID); found || err != nil {
+			return
+		}
+	}
+
+	// sealed[:i] start at or before txTimeID; walk back while one may still reach it.
+	i := sort.Search(len(planet.sealed), func(i int) bool {
+		return planet.sealed[i].first.CompareTo(txTimeID) > 0
+	})
+	for i--; i >= 0 && planet.reach[i].CompareTo(txTimeID) >= 0; i-- {
+		if seg = planet.sealed[i]; !seg.holds(txTimeID) {
+			continue
+		}
+		if ofs, hdr, found, err = seg.locate(txTimeID); found || err != nil {
+			return
+		}
+	}
+	return nil, 0, recHeader{}, false, nil
+}
+
+func (planet *filePlanet) markQuarantined(txTimeID tag.UID, mark quarantineMark) {
+	planet.quarantine[txTimeID] = mark
+	if planet.nextExpiry.IsZero() || mark.expires.Before(planet.nextExpiry) {
+		planet.nextExpiry = mark.expires
+	}
+}
+
+// sweep rewrites quarantined records whose TTL has lapsed as evicted.  Called
+// under the lock; scan-free until the earliest expiry comes due.
+func (planet *filePlanet) sweep(now time.Time, sync bool) error {
+	if planet.nextExpiry.IsZero() || now.Before(planet.nextExpiry) {
+		return nil
+	}
+	planet.nextExpiry = time.Time{}
+	for txTimeID, mark := range planet.quarantine {
+		if now.Before(mark.expires) {
+			if planet.nextExpiry.IsZero() || mark.expires.Before(planet.nextExpiry) {
+				planet.nextExpiry = mark.expires
+			}
+			continue
+		}
+		seg := mark.seg
+		if err := seg.writeFlags(mark.ofs, recQuarantined|recEvicted, mark.expires.UnixNano(), sync); err != nil {
+			planet.nextExpiry = now // retry on the next call
+			return err
+		}
+		delete(planet.quarantine, txTimeID)
+		seg.live--
+		if seg.late {
+			seg.dropLate(txTimeID)
+		} else if txTimeID == seg.last {
+			if err := seg.recomputeLast(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (planet *filePlanet) close() {
+	for _, seg := range planet.segments {
+		seg.closeFile()
+	}
+	if planet.backfill != nil {
+		planet.backfill.closeFile()
+	}
+	planet.segments = nil
+	planet.sealed = nil
+	planet.backfill = nil
+}
+
+// handle returns the segment's file, reopening it if it was closed as cold.
+// Called under the lock.
+func (seg *segment) handle() (*os.File, error) {
+	seg.files.clock++
+	seg.used = seg.files.clock
+	if seg.file == nil {
+		file, err := os.OpenFile(seg.path, os.O_RDWR, 0600)
+		if err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		seg.file = file
+		seg.files.opened = true
+	}
+	return seg.file, nil
+}
+
+func (seg *segment) closeFile() {
+	if seg.file != nil {
+		seg.file.Close()
+		seg.file = nil
+	}
+}
+
+func (seg *segment) writePreamble() error {
+	var preamble [segPreambleSize]byte
+	copy(preamble[:], segmentMagic)
+	binary.BigEndian.PutUint32(preamble[4:], segmentVersion)
+	if err := seg.file.Truncate(0); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if _, err := seg.file.WriteAt(preamble[:], 0); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+// holds reports whether txTimeID falls within the segment's span of live records.
+func (seg *segment) holds(txTimeID tag.UID) bool {
+	return seg.live > 0 && txTimeID.CompareTo(seg.first) >= 0 && txTimeID.CompareTo(seg.tail) <= 0
+}
+
+// admit records a whole record at ofs in the segment's bookkeeping.
+func (seg *segment) admit(txTimeID tag.UID, flags byte, ofs int64, stride int) {
+	if seg.count%stride == 0 {
+		seg.index = append(seg.index, checkpoint{txTimeID, ofs})
+	}
+	if seg.count == 0 {
+		seg.first = txTimeID
+	}
+	seg.count++
+	seg.tail = txTimeID
+	if flags&recEvicted == 0 {
+		seg.live++
+		seg.last = txTimeID
+	}
+}
+
+// admitLate records a live record at ofs in the backfill log's sorted index.
+func (log *segment) admitLate(txTimeID tag.UID, ofs int64) {
+	i, _ := slices.BinarySearchFunc(log.index, txTimeID, compareCheckpoint)
+	log.index = slices.Insert(log.index, i, checkpoint{txTimeID, ofs})
+	if log.count == 0 || txTimeID.CompareTo(log.first) < 0 {
+		log.first = txTimeID
+	}
+	if txTimeID.CompareTo(log.tail) > 0 {
+		log.tail = txTimeID
+	}
+	log.count++
+	log.live++
+	log.last = log.index[len(log.index)-1].txTimeID
+}
+
+// dropLate removes an evicted record from the backfill log's index; its bytes
+// stay in the log until the next merge.
+func (log *segment) dropLate(txTimeID tag.UID) {
+	if i, found := slices.BinarySearchFunc(log.index, txTimeID, compareCheckpoint); found {
+		log.index = slices.Delete(log.index, i, i+1)
+		log.count--
+	}
+	log.last = tag.UID{}
+	if n := len(log.index); n > 0 {
+		log.last = log.index[n-1].txTimeID
+	}
+}
+
+func compareCheckpoint(cp checkpoint, target tag.UID) int {
+	return cp.txTimeID.CompareTo(target)
+}
+
+// seekOfs returns the offset of the last checkpoint at or before txTimeID — a
+// read for records > txTimeID starts there and skips at most one stride.
+func (seg *segment) seekOfs(txTimeID tag.UID) int64 {
+	i, found := slices.BinarySearchFunc(seg.index, txTimeID, compareCheckpoint)
+	if !found {
+		i--
+	}
+	if i < 0 {
+		return segPreambleSize
+	}
+	return seg.index[i].ofs
+}
+
+// locate finds the live record for txTimeID by walking headers from the nearest
+// checkpoint (or, in the backfill log, straight from its index entry).
+func (seg *segment) locate(txTimeID tag.UID) (ofs int64, hdr recHeader, found bool, err error) {
+	file, err := seg.handle()
+	if err != nil {
+		return 0, hdr, false, err
+	}
+	var header [recHeaderSize]byte
+	if seg.late {
+		i, ok := slices.BinarySearchFunc(seg.index, txTimeID, compareCheckpoint)
+		if !ok {
+			return 0, hdr, false, nil
+		}
+		ofs = seg.index[i].ofs
+		if _, err = file.ReadAt(header[:], ofs); err != nil {
+			return 0, hdr, false, status.Code_StorageFailure.Wrap(err)
+		}
+		hdr.decode(header[:])
+		return ofs, hdr, hdr.flags&recEvicted == 0, nil
+	}
+	for ofs = seg.seekOfs(txTimeID); ofs < seg.size; ofs += recHeaderSize + int64(hdr.size) {
+		if _, err = file.ReadAt(header[:], ofs); err != nil {
+			return 0, hdr, false, status.Code_StorageFailure.Wrap(err)
+		}
+		hdr.decode(header[:])
+		if cmp := hdr.txTimeID.CompareTo(txTimeID); cmp >= 0 {
+			return ofs, hdr, cmp == 0 && hdr.flags&recEvicted == 0, nil
+		}
+	}
+	return 0, hdr, false, nil
+}
+
+// recomputeLast re-derives the greatest live TxTimeID after an eviction.
+func (seg *segment) recomputeLast() error {
+	file, err := seg.handle()
+	if err != nil {
+		return err
+	}
+	var (
+		header [recHeaderSize]byte
+		hdr    recHeader
+	)
+	seg.last = tag.UID{}
+	for ofs := int64(segPreambleSize); ofs < seg.size; ofs += recHeaderSize + int64(hdr.size) {
+		if _, err := file.ReadAt(header[:], ofs); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		hdr.decode(header[:])
+		if hdr.flags&recEvicted == 0 {
+			seg.last = hdr.txTimeID
+		}
+	}
+	return nil
+}
+
+// writeFlags rewrites a record's flag word in place.
+func (seg *segment) writeFlags(ofs int64, flags byte, expires int64, sync bool) error {
+	file, err := seg.handle()
+	if err != nil {
+		return err
+	}
+	var word [16]byte
+	encodeFlags(word[:], flags, expires)
+	if _, err := file.WriteAt(word[:], ofs+recFlagsOfs); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if sync {
+		if err := file.Sync(); err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	return nil
+}
+
+// copyFrom writes the backfill log's live records into an empty segment in
+// TxTimeID order, flag words included, and returns the new offsets of its
+// quarantined records.
+func (seg *segment) copyFrom(log *segment, stride int) (map[tag.UID]int64, error) {
+	if err := seg.writePreamble(); err != nil {
+		return nil, err
+	}
+	var (
+		w      = bufio.NewWriterSize(io.NewOffsetWriter(seg.file, segPreambleSize), 64<<10)
+		moved  = make(map[tag.UID]int64)
+		header [recHeaderSize]byte
+		hdr    recHeader
+		record []byte
+	)
+	for _, cp := range log.index {
+		if _, err := log.file.ReadAt(header[:], cp.ofs); err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		hdr.decode(header[:])
+		record = slices.Grow(record[:0], recHeaderSize+int(hdr.size))[:recHeaderSize+int(hdr.size)]
+		if _, err := log.file.ReadAt(record, cp.ofs); err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		if _, err := w.Write(record); err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		if hdr.flags&recQuarantined != 0 {
+			moved[hdr.txTimeID] = seg.size
+		}
+		seg.admit(hdr.txTimeID, hdr.flags, seg.size, stride)
+		seg.size += int64(len(record))
+	}
+	if err := w.Flush(); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return moved, nil
+}
+
+// cursor returns a reader over the segment's whole records from the checkpoint
+// nearest after, bounded by the segment's current size.
+func (seg *segment) cursor(after tag.UID) *segCursor {
+	start := seg.seekOfs(after)
+	return &segCursor{
+		path:  seg.path,
+		first: seg.first,
+		ofs:   start,
+		end:   seg.size,
+	}
+}
+
+// lateCursor returns a reader over the backfill log's records after after, in
+// TxTimeID order.  Its file is opened at once, as a merge may delete the log.
+func (log *segment) lateCursor(after tag.UID) (*segCursor, error) {
+	i, found := slices.BinarySearchFunc(log.index, after, compareCheckpoint)
+	if found {
+		i++
+	}
+	cur := &segCursor{
+		path:  log.path,
+		first: tag.MaxID(),
+		late:  slices.Clone(log.index[i:]),
+	}
+	if len(cur.late) > 0 {
+		cur.first = cur.late[0].txTimeID
+	}
+	file, err := os.Open(log.path)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	cur.file = file
+	return cur, nil
+}
+
+// segCursor steps through one segment's records, verifying each CRC.  It reads
+// through its own file handle, so a segment may be closed as cold mid-scan.
+type segCursor struct {
+	path   string
+	first  tag.UID      // no record the cursor yields precedes this
+	ofs    int64        // start offset
+	end    int64        // segment size when captured
+	late   []checkpoint // backfill log records still to read, sorted; nil for a segment
+	file   *os.File
+	r      *bufio.Reader
+	header [recHeaderSize]byte
+	hdr    recHeader
+	raw    []byte
+	ok     bool
+	err    error
+}
+
+// start opens the cursor and steps it to its first record > after.
+func (cur *segCursor) start(after tag.UID) bool {
+	if cur.file == nil {
+		file, err := os.Open(cur.path)
+		if err != nil {
+			cur.err = status.Code_StorageFailure.Wrap(err)
+			return false
+		}
+		cur.file = file
+		cur.r = bufio.NewReaderSize(io.NewSectionReader(file, cur.ofs, cur.end-cur.ofs), 64<<10)
+	}
+	for cur.next() {
+		if cur.hdr.txTimeID.CompareTo(after) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (cur *segCursor) next() bool {
+	cur.ok = false
+	if cur.r == nil {
+		if len(cur.late) == 0 {
+			return false
+		}
+		ofs := cur.late[0].ofs
+		cur.late = cur.late[1:]
+		if _, err := cur.file.ReadAt(cur.header[:], ofs); err != nil {
+			cur.err = status.Code_StorageFailure.Wrap(err)
+			return false
+		}
+		cur.hdr.decode(cur.header[:])
+		cur.raw = make([]byte, cur.hdr.size)
+		if _, err := cur.file.ReadAt(cur.raw, ofs+recHeaderSize); err != nil {
+			cur.err = status.Code_StorageFailure.Wrap(err)
+			return false
+		}
+	} else {
+		if _, err := io.ReadFull(cur.r, cur.header[:]); err != nil {
+			if err != io.EOF {
+				cur.err = status.Code_StorageFailure.Wrap(err)
+			}
+			return false
+		}
+		cur.hdr.decode(cur.header[:])
+		cur.raw = make([]byte, cur.hdr.size)
+		if _, err := io.ReadFull(cur.r, cur.raw); err != nil {
+			cur.err = status.Code_StorageFailure.Wrap(err)
+			return false
+		}
+	}
+	if recordCRC(cur.header[:], cur.raw) != cur.hdr.crc {
+		cur.err = status.ErrValueDamaged
+		return false
+	}
+	cur.ok = true
+	return true
+}
+
+func (cur *segCursor) close() {
+	if cur.file != nil {
+		cur.file.Close()
+		cur.file = nil
+	}
+}
+
+// syncDir makes a rename within dir durable — best effort, as not every platform
+// can sync a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,453 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+func TestFileContract(t *testing.T) {
+	testJournalContract(t, func(t *testing.T, clock *testClock) amp.TxJournal {
+		j, err := OpenFile(t.TempDir(), FileOpts{IndexStride: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.now = clock.Now
+		return j
+	})
+}
+
+// fileFixture is a File journal in a fixed directory that can be closed,
+// damaged on disk, and reopened.
+type fileFixture struct {
+	t        *testing.T
+	dir      string
+	opts     FileOpts
+	planetID tag.UID
+	ids      []tag.UID
+	j        *File
+}
+
+func newFileFixture(t *testing.T, opts FileOpts, entries int) *fileFixture {
+	fx := &fileFixture{
+		t:        t,
+		dir:      t.TempDir(),
+		opts:     opts,
+		planetID: tag.NewID(),
+		ids:      entryIDs(entries),
+	}
+	fx.reopen()
+	for _, id := range fx.ids {
+		if err := fx.j.Append(fx.planetID, id, entryBytes(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		if fx.j != nil {
+			fx.j.Close()
+		}
+	})
+	return fx
+}
+
+func (fx *fileFixture) reopen() {
+	fx.t.Helper()
+	if fx.j != nil {
+		fx.j.Close()
+	}
+	j, err := OpenFile(fx.dir, fx.opts)
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	fx.j = j
+}
+
+// segments returns the planet's segment paths, oldest first.
+func (fx *fileFixture) segments() []string {
+	fx.t.Helper()
+	paths, err := filepath.Glob(filepath.Join(fx.dir, fx.planetID.Base16(), "*"+segExt))
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	return paths
+}
+
+// damageTail closes the journal and applies damage to the newest segment.
+func (fx *fileFixture) damageTail(damage func(f *os.File, size int64)) {
+	fx.t.Helper()
+	fx.j.Close()
+	paths := fx.segments()
+	f, err := os.OpenFile(paths[len(paths)-1], os.O_RDWR, 0)
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	info, _ := f.Stat()
+	damage(f, info.Size())
+	f.Close()
+	fx.reopen()
+}
+
+// requireEntries fatals unless exactly the first n fixture entries read back.
+func (fx *fileFixture) requireEntries(n int) {
+	fx.t.Helper()
+	got := readAll(fx.t, fx.j, fx.planetID, tag.UID{})
+	status.Require(fx.t, len(got), n)
+	for i := range got {
+		status.Require(fx.t, got[i], fx.ids[i])
+	}
+	high, err := fx.j.HighWater(fx.planetID)
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	if n > 0 {
+		status.Require(fx.t, high, fx.ids[n-1])
+	}
+}
+
+// requireAppendable fatals unless the recovered journal accepts the lost tail again.
+func (fx *fileFixture) requireAppendable(from int) {
+	fx.t.Helper()
+	for _, id := range fx.ids[from:] {
+		if err := fx.j.Append(fx.planetID, id, entryBytes(id)); err != nil {
+			fx.t.Fatal(err)
+		}
+	}
+	fx.reopen()
+	fx.requireEntries(len(fx.ids))
+}
+
+func TestFileReopen(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{IndexStride: 3}, 25)
+	before, _ := fx.j.RangeHash(fx.planetID, tag.UID{}, tag.MaxID())
+	if err := fx.j.Quarantine(fx.planetID, fx.ids[24], time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	fx.reopen()
+	status.Require(t, len(readAll(t, fx.j, fx.planetID, tag.UID{})), 24)
+	high, _ := fx.j.HighWater(fx.planetID)
+	status.Require(t, high, fx.ids[24]) // quarantined entries still count
+	after, _ := fx.j.RangeHash(fx.planetID, tag.UID{}, tag.MaxID())
+	status.Require(t, after, before)
+
+	held := 0
+	fx.j.ReadQuarantined(fx.planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		status.Require(t, txTimeID, fx.ids[24])
+		held++
+		return true
+	})
+	status.Require(t, held, 1)
+
+	// Sparse-index seeks land on every possible stride offset.
+	for i := range fx.ids[:24] {
+		got := readAll(t, fx.j, fx.planetID, fx.ids[i])
+		status.Require(t, len(got), 23-i)
+	}
+}
+
+func TestFileSegmentRoll(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{SegmentSize: 256, IndexStride: 2}, 12)
+	if len(fx.segments()) < 3 {
+		t.Fatalf("expected SegmentSize to roll segments, got %d", len(fx.segments()))
+	}
+
+	// An out-of-order backfill lands in the backfill log; reads still merge in order.
+	planetID := fx.planetID
+	extra := tag.UID{fx.ids[3][0], fx.ids[3][1] + 1}
+	if err := fx.j.Append(planetID, extra, entryBytes(extra)); err != nil {
+		t.Fatal(err)
+	}
+	fx.reopen()
+	got := readAll(t, fx.j, planetID, tag.UID{})
+	status.Require(t, len(got), 13)
+	status.Require(t, got[4], extra)
+
+	digest := amp.NewTxRangeDigest()
+	for _, id := range got {
+		digest.Add(id, entryBytes(id))
+	}
+	hash, _ := fx.j.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	status.Require(t, hash, digest.Sum())
+}
+
+func TestFileEvictionReclaimsSegments(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{SegmentSize: 128}, 6)
+	clock := newClock()
+	fx.j.now = clock.Now
+
+	segments := len(fx.segments())
+	for _, id := range fx.ids[:2] {
+		if err := fx.j.Quarantine(fx.planetID, id, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(time.Hour)
+
+	got := readAll(t, fx.j, fx.planetID, tag.UID{})
+	status.Require(t, len(got), 4)
+	status.Require(t, got[0], fx.ids[2])
+
+	fx.reopen()
+	if len(fx.segments()) >= segments {
+		t.Fatalf("fully evicted segment not reclaimed: %d → %d", segments, len(fx.segments()))
+	}
+	status.Require(t, len(readAll(t, fx.j, fx.planetID, tag.UID{})), 4)
+
+	// An evicted TxTimeID may arrive again (e.g. re-synced after a fix).
+	if err := fx.j.Append(fx.planetID, fx.ids[0], entryBytes(fx.ids[0])); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(readAll(t, fx.j, fx.planetID, tag.UID{})), 5)
+}
+
+// requireRead fatals unless a full read returns exactly want, in order.
+func (fx *fileFixture) requireRead(want []tag.UID) {
+	fx.t.Helper()
+	got := readAll(fx.t, fx.j, fx.planetID, tag.UID{})
+	status.Require(fx.t, len(got), len(want))
+	for i := range got {
+		status.Require(fx.t, got[i], want[i])
+	}
+}
+
+// requireHash fatals unless RangeHash over the planet covers exactly want.
+func (fx *fileFixture) requireHash(want []tag.UID) {
+	fx.t.Helper()
+	digest := amp.NewTxRangeDigest()
+	for _, id := range want {
+		digest.Add(id, entryBytes(id))
+	}
+	hash, err := fx.j.RangeHash(fx.planetID, tag.UID{}, tag.MaxID())
+	if err != nil {
+		fx.t.Fatal(err)
+	}
+	status.Require(fx.t, hash, digest.Sum())
+}
+
+func (fx *fileFixture) append(ids ...tag.UID) {
+	fx.t.Helper()
+	for _, id := range ids {
+		if err := fx.j.Append(fx.planetID, id, entryBytes(id)); err != nil {
+			fx.t.Fatal(err)
+		}
+	}
+}
+
+func (fx *fileFixture) backfillPath() string {
+	return filepath.Join(fx.dir, fx.planetID.Base16(), backfillName)
+}
+
+func TestFileBackfillMerge(t *testing.T) {
+	all := entryIDs(200)
+	fx := newFileFixture(t, FileOpts{BackfillSize: 1024, IndexStride: 4}, 0)
+	fx.ids = all
+
+	// Interleaved backfill: every even entry arrives after the odd ones.
+	for i := 1; i < len(all); i += 2 {
+		fx.append(all[i])
+	}
+	if err := fx.j.Quarantine(fx.planetID, all[1], time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(all); i += 2 {
+		fx.append(all[i])
+		if i == 0 {
+			if err := fx.j.Quarantine(fx.planetID, all[0], time.Hour); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if n := len(fx.segments()); n > 8 {
+		t.Fatalf("backfill of %d entries left %d segments", len(all)/2, n)
+	}
+	fx.requireRead(all[2:])
+	fx.requireHash(all)
+
+	fx.reopen()
+	fx.requireRead(all[2:])
+	fx.requireHash(all)
+	var held []tag.UID
+	fx.j.ReadQuarantined(fx.planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		held = append(held, txTimeID)
+		return true
+	})
+	status.Require(t, len(held), 2)
+	status.Require(t, held[0], all[0])
+
+	// Re-appending a merged entry is still a no-op.
+	fx.append(all[10])
+	fx.requireRead(all[2:])
+}
+
+func TestFileBackfillCrashMidMerge(t *testing.T) {
+	all := entryIDs(20)
+	fx := newFileFixture(t, FileOpts{}, 0)
+	fx.append(all[10:]...)
+	fx.append(all[:5]...)
+	fx.j.Close()
+	unmerged, err := os.ReadFile(fx.backfillPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Merge, then put the log back as a crash before its removal would leave it.
+	fx.opts.BackfillSize = 1
+	fx.reopen()
+	fx.append(all[5])
+	if _, err := os.Stat(fx.backfillPath()); !os.IsNotExist(err) {
+		t.Fatalf("backfill log not merged: %v", err)
+	}
+	fx.j.Close()
+	if err := os.WriteFile(fx.backfillPath(), unmerged, 0600); err != nil {
+		t.Fatal(err)
+	}
+	fx.opts.BackfillSize = 0
+	fx.reopen()
+	want := append(slices.Clone(all[:6]), all[10:]...)
+	fx.requireRead(want)
+	fx.requireHash(want)
+}
+
+func TestFileOpenSegments(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{SegmentSize: 128, OpenSegments: 2}, 30)
+	if len(fx.segments()) < 8 {
+		t.Fatalf("expected many segments, got %d", len(fx.segments()))
+	}
+	openSealed := func() (n int) {
+		for _, planet := range fx.j.planets {
+			for _, seg := range planet.sealed {
+				if seg.file != nil {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	fx.requireRead(fx.ids)
+	for _, id := range fx.ids {
+		fx.append(id) // a no-op that opens the segment holding id
+		if n := openSealed(); n > 2 {
+			t.Fatalf("%d sealed segment files open", n)
+		}
+	}
+	for _, id := range fx.ids[:5] {
+		if err := fx.j.Quarantine(fx.planetID, id, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status.Require(t, openSealed() <= 2, true)
+	fx.reopen()
+	fx.requireRead(fx.ids[5:])
+}
+
+// ── torn-write recovery ───────────────────────────────────────────────────────
+
+func TestFileTornTail(t *testing.T) {
+	const entries = 10
+	recSize := func(i int) int64 {
+		return recHeaderSize + int64(len(entryBytes(entryIDs(entries)[i])))
+	}
+
+	cases := []struct {
+		name   string
+		damage func(f *os.File, size int64)
+		keep   int
+	}{
+		{"TruncatedBody", func(f *os.File, size int64) {
+			f.Truncate(size - 3)
+		}, entries - 1},
+		{"TruncatedHeader", func(f *os.File, size int64) {
+			f.Truncate(size - recSize(entries-1) + recHeaderSize/2)
+		}, entries - 1},
+		{"HeaderOnly", func(f *os.File, size int64) {
+			f.Truncate(size - recSize(entries-1) + recHeaderSize)
+		}, entries - 1},
+		{"GarbageTail", func(f *os.File, size int64) {
+			f.WriteAt([]byte("\xde\xad\xbe\xef not a record at all, just noise"), size)
+		}, entries},
+		{"ZeroFilledTail", func(f *os.File, size int64) {
+			f.WriteAt(make([]byte, 4096), size)
+		}, entries},
+		{"CorruptLastBody", func(f *os.File, size int64) {
+			f.WriteAt([]byte{'X'}, size-1)
+		}, entries - 1},
+		{"CorruptLastLength", func(f *os.File, size int64) {
+			f.WriteAt([]byte{0x7f, 0xff, 0xff, 0xff}, size-recSize(entries-1)+16)
+		}, entries - 1},
+		{"TornMidSegment", func(f *os.File, size int64) {
+			f.Truncate(size - recSize(entries-1) - recSize(entries-2) - 5)
+		}, entries - 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			fx := newFileFixture(t, FileOpts{IndexStride: 2}, entries)
+			fx.damageTail(tc.damage)
+			fx.requireEntries(tc.keep)
+
+			// Recovery is idempotent and leaves the tail appendable.
+			fx.reopen()
+			fx.requireEntries(tc.keep)
+			fx.requireAppendable(tc.keep)
+		})
+	}
+}
+
+func TestFileTornBackfill(t *testing.T) {
+	all := entryIDs(10)
+	fx := newFileFixture(t, FileOpts{}, 0)
+	fx.ids = all
+	fx.append(all[5:]...)
+	fx.append(all[:3]...)
+	fx.j.Close()
+
+	// The backfill log tears like the active segment: only the last late append is lost.
+	info, _ := os.Stat(fx.backfillPath())
+	os.Truncate(fx.backfillPath(), info.Size()-3)
+	fx.reopen()
+	fx.requireRead(append(slices.Clone(all[:2]), all[5:]...))
+
+	fx.append(all[2:5]...)
+	fx.reopen()
+	fx.requireEntries(len(all))
+}
+
+func TestFileTornPreamble(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{SegmentSize: 128}, 4)
+	segments := fx.segments()
+
+	// A crash right after creating the next segment leaves a torn preamble.
+	torn := filepath.Join(filepath.Dir(segments[0]), "000000ff-"+tag.NowID().Base16()+segExt)
+	if err := os.WriteFile(torn, []byte("AM"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fx.reopen()
+	fx.requireEntries(4)
+
+	extra := tag.UID_FromTime(time.Unix(1_800_000_000, 0))
+	if err := fx.j.Append(fx.planetID, extra, entryBytes(extra)); err != nil {
+		t.Fatal(err)
+	}
+	fx.reopen()
+	status.Require(t, len(readAll(t, fx.j, fx.planetID, tag.UID{})), 5)
+}
+
+func TestFileDamagedSealedSegment(t *testing.T) {
+	fx := newFileFixture(t, FileOpts{SegmentSize: 128}, 6)
+	fx.j.Close()
+
+	// A sealed segment can't be torn by a crash; damage there is reported, not truncated.
+	sealed := fx.segments()[0]
+	info, _ := os.Stat(sealed)
+	os.Truncate(sealed, info.Size()-2)
+	if _, err := OpenFile(fx.dir, fx.opts); !status.IsError(err, status.Code_StorageFailure) {
+		t.Fatalf("expected StorageFailure, got %v", err)
+	}
+	fx.j = nil
+}
@@ -0,0 +1,319 @@
+package journal
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// testClock is a manually advanced clock shared by a journal under test.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *testClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	clock.now = clock.now.Add(d)
+	clock.mu.Unlock()
+}
+
+// openJournal opens a journal under test driven by the given clock.
+type openJournal func(t *testing.T, clock *testClock) amp.TxJournal
+
+func TestMemoryContract(t *testing.T) {
+	testJournalContract(t, func(t *testing.T, clock *testClock) amp.TxJournal {
+		j := NewMemory()
+		j.now = clock.Now
+		return j
+	})
+}
+
+// testJournalContract exercises the amp.TxJournal contract every implementation honors.
+func testJournalContract(t *testing.T, open openJournal) {
+	t.Run("Ordering", func(t *testing.T) { testOrdering(t, open) })
+	t.Run("Idempotent", func(t *testing.T) { testIdempotent(t, open) })
+	t.Run("RangeHash", func(t *testing.T) { testRangeHash(t, open) })
+	t.Run("Quarantine", func(t *testing.T) { testQuarantine(t, open) })
+	t.Run("Closed", func(t *testing.T) { testClosed(t, open) })
+}
+
+func newClock() *testClock {
+	return &testClock{now: time.Unix(1_700_000_000, 0)}
+}
+
+// entryIDs returns n ascending TxTimeIDs one second apart.
+func entryIDs(n int) []tag.UID {
+	base := time.Unix(1_700_000_000, 0)
+	ids := make([]tag.UID, n)
+	for i := range ids {
+		ids[i] = tag.UID_FromTime(base.Add(time.Duration(i) * time.Second))
+	}
+	return ids
+}
+
+func entryBytes(id tag.UID) []byte {
+	return fmt.Appendf(nil, "tx-%v", id)
+}
+
+func readAll(t *testing.T, j amp.TxJournal, planetID, after tag.UID) []tag.UID {
+	t.Helper()
+	var got []tag.UID
+	err := j.ReadSince(planetID, after, func(txTimeID tag.UID, raw []byte) bool {
+		if string(raw) != string(entryBytes(txTimeID)) {
+			t.Errorf("entry %v: bytes mismatch", txTimeID)
+		}
+		got = append(got, txTimeID)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func testOrdering(t *testing.T, open openJournal) {
+	j := open(t, newClock())
+	defer j.Close()
+
+	planetID := tag.NewID()
+	ids := entryIDs(20)
+
+	// Append out of order; reads must come back ascending.
+	for _, i := range []int{5, 0, 19, 7, 3, 12, 1, 18, 2, 4, 6, 8, 9, 10, 11, 13, 14, 15, 16, 17} {
+		if err := j.Append(planetID, ids[i], entryBytes(ids[i])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := readAll(t, j, planetID, tag.UID{})
+	status.Require(t, len(got), len(ids))
+	for i := range ids {
+		status.Require(t, got[i], ids[i])
+	}
+
+	got = readAll(t, j, planetID, ids[9])
+	status.Require(t, len(got), 10)
+	status.Require(t, got[0], ids[10])
+
+	count := 0
+	j.ReadSince(planetID, tag.UID{}, func(tag.UID, []byte) bool {
+		count++
+		return count < 3
+	})
+	status.Require(t, count, 3)
+
+	high, err := j.HighWater(planetID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, high, ids[19])
+
+	high, _ = j.HighWater(tag.NewID())
+	status.Require(t, high.IsNil(), true)
+}
+
+func testIdempotent(t *testing.T, open openJournal) {
+	j := open(t, newClock())
+	defer j.Close()
+
+	planetID := tag.NewID()
+	id := entryIDs(1)[0]
+	if err := j.Append(planetID, id, entryBytes(id)); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Append(planetID, id, entryBytes(id)); err != nil {
+		t.Fatalf("identical re-append: %v", err)
+	}
+	if err := j.Append(planetID, id, []byte("substitute")); !status.IsError(err, status.Code_AlreadyExists) {
+		t.Fatalf("differing re-append: expected AlreadyExists, got %v", err)
+	}
+	if err := j.Append(tag.UID{}, id, entryBytes(id)); !status.IsError(err, status.Code_BadRequest) {
+		t.Fatalf("nil planet: expected BadRequest, got %v", err)
+	}
+	status.Require(t, len(readAll(t, j, planetID, tag.UID{})), 1)
+}
+
+func testRangeHash(t *testing.T, open openJournal) {
+	a := open(t, newClock())
+	defer a.Close()
+	b := open(t, newClock())
+	defer b.Close()
+
+	planetID := tag.NewID()
+	ids := entryIDs(16)
+	for i, id := range ids {
+		a.Append(planetID, id, entryBytes(id))
+		b.Append(planetID, ids[len(ids)-1-i], entryBytes(ids[len(ids)-1-i]))
+	}
+
+	full := func(j amp.TxJournal) tag.UID {
+		t.Helper()
+		hash, err := j.RangeHash(planetID, tag.UID{}, tag.MaxID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	hashA := full(a)
+	if hashA.IsNil() {
+		t.Fatal("non-empty range hashed to nil")
+	}
+	status.Require(t, full(b), hashA)
+
+	// Matches the canonical digest over the same entries.
+	digest := amp.NewTxRangeDigest()
+	for _, id := range ids {
+		digest.Add(id, entryBytes(id))
+	}
+	status.Require(t, hashA, digest.Sum())
+
+	// Sub-range is inclusive at both ends.
+	sub, _ := a.RangeHash(planetID, ids[4], ids[7])
+	digest = amp.NewTxRangeDigest()
+	for _, id := range ids[4:8] {
+		digest.Add(id, entryBytes(id))
+	}
+	status.Require(t, sub, digest.Sum())
+
+	// Empty ranges agree.
+	empty, _ := a.RangeHash(tag.NewID(), tag.UID{}, tag.MaxID())
+	status.Require(t, empty.IsNil(), true)
+
+	// The hash binds entry bytes, not just TxTimeIDs.
+	c := open(t, newClock())
+	defer c.Close()
+	for i, id := range ids {
+		raw := entryBytes(id)
+		if i == 9 {
+			raw = []byte("corrupted")
+		}
+		c.Append(planetID, id, raw)
+	}
+	if full(c) == hashA {
+		t.Fatal("substituted entry did not change RangeHash")
+	}
+}
+
+func testQuarantine(t *testing.T, open openJournal) {
+	clock := newClock()
+	j := open(t, clock)
+	defer j.Close()
+
+	planetID := tag.NewID()
+	ids := entryIDs(8)
+	for _, id := range ids {
+		j.Append(planetID, id, entryBytes(id))
+	}
+	before, _ := j.RangeHash(planetID, tag.UID{}, tag.MaxID())
+
+	if err := j.Quarantine(planetID, ids[3], time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Quarantine(planetID, ids[7], 2*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Quarantine(planetID, tag.NewID(), time.Hour); !status.IsError(err, status.Code_ItemNotFound) {
+		t.Fatalf("expected ItemNotFound, got %v", err)
+	}
+
+	// Hidden from ReadSince, still counted by HighWater and RangeHash.
+	live := readAll(t, j, planetID, tag.UID{})
+	status.Require(t, len(live), 6)
+	for _, id := range live {
+		if id == ids[3] || id == ids[7] {
+			t.Fatalf("quarantined entry %v surfaced in ReadSince", id)
+		}
+	}
+	high, _ := j.HighWater(planetID)
+	status.Require(t, high, ids[7])
+	after, _ := j.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	status.Require(t, after, before)
+
+	var held []tag.UID
+	j.ReadQuarantined(planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		held = append(held, txTimeID)
+		return true
+	})
+	status.Require(t, len(held), 2)
+	status.Require(t, held[0], ids[3])
+	status.Require(t, held[1], ids[7])
+
+	// The first TTL lapses: ids[3] is evicted, ids[7] is still held.
+	clock.Advance(90 * time.Minute)
+	held = held[:0]
+	j.ReadQuarantined(planetID, tag.UID{}, func(txTimeID tag.UID, raw []byte) bool {
+		held = append(held, txTimeID)
+		return true
+	})
+	status.Require(t, len(held), 1)
+	status.Require(t, held[0], ids[7])
+	evicted, _ := j.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	if evicted == before {
+		t.Fatal("evicted entry still counted by RangeHash")
+	}
+
+	// The second lapses and the high-water mark recedes.
+	clock.Advance(time.Hour)
+	high, _ = j.HighWater(planetID)
+	status.Require(t, high, ids[6])
+	status.Require(t, len(readAll(t, j, planetID, tag.UID{})), 6)
+}
+
+func testClosed(t *testing.T, open openJournal) {
+	j := open(t, newClock())
+	id := entryIDs(1)[0]
+	planetID := tag.NewID()
+	j.Append(planetID, id, entryBytes(id))
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Append(planetID, id, entryBytes(id)); err == nil {
+		t.Fatal("Append after Close succeeded")
+	}
+	if _, err := j.HighWater(planetID); err == nil {
+		t.Fatal("HighWater after Close succeeded")
+	}
+}
+
+func TestMemoryConcurrent(t *testing.T) {
+	j := NewMemory()
+	defer j.Close()
+
+	planetID := tag.NewID()
+	const writers, perWriter = 8, 200
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Go(func() {
+			for i := range perWriter {
+				id := tag.UID{uint64(1 + i), uint64(w)}
+				if err := j.Append(planetID, id, entryBytes(id)); err != nil {
+					t.Error(err)
+					return
+				}
+				j.ReadSince(planetID, tag.UID{}, func(tag.UID, []byte) bool { return false })
+			}
+		})
+	}
+	wg.Wait()
+
+	got := readAll(t, j, planetID, tag.UID{})
+	status.Require(t, len(got), writers*perWriter)
+	for i := 1; i < len(got); i++ {
+		if got[i-1].CompareTo(got[i]) >= 0 {
+			t.Fatalf("entries out of order at %d", i)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+// Package journal provides reference amp.TxJournal implementations.
+//
+// A TxJournal holds raw signed TxMsg bytes keyed by (PlanetID, TxTimeID) — the
+// vault sync engine's primary store.  Every implementation here honors the same
+// contract as the vault's own journal:
+//
+//   - entries iterate in ascending TxTimeID order;
+//   - RangeHash is the amp.TxRangeDigest fingerprint, so peers backed by different
+//     stores still converge under Merkle bisection;
+//   - a quarantined entry is hidden from ReadSince (and so from fanout and sync),
+//     still counted by HighWater and RangeHash, readable via ReadQuarantined, and
+//     evicted once its TTL lapses.
+//
+// Memory is the in-process journal for tests, tools, and ephemeral nodes; File is
+// the durable, crash-recovering journal for embedded and edge deployments.
+package journal
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Memory is a concurrency-safe in-memory amp.TxJournal.  All methods are threadsafe.
+//
+// Callbacks passed to ReadSince / ReadQuarantined run outside the journal lock,
+// so a callback may itself Append or Quarantine.  The raw slice a callback
+// receives is the journal's stored copy and must not be modified.
+type Memory struct {
+	now func() time.Time // clock for quarantine expiry (swapped in tests)
+
+	mu      sync.Mutex
+	planets map[tag.UID]*memPlanet
+	closed  bool
+}
+
+// memPlanet is one planet's entries in ascending TxTimeID order.
+type memPlanet struct {
+	entries    []*memEntry
+	nextExpiry time.Time // earliest quarantine expiry; zero when nothing is quarantined
+}
+
+type memEntry struct {
+	txTimeID tag.UID
+	raw      []byte
+	expires  time.Time // zero = not quarantined
+}
+
+func (entry *memEntry) quarantined() bool {
+	return !entry.expires.IsZero()
+}
+
+var _ amp.TxJournal = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory journal.
+func NewMemory() *Memory {
+	return &Memory{
+		now:     time.Now,
+		planets: make(map[tag.UID]*memPlanet),
+	}
+}
+
+// Close releases the journal's entries; subsequent calls return status.ErrClosed.
+func (j *Memory) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.closed = true
+	j.planets = nil
+	return nil
+}
+
+// Append stores a copy of raw at (planetID, txTimeID).  Re-appending identical
+// bytes is a no-op, so replayed sync streams are harmless; differing bytes under
+// an existing TxTimeID are refused — the first durable write holds.
+func (j *Memory) Append(planetID tag.UID, txTimeID tag.UID, raw []byte) error {
+	if planetID.IsNil() || txTimeID.IsNil() {
+		return status.Code_BadRequest.Error("journal: Append requires a PlanetID and TxTimeID")
+	}
+	if len(raw) == 0 {
+		return status.Code_BadRequest.Error("journal: Append requires TxMsg bytes")
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return status.ErrClosed
+	}
+	planet := j.planets[planetID]
+	if planet == nil {
+		planet = &memPlanet{}
+		j.planets[planetID] = planet
+	}
+	planet.evict(j.now())
+
+	idx, found := planet.search(txTimeID)
+	if found {
+		if bytes.Equal(planet.entries[idx].raw, raw) {
+			return nil
+		}
+		return status.Code_AlreadyExists.Errorf("journal: entry %v already holds different bytes", txTimeID)
+	}
+	entry := &memEntry{
+		txTimeID: txTimeID,
+		raw:      bytes.Clone(raw),
+	}
+	planet.entries = slices.Insert(planet.entries, idx, entry)
+	return nil
+}
+
+// ReadSince calls cb for each live (non-quarantined) entry with TxTimeID > after,
+// in ascending order, until cb returns false.
+func (j *Memory) ReadSince(planetID tag.UID, after tag.UID, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	return j.read(planetID, after, false, cb)
+}
+
+// ReadQuarantined calls cb for each quarantined, unexpired entry with TxTimeID > after,
+// in ascending order, until cb returns false.
+func (j *Memory) ReadQuarantined(planetID tag.UID, after tag.UID, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	return j.read(planetID, after, true, cb)
+}
+
+func (j *Memory) read(planetID, after tag.UID, quarantined bool, cb func(txTimeID tag.UID, raw []byte) bool) error {
+	snapshot, err := j.snapshot(planetID, after)
+	if err != nil {
+		return err
+	}
+	for _, entry := range snapshot {
+		if entry.quarantined() != quarantined {
+			continue
+		}
+		if !cb(entry.txTimeID, entry.raw) {
+			break
+		}
+	}
+	return nil
+}
+
+// snapshot returns the planet's entries with TxTimeID > after, evicting expired
+// quarantine first.  Entries are immutable once stored except for expires, which
+// is only written under the write lock — so the snapshot copies the flag state.
+func (j *Memory) snapshot(planetID, after tag.UID) ([]memEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return nil, status.ErrClosed
+	}
+	planet := j.planets[planetID]
+	if planet == nil {
+		return nil, nil
+	}
+	planet.evict(j.now())
+
+	idx, found := planet.search(after)
+	if found {
+		idx++
+	}
+	snapshot := make([]memEntry, 0, len(planet.entries)-idx)
+	for _, entry := range planet.entries[idx:] {
+		snapshot = append(snapshot, *entry)
+	}
+	return snapshot, nil
+}
+
+// HighWater returns the greatest stored TxTimeID (quarantined entries included),
+// or the nil UID when the planet holds no entries.
+func (j *Memory) HighWater(planetID tag.UID) (tag.UID, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return tag.UID{}, status.ErrClosed
+	}
+	planet := j.planets[planetID]
+	if planet == nil {
+		return tag.UID{}, nil
+	}
+	planet.evict(j.now())
+	if n := len(planet.entries); n > 0 {
+		return planet.entries[n-1].txTimeID, nil
+	}
+	return tag.UID{}, nil
+}
+
+// RangeHash returns the amp.TxRangeDigest fingerprint over entries with
+// start <= TxTimeID <= end, quarantined entries included.
+func (j *Memory) RangeHash(planetID tag.UID, start, end tag.UID) (tag.UID, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return tag.UID{}, status.ErrClosed
+	}
+	digest := amp.NewTxRangeDigest()
+	planet := j.planets[planetID]
+	if planet == nil {
+		return digest.Sum(), nil
+	}
+	planet.evict(j.now())
+
+	idx, _ := planet.search(start)
+	for _, entry := range planet.entries[idx:] {
+		if entry.txTimeID.CompareTo(end) > 0 {
+			break
+		}
+		digest.Add(entry.txTimeID, entry.raw)
+	}
+	return digest.Sum(), nil
+}
+
+// Quarantine flags the entry at (planetID, txTimeID) for eviction after ttl
+// (re-arming the TTL if already quarantined).  A non-positive ttl selects
+// amp.DefaultQuarantineRetention.  Returns status.ErrItemNotFound if absent.
+func (j *Memory) Quarantine(planetID tag.UID, txTimeID tag.UID, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = (*amp.VaultConfig)(nil).QuarantineRetention()
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return status.ErrClosed
+	}
+	planet := j.planets[planetID]
+	if planet == nil {
+		return status.ErrItemNotFound
+	}
+	now := j.now()
+	planet.evict(now)
+
+	idx, found := planet.search(txTimeID)
+	if !found {
+		return status.ErrItemNotFound
+	}
+	expires := now.Add(ttl)
+	planet.entries[idx].expires = expires
+	if planet.nextExpiry.IsZero() || expires.Before(planet.nextExpiry) {
+		planet.nextExpiry = expires
+	}
+	return nil
+}
+
+// search returns the index of txTimeID, or where it would be inserted.
+func (planet *memPlanet) search(txTimeID tag.UID) (int, bool) {
+	return slices.BinarySearchFunc(planet.entries, txTimeID, func(entry *memEntry, target tag.UID) int {
+		return entry.txTimeID.CompareTo(target)
+	})
+}
+
+// evict drops quarantined entries whose TTL has lapsed.  Called under the write
+// lock; a no-op scan-free path until the earliest expiry comes due.
+func (planet *memPlanet) evict(now time.Time) {
+	if planet.nextExpiry.IsZero() || now.Before(planet.nextExpiry) {
+		return
+	}
+	planet.nextExpiry = time.Time{}
+	planet.entries = slices.DeleteFunc(planet.entries, func(entry *memEntry) bool {
+		if !entry.quarantined() {
+			return false
+		}
+		if !now.Before(entry.expires) {
+			return true
+		}
+		if planet.nextExpiry.IsZero() || entry.expires.Before(planet.nextExpiry) {
+			planet.nextExpiry = entry.expires
+		}
+		return false
+	})
+}
@@ -0,0 +1,116 @@
+// Package meter enforces a planet's VaultConfig workload budgets per proven
+// identity — a handshake-verified peer or a member — so that one identity
+// cannot masquerade as many to multiply its share.
+//
+// Each identity holds three token buckets, each refilling its whole budget once
+// per window:
+//
+//	TxMsg count   MaxTxPerWindow         over RateLimitWindow
+//	TxMsg bytes   MaxBytesPerWindow      over RateLimitWindow
+//	blob bytes    MaxBlobBytesPerWindow  over BlobRateLimitWindow
+//
+// A bucket starts full, so an identity may spend its whole window budget in a
+// burst and then proceeds at the refill rate — the sliding-window bound the
+// VaultConfig fields describe, without per-event bookkeeping.
+package meter
+
+import (
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/bucket"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// DefaultMaxKeys bounds the identities a Meter tracks at once.
+const DefaultMaxKeys = 1 << 16
+
+// Opts tunes a Meter beyond what VaultConfig sets.
+type Opts struct {
+	// MaxKeys bounds the identities tracked per budget (0 = DefaultMaxKeys).
+	// Past it the least recently metered identity is evicted and restarts with
+	// a full budget, so size it above the identities active within a window.
+	MaxKeys int
+}
+
+// Meter is a keyed limiter registry: one set of budgets per identity.  All
+// methods are threadsafe.
+type Meter struct {
+	txCount   *bucket.Keyed[tag.UID]
+	txBytes   *bucket.Keyed[tag.UID]
+	blobBytes *bucket.Keyed[tag.UID]
+}
+
+// Snapshot is a point-in-time view of a Meter's budgets, for operators.
+type Snapshot struct {
+	TxCount   bucket.KeyedSnapshot[tag.UID]
+	TxBytes   bucket.KeyedSnapshot[tag.UID]
+	BlobBytes bucket.KeyedSnapshot[tag.UID]
+}
+
+// New returns a Meter enforcing config's budgets (nil = all defaults).
+func New(config *amp.VaultConfig, opts Opts) *Meter {
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = DefaultMaxKeys
+	}
+	window := config.RateLimitWindow()
+	blobWindow := config.BlobRateLimitWindow()
+	return &Meter{
+		txCount:   newKeyed(nonZeroInt64(config.GetMaxTxPerWindow(), amp.DefaultMaxTxPerWindow), window, opts.MaxKeys),
+		txBytes:   newKeyed(nonZeroInt64(config.GetMaxBytesPerWindow(), amp.DefaultMaxBytesPerWindow), window, opts.MaxKeys),
+		blobBytes: newKeyed(nonZeroInt64(config.GetMaxBlobBytesPerWindow(), amp.DefaultMaxBlobBytesPerWindow), blobWindow, opts.MaxKeys),
+	}
+}
+
+func newKeyed(budget int64, window time.Duration, maxKeys int) *bucket.Keyed[tag.UID] {
+	return bucket.NewKeyed[tag.UID](float64(budget), float64(budget)/window.Seconds(), maxKeys)
+}
+
+func nonZeroInt64(val, fallback int64) int64 {
+	if val <= 0 {
+		return fallback
+	}
+	return val
+}
+
+// AdmitTx charges one TxMsg of txSize bytes to id's TxMsg budgets, all or
+// nothing.  A refusal carries the wait until the charge would be admitted.
+func (meter *Meter) AdmitTx(id tag.UID, txSize int64) (bool, time.Duration) {
+	if admitted, wait := meter.txCount.TryTakeN(id, 1); !admitted {
+		return false, wait
+	}
+	if admitted, wait := meter.txBytes.TryTakeN(id, float64(txSize)); !admitted {
+		meter.txCount.Refund(id, 1)
+		return false, wait
+	}
+	return true, 0
+}
+
+// AdmitBlobBytes charges byteCount blob-plane bytes (ingest or egress) to id.
+// Charge per chunk: a single charge above MaxBlobBytesPerWindow never admits.
+func (meter *Meter) AdmitBlobBytes(id tag.UID, byteCount int64) (bool, time.Duration) {
+	return meter.blobBytes.TryTakeN(id, float64(byteCount))
+}
+
+// Forget drops id's budgets, e.g. once its session is revoked and its standing
+// is being reset.
+func (meter *Meter) Forget(id tag.UID) {
+	meter.txCount.Forget(id)
+	meter.txBytes.Forget(id)
+	meter.blobBytes.Forget(id)
+}
+
+// Prune drops budgets idle at least `idle` that have fully refilled, returning
+// how many were dropped; call it periodically to bound memory well below MaxKeys.
+func (meter *Meter) Prune(idle time.Duration) int {
+	return meter.txCount.Prune(idle) + meter.txBytes.Prune(idle) + meter.blobBytes.Prune(idle)
+}
+
+// Snapshot returns the current state of every budget.
+func (meter *Meter) Snapshot() Snapshot {
+	return Snapshot{
+		TxCount:   meter.txCount.Snapshot(),
+		TxBytes:   meter.txBytes.Snapshot(),
+		BlobBytes: meter.blobBytes.Snapshot(),
+	}
+}
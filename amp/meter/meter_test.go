@@ -0,0 +1,90 @@
+package meter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+func TestMeterBudgets(t *testing.T) {
+	meter := New(&amp.VaultConfig{
+		MaxTxPerWindow:        3,
+		MaxBytesPerWindow:     1000,
+		MaxBlobBytesPerWindow: 5000,
+		RateLimitWindowSecs:   3600,
+	}, Opts{})
+	alice, bob := tag.NewID(), tag.NewID()
+
+	// Count budget: three TxMsgs, then refused with a retry-after.
+	for range 3 {
+		if admitted, _ := meter.AdmitTx(alice, 10); !admitted {
+			t.Fatal("TxMsg within budget refused")
+		}
+	}
+	admitted, wait := meter.AdmitTx(alice, 10)
+	status.Require(t, admitted, false)
+	if wait <= 0 || wait > 20*time.Minute {
+		t.Fatalf("retry-after %v, want about a third of the window", wait)
+	}
+
+	// Byte budget: a refused byte charge does not spend the count budget.
+	if admitted, _ := meter.AdmitTx(bob, 900); !admitted {
+		t.Fatal("TxMsg within budget refused")
+	}
+	admitted, _ = meter.AdmitTx(bob, 200)
+	status.Require(t, admitted, false)
+	if admitted, _ := meter.AdmitTx(bob, 100); !admitted {
+		t.Fatal("refused byte charge spent the count budget")
+	}
+
+	// The blob plane has its own budget, untouched by TxMsg traffic.
+	if admitted, _ := meter.AdmitBlobBytes(alice, 5000); !admitted {
+		t.Fatal("blob charge within budget refused")
+	}
+	admitted, _ = meter.AdmitBlobBytes(alice, 1)
+	status.Require(t, admitted, false)
+
+	snap := meter.Snapshot()
+	status.Require(t, len(snap.TxCount.Keys), 2)
+	status.Require(t, snap.TxCount.Keys[0].Key, bob) // most recent first
+	status.Require(t, snap.TxCount.Admitted, uint64(5))
+	status.Require(t, snap.TxCount.Rejected, uint64(1))
+	status.Require(t, snap.TxBytes.Rejected, uint64(1))
+	status.Require(t, snap.BlobBytes.Capacity, float64(5000))
+	if rate := snap.BlobBytes.RefillRate; rate != 5000.0/3600 {
+		t.Fatalf("blob window fell back to %v/sec, want the TxMsg window", rate)
+	}
+
+	// Forgetting an identity restores its budget.
+	meter.Forget(alice)
+	if admitted, _ := meter.AdmitTx(alice, 10); !admitted {
+		t.Fatal("forgotten identity still throttled")
+	}
+}
+
+func TestMeterEviction(t *testing.T) {
+	meter := New(nil, Opts{MaxKeys: 2})
+	ids := []tag.UID{tag.NewID(), tag.NewID(), tag.NewID()}
+	for _, id := range ids {
+		meter.AdmitTx(id, 100)
+	}
+	snap := meter.Snapshot()
+	status.Require(t, len(snap.TxCount.Keys), 2)
+	status.Require(t, snap.TxCount.Evicted, uint64(1))
+	status.Require(t, snap.TxCount.Capacity, float64(amp.DefaultMaxTxPerWindow))
+	for _, stats := range snap.TxCount.Keys {
+		if stats.Key == ids[0] {
+			t.Fatal("least recently used identity was not evicted")
+		}
+	}
+
+	// Only fully refilled budgets are pruned: these have spent tokens.
+	status.Require(t, meter.Prune(0), 0)
+	meter.Forget(ids[1])
+	meter.Forget(ids[2])
+	meter.AdmitBlobBytes(ids[0], 0)
+	status.Require(t, meter.Prune(0), 1)
+}
@@ -0,0 +1,422 @@
+// Package outbox provides a durable, file-backed amp.TxOutbox.
+//
+// Locally authored TxMsgs queue here until a vault accepts them.  An entry stays
+// queued until the drain callback returns nil; each failure is recorded on the
+// entry (attempt count and last error) and pushes its next attempt out on an
+// exponential backoff, so a vault that keeps refusing is retried at a falling
+// cadence instead of being hot-looped by every drain.
+//
+// Layout — one directory per planet, one pair of files per entry:
+//
+//	<dir>/<PlanetID hex>/<TxTimeID hex>.tx     raw TxMsg bytes
+//	<dir>/<PlanetID hex>/<TxTimeID hex>.meta   delivery state
+//
+// Both are published by temp-file + rename, so a crash leaves either the old
+// or the new file, never a tear.  Meta layout (big endian):
+//
+//	00:08  queued at (unix nanoseconds)
+//	08:16  next attempt not before (unix nanoseconds)
+//	16:20  attempts
+//	20:    last error text (UTF-8)
+package outbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/platform"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+const (
+	// DefaultBaseBackoff is the delay after an entry's first failed delivery.
+	DefaultBaseBackoff = time.Second
+
+	// DefaultMaxBackoff caps the delay between delivery attempts of one entry.
+	DefaultMaxBackoff = 5 * time.Minute
+
+	txExt       = ".tx"
+	metaExt     = ".meta"
+	metaSize    = 20
+	maxErrorLen = 1024 // last-error text is clipped to this many bytes
+)
+
+// Opts tunes an Outbox.  The zero value selects the defaults.
+type Opts struct {
+	BaseBackoff time.Duration // delay after the first failure; 0 = DefaultBaseBackoff
+	MaxBackoff  time.Duration // ceiling the doubling delay saturates at; 0 = DefaultMaxBackoff
+}
+
+// PendingTx describes one queued entry — the operator's view of the outbox.
+type PendingTx struct {
+	PlanetID    tag.UID
+	TxTimeID    tag.UID
+	ByteSize    int64         // raw TxMsg size
+	Queued      time.Time     // when the entry was first enqueued
+	Age         time.Duration // time queued as of the listing
+	Attempts    int           // failed delivery attempts so far
+	LastError   string        // the most recent failure; empty before the first attempt
+	NextAttempt time.Time     // the entry is skipped by DrainTx until then
+}
+
+// Outbox is a durable amp.TxOutbox.  All methods are threadsafe; DrainTx calls
+// are serialized, and EnqueueTx proceeds while a drain is in flight.
+type Outbox struct {
+	dir  string
+	opts Opts
+	now  func() time.Time // clock for queue age and backoff (swapped in tests)
+
+	drainMu sync.Mutex // serializes DrainTx
+
+	mu      sync.Mutex
+	planets map[tag.UID]map[tag.UID]*entry
+	closed  bool
+}
+
+// entry is one queued TxMsg's delivery state; its bytes stay on disk until drained.
+type entry struct {
+	txTimeID    tag.UID
+	byteSize    int64
+	queued      time.Time
+	nextAttempt time.Time
+	attempts    int
+	lastError   string
+}
+
+var _ amp.TxOutbox = (*Outbox)(nil)
+
+// Open opens (creating if needed) the outbox rooted at dir and reloads any
+// entries queued before a restart.
+func Open(dir string, opts Opts) (*Outbox, error) {
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = DefaultBaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	opts.MaxBackoff = max(opts.MaxBackoff, opts.BaseBackoff)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	box := &Outbox{
+		dir:     dir,
+		opts:    opts,
+		now:     time.Now,
+		planets: make(map[tag.UID]map[tag.UID]*entry),
+	}
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	for _, dirent := range dirents {
+		planetID, err := tag.UID_ParseBase16(dirent.Name())
+		if !dirent.IsDir() || err != nil {
+			continue
+		}
+		entries, err := loadPlanet(filepath.Join(dir, dirent.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			box.planets[planetID] = entries
+		}
+	}
+	return box, nil
+}
+
+// loadPlanet reloads one planet's entries, discarding temp files and orphaned
+// meta left by a crash.  An entry whose meta never landed restarts its delivery
+// state, queued as of its bytes' modification time.
+func loadPlanet(dir string) (map[tag.UID]*entry, error) {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	entries := make(map[tag.UID]*entry)
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		path := filepath.Join(dir, name)
+		if strings.HasPrefix(name, platform.AtomicTempPrefix) {
+			os.Remove(path)
+			continue
+		}
+		txTimeID, err := tag.UID_ParseBase16(strings.TrimSuffix(name, txExt))
+		if err != nil || !strings.HasSuffix(name, txExt) {
+			continue
+		}
+		info, err := dirent.Info()
+		if err != nil {
+			return nil, status.Code_StorageFailure.Wrap(err)
+		}
+		ent := &entry{
+			txTimeID: txTimeID,
+			byteSize: info.Size(),
+			queued:   info.ModTime(),
+		}
+		if buf, err := os.ReadFile(metaPath(dir, txTimeID)); err == nil {
+			ent.decodeMeta(buf)
+		}
+		entries[txTimeID] = ent
+	}
+	for _, dirent := range dirents {
+		name := dirent.Name()
+		if txTimeID, err := tag.UID_ParseBase16(strings.TrimSuffix(name, metaExt)); err == nil && strings.HasSuffix(name, metaExt) && entries[txTimeID] == nil {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+	return entries, nil
+}
+
+// Close releases the outbox; queued entries stay on disk for the next Open.
+func (box *Outbox) Close() error {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	box.closed = true
+	box.planets = nil
+	return nil
+}
+
+// EnqueueTx durably queues raw for delivery.  Re-enqueuing a TxTimeID already
+// queued is a no-op that keeps the entry's delivery state.
+func (box *Outbox) EnqueueTx(planetID tag.UID, txTimeID tag.UID, raw []byte) error {
+	if planetID.IsNil() || txTimeID.IsNil() {
+		return status.Code_BadRequest.Error("outbox: EnqueueTx requires a PlanetID and TxTimeID")
+	}
+	if len(raw) == 0 {
+		return status.Code_BadRequest.Error("outbox: EnqueueTx requires TxMsg bytes")
+	}
+
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	if box.closed {
+		return status.ErrClosed
+	}
+	entries := box.planets[planetID]
+	if entries[txTimeID] != nil {
+		return nil
+	}
+
+	dir := box.planetDir(planetID)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	ent := &entry{
+		txTimeID: txTimeID,
+		byteSize: int64(len(raw)),
+		queued:   box.now(),
+	}
+	// Meta first: a crash between the two writes leaves an orphaned meta, which
+	// Open discards, rather than bytes that would be delivered with no state.
+	if err := platform.WriteFileAtomic(metaPath(dir, txTimeID), ent.encodeMeta()); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if err := platform.WriteFileAtomic(txPath(dir, txTimeID), raw); err != nil {
+		os.Remove(metaPath(dir, txTimeID))
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	if entries == nil {
+		entries = make(map[tag.UID]*entry)
+		box.planets[planetID] = entries
+	}
+	entries[txTimeID] = ent
+	return nil
+}
+
+// DrainTx offers each due entry to cb — planet by planet, ascending TxTimeID
+// within a planet — and removes the entries cb accepts (returns nil).
+//
+// A failure is recorded on the entry and defers it by the backoff for its attempt
+// count; the planet's remaining entries are then left for a later drain, since
+// they would fail against the same vault and must not overtake the failed entry.
+// Callback failures are not returned — they are delivery state, visible through
+// Pending; DrainTx returns an error only when the outbox itself fails.
+func (box *Outbox) DrainTx(cb func(planetID tag.UID, txTimeID tag.UID, raw []byte) error) error {
+	box.drainMu.Lock()
+	defer box.drainMu.Unlock()
+
+	for _, planetID := range box.planetIDs() {
+		for _, txTimeID := range box.dueEntries(planetID) {
+			raw, err := os.ReadFile(txPath(box.planetDir(planetID), txTimeID))
+			if err != nil {
+				return status.Code_StorageFailure.Wrap(err)
+			}
+			cbErr := cb(planetID, txTimeID, raw)
+			if err := box.settle(planetID, txTimeID, cbErr); err != nil {
+				return err
+			}
+			if cbErr != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Pending lists the queued entries — planet by planet, ascending TxTimeID
+// within a planet — with each entry's age and delivery state.
+func (box *Outbox) Pending() []PendingTx {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	now := box.now()
+	var pending []PendingTx
+	for planetID, entries := range box.planets {
+		for _, ent := range entries {
+			pending = append(pending, PendingTx{
+				PlanetID:    planetID,
+				TxTimeID:    ent.txTimeID,
+				ByteSize:    ent.byteSize,
+				Queued:      ent.queued,
+				Age:         now.Sub(ent.queued),
+				Attempts:    ent.attempts,
+				LastError:   ent.lastError,
+				NextAttempt: ent.nextAttempt,
+			})
+		}
+	}
+	slices.SortFunc(pending, func(a, b PendingTx) int {
+		if c := a.PlanetID.CompareTo(b.PlanetID); c != 0 {
+			return c
+		}
+		return a.TxTimeID.CompareTo(b.TxTimeID)
+	})
+	return pending
+}
+
+// Backoff returns the delay before the next attempt of an entry that has failed
+// `attempts` times: BaseBackoff doubling per failure, capped at MaxBackoff.
+func (opts Opts) Backoff(attempts int) time.Duration {
+	if attempts <= 0 {
+		return 0
+	}
+	delay := opts.BaseBackoff
+	for i := 1; i < attempts && delay < opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, opts.MaxBackoff)
+}
+
+func (box *Outbox) planetIDs() []tag.UID {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	planetIDs := make([]tag.UID, 0, len(box.planets))
+	for planetID := range box.planets {
+		planetIDs = append(planetIDs, planetID)
+	}
+	slices.SortFunc(planetIDs, tag.UID.CompareTo)
+	return planetIDs
+}
+
+// dueEntries returns the planet's entries in ascending TxTimeID order, stopping
+// at the first entry still backing off so nothing overtakes it.
+func (box *Outbox) dueEntries(planetID tag.UID) []tag.UID {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	entries := make([]*entry, 0, len(box.planets[planetID]))
+	for _, ent := range box.planets[planetID] {
+		entries = append(entries, ent)
+	}
+	slices.SortFunc(entries, func(a, b *entry) int {
+		return a.txTimeID.CompareTo(b.txTimeID)
+	})
+	now := box.now()
+	var due []tag.UID
+	for _, ent := range entries {
+		if now.Before(ent.nextAttempt) {
+			break
+		}
+		due = append(due, ent.txTimeID)
+	}
+	return due
+}
+
+// settle removes a delivered entry or records a failed attempt.
+func (box *Outbox) settle(planetID, txTimeID tag.UID, cbErr error) error {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+
+	if box.closed {
+		return status.ErrClosed
+	}
+	entries := box.planets[planetID]
+	ent := entries[txTimeID]
+	if ent == nil {
+		return nil
+	}
+	dir := box.planetDir(planetID)
+	if cbErr == nil {
+		if err := os.Remove(txPath(dir, txTimeID)); err != nil && !os.IsNotExist(err) {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		os.Remove(metaPath(dir, txTimeID))
+		delete(entries, txTimeID)
+		if len(entries) == 0 {
+			delete(box.planets, planetID)
+			os.Remove(dir) // only succeeds once empty
+		}
+		return nil
+	}
+
+	ent.attempts++
+	ent.lastError = cbErr.Error()
+	if len(ent.lastError) > maxErrorLen {
+		ent.lastError = ent.lastError[:maxErrorLen]
+	}
+	ent.nextAttempt = box.now().Add(box.opts.Backoff(ent.attempts))
+	if err := platform.WriteFileAtomic(metaPath(dir, txTimeID), ent.encodeMeta()); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+func (box *Outbox) planetDir(planetID tag.UID) string {
+	return filepath.Join(box.dir, planetID.Base16())
+}
+
+func (ent *entry) encodeMeta() []byte {
+	buf := make([]byte, metaSize, metaSize+len(ent.lastError))
+	binary.BigEndian.PutUint64(buf[0:], uint64(ent.queued.UnixNano()))
+	binary.BigEndian.PutUint64(buf[8:], uint64(unixNano(ent.nextAttempt)))
+	binary.BigEndian.PutUint32(buf[16:], uint32(ent.attempts))
+	return append(buf, ent.lastError...)
+}
+
+func (ent *entry) decodeMeta(buf []byte) {
+	if len(buf) < metaSize {
+		return
+	}
+	ent.queued = time.Unix(0, int64(binary.BigEndian.Uint64(buf[0:])))
+	if next := int64(binary.BigEndian.Uint64(buf[8:])); next != 0 {
+		ent.nextAttempt = time.Unix(0, next)
+	}
+	ent.attempts = int(binary.BigEndian.Uint32(buf[16:]))
+	ent.lastError = string(bytes.ToValidUTF8(buf[metaSize:], nil))
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func txPath(dir string, txTimeID tag.UID) string {
+	return filepath.Join(dir, txTimeID.Base16()+txExt)
+}
+
+func metaPath(dir string, txTimeID tag.UID) string {
+	return filepath.Join(dir, txTimeID.Base16()+metaExt)
+}
@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *testClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *testClock) Advance(d time.Duration) {
+	clock.mu.Lock()
+	clock.now = clock.now.Add(d)
+	clock.mu.Unlock()
+}
+
+func openTest(t *testing.T, dir string, clock *testClock) *Outbox {
+	t.Helper()
+	box, err := Open(dir, Opts{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	box.now = clock.Now
+	t.Cleanup(func() { box.Close() })
+	return box
+}
+
+func txIDs(n int) []tag.UID {
+	base := time.Unix(1_700_000_000, 0)
+	ids := make([]tag.UID, n)
+	for i := range ids {
+		ids[i] = tag.UID_FromTime(base.Add(time.Duration(i) * time.Millisecond))
+	}
+	return ids
+}
+
+func txBytes(id tag.UID) []byte {
+	return fmt.Appendf(nil, "tx-%v", id)
+}
+
+// drainAll drains once, delivering via deliver, and returns the TxTimeIDs offered.
+func drainAll(t *testing.T, box *Outbox, deliver func(planetID, txTimeID tag.UID) error) []tag.UID {
+	t.Helper()
+	var offered []tag.UID
+	err := box.DrainTx(func(planetID, txTimeID tag.UID, raw []byte) error {
+		if string(raw) != string(txBytes(txTimeID)) {
+			t.Errorf("entry %v: bytes mismatch", txTimeID)
+		}
+		offered = append(offered, txTimeID)
+		return deliver(planetID, txTimeID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return offered
+}
+
+func TestDrainSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	box := openTest(t, dir, clock)
+
+	planetA, planetB := tag.NewID(), tag.NewID()
+	ids := txIDs(6)
+	for i, id := range ids {
+		planetID := planetA
+		if i%2 == 1 {
+			planetID = planetB
+		}
+		if err := box.EnqueueTx(planetID, id, txBytes(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := box.EnqueueTx(planetA, ids[0], txBytes(ids[0])); err != nil {
+		t.Fatalf("re-enqueue: %v", err)
+	}
+	status.Require(t, len(box.Pending()), 6)
+
+	// Restart before any delivery: everything is still queued.
+	box.Close()
+	box = openTest(t, dir, clock)
+	status.Require(t, len(box.Pending()), 6)
+
+	accepted := map[tag.UID]bool{}
+	offered := drainAll(t, box, func(planetID, txTimeID tag.UID) error {
+		accepted[txTimeID] = true
+		return nil
+	})
+	status.Require(t, len(offered), 6)
+	status.Require(t, len(accepted), 6)
+	status.Require(t, len(box.Pending()), 0)
+
+	// Delivered entries are gone from disk too.
+	box.Close()
+	box = openTest(t, dir, clock)
+	status.Require(t, len(box.Pending()), 0)
+	if dirents, _ := os.ReadDir(dir); len(dirents) != 0 {
+		t.Fatalf("drained outbox left %d planet dirs", len(dirents))
+	}
+}
+
+func TestDrainOrderAndBackoff(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	box := openTest(t, dir, clock)
+
+	planetID := tag.NewID()
+	ids := txIDs(4)
+	for _, i := range []int{2, 0, 3, 1} {
+		box.EnqueueTx(planetID, ids[i], txBytes(ids[i]))
+	}
+
+	// The vault refuses ids[1]: ids[0] delivers, the rest wait behind ids[1].
+	refuse := errors.New("vault unreachable")
+	offered := drainAll(t, box, func(_, txTimeID tag.UID) error {
+		if txTimeID == ids[1] {
+			return refuse
+		}
+		return nil
+	})
+	status.Require(t, len(offered), 2)
+	status.Require(t, offered[0], ids[0])
+	status.Require(t, offered[1], ids[1])
+
+	pending := box.Pending()
+	status.Require(t, len(pending), 3)
+	status.Require(t, pending[0].TxTimeID, ids[1])
+	status.Require(t, pending[0].Attempts, 1)
+	status.Require(t, pending[0].LastError, refuse.Error())
+	status.Require(t, pending[0].NextAttempt, clock.Now().Add(time.Second))
+
+	// Still backing off: the drain does not touch the planet at all.
+	clock.Advance(500 * time.Millisecond)
+	status.Require(t, len(drainAll(t, box, func(_, _ tag.UID) error { return nil })), 0)
+
+	// Repeated failures double the delay up to MaxBackoff.
+	for _, want := range []time.Duration{2, 4, 8, 10, 10} {
+		clock.Advance(time.Minute)
+		drainAll(t, box, func(_, _ tag.UID) error { return refuse })
+		pending = box.Pending()
+		status.Require(t, pending[0].NextAttempt.Sub(clock.Now()), want*time.Second)
+	}
+	status.Require(t, pending[0].Attempts, 6)
+
+	// Delivery state survives a restart.
+	box.Close()
+	box = openTest(t, dir, clock)
+	pending = box.Pending()
+	status.Require(t, pending[0].Attempts, 6)
+	status.Require(t, pending[0].LastError, refuse.Error())
+
+	clock.Advance(time.Minute)
+	offered = drainAll(t, box, func(_, _ tag.UID) error { return nil })
+	status.Require(t, len(offered), 3)
+	status.Require(t, len(box.Pending()), 0)
+}
+
+func TestPendingAge(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	box := openTest(t, t.TempDir(), clock)
+
+	planetID := tag.NewID()
+	ids := txIDs(2)
+	box.EnqueueTx(planetID, ids[0], txBytes(ids[0]))
+	clock.Advance(time.Minute)
+	box.EnqueueTx(planetID, ids[1], txBytes(ids[1]))
+	clock.Advance(time.Minute)
+
+	pending := box.Pending()
+	status.Require(t, len(pending), 2)
+	status.Require(t, pending[0].PlanetID, planetID)
+	status.Require(t, pending[0].Age, 2*time.Minute)
+	status.Require(t, pending[1].Age, time.Minute)
+	status.Require(t, pending[1].ByteSize, int64(len(txBytes(ids[1]))))
+}
+
+func TestCrashLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	box := openTest(t, dir, clock)
+
+	planetID := tag.NewID()
+	ids := txIDs(3)
+	box.EnqueueTx(planetID, ids[0], txBytes(ids[0]))
+	box.Close()
+
+	planetDir := filepath.Join(dir, planetID.Base16())
+	// A meta whose bytes never landed, bytes whose meta never landed, and a stray temp.
+	os.WriteFile(metaPath(planetDir, ids[1]), make([]byte, metaSize), 0600)
+	os.WriteFile(txPath(planetDir, ids[2]), txBytes(ids[2]), 0600)
+	os.WriteFile(filepath.Join(planetDir, "x.tx.tmp-123"), []byte("torn"), 0600)
+
+	box = openTest(t, dir, clock)
+	pending := box.Pending()
+	status.Require(t, len(pending), 2)
+	status.Require(t, pending[0].TxTimeID, ids[0])
+	status.Require(t, pending[1].TxTimeID, ids[2])
+	if _, err := os.Stat(metaPath(planetDir, ids[1])); !os.IsNotExist(err) {
+		t.Fatal("orphaned meta not discarded")
+	}
+	status.Require(t, len(drainAll(t, box, func(_, _ tag.UID) error { return nil })), 2)
+}
+
+func TestEnqueueDuringDrain(t *testing.T) {
+	clock := &testClock{now: time.Unix(1_700_000_000, 0)}
+	box := openTest(t, t.TempDir(), clock)
+
+	planetID := tag.NewID()
+	ids := txIDs(2)
+	box.EnqueueTx(planetID, ids[0], txBytes(ids[0]))
+	drainAll(t, box, func(_, _ tag.UID) error {
+		return box.EnqueueTx(planetID, ids[1], txBytes(ids[1]))
+	})
+	status.Require(t, len(box.Pending()), 1)
+}
@@ -103,6 +103,7 @@ var Attr = struct {
 	LawPlanetOrigin                    tag.Name
 	LawEquivalence                     tag.Name
 	LawWithdraw                        tag.Name
+	LawChronicleCompact                tag.Name
 	LawMemberKind                      tag.Name
 	LawMemberKind_Person               tag.Name
 	LawMemberKind_Group                tag.Name
@@ -278,13 +279,14 @@ var Attr = struct {
 	// the permissions other channels inherit.
 	LawAttr: tag.Name{ID: tag.UID{0xB8D21569231305B8, 0xF74881C639EB11E5}, Text: "amp.law"}, // 5su-8bqk8sm0qw-gfk41sswyq-4g5
 
-	LawPlanetEpoch:  tag.Name{ID: tag.UID{0x62F9D4DD32683CE3, 0x1D28B3308B06594F}, Text: "amp.law.PlanetEpoch"},  // 32z-7beudm87mj-jub5m625hd-qbg
-	LawMemberEpoch:  tag.Name{ID: tag.UID{0xD4D5E6BE48B6D994, 0x19B1DB5E98597C29}, Text: "amp.law.MemberEpoch"},  // 6nu-rmcwk5qv6b-1mdfvcud5k-z19
-	LawChannelEpoch: tag.Name{ID: tag.UID{0xC49076CDFEB23BBF, 0xAD7271CB90287F97}, Text: "amp.law.ChannelEpoch"}, // 64k-1vdvzpk7fz-uuwmjtf82h-zwr
-	LawEpochLink:    tag.Name{ID: tag.UID{0xC320666D94AB0CFA, 0xD04D554E454713BF}, Text: "amp.law.EpochLink"},    // 634-1m6v55c1mx-e0mbp9t2nf-4xz
-	LawPlanetOrigin: tag.Name{ID: tag.UID{0x6C8CDF082B47A29E, 0xCC572ADCF4282E79}, Text: "amp.law.PlanetOrigin"}, // 3dj-mghhbu7nbg-dsptbvmu2h-cmt
-	LawEquivalence:  tag.Name{ID: tag.UID{0x99F3808D1F407BE6, 0x2E656BA55F1738FE}, Text: "amp.law.Equivalence"},  // 4ty-f08u7u0ggm-2wtccnpgjf-f7y
-	LawWithdraw:     tag.Name{ID: tag.UID{0x850B8DAE8EC87EF2, 0x228AC81879D663ED}, Text: "amp.law.Withdraw"},     // 451-f6ux3q8gvt-252q831wxd-sze
+	LawPlanetEpoch:      tag.Name{ID: tag.UID{0x62F9D4DD32683CE3, 0x1D28B3308B06594F}, Text: "amp.law.PlanetEpoch"},      // 32z-7beudm87mj-jub5m625hd-qbg
+	LawMemberEpoch:      tag.Name{ID: tag.UID{0xD4D5E6BE48B6D994, 0x19B1DB5E98597C29}, Text: "amp.law.MemberEpoch"},      // 6nu-rmcwk5qv6b-1mdfvcud5k-z19
+	LawChannelEpoch:     tag.Name{ID: tag.UID{0xC49076CDFEB23BBF, 0xAD7271CB90287F97}, Text: "amp.law.ChannelEpoch"},     // 64k-1vdvzpk7fz-uuwmjtf82h-zwr
+	LawEpochLink:        tag.Name{ID: tag.UID{0xC320666D94AB0CFA, 0xD04D554E454713BF}, Text: "amp.law.EpochLink"},        // 634-1m6v55c1mx-e0mbp9t2nf-4xz
+	LawPlanetOrigin:     tag.Name{ID: tag.UID{0x6C8CDF082B47A29E, 0xCC572ADCF4282E79}, Text: "amp.law.PlanetOrigin"},     // 3dj-mghhbu7nbg-dsptbvmu2h-cmt
+	LawEquivalence:      tag.Name{ID: tag.UID{0x99F3808D1F407BE6, 0x2E656BA55F1738FE}, Text: "amp.law.Equivalence"},      // 4ty-f08u7u0ggm-2wtccnpgjf-f7y
+	LawWithdraw:         tag.Name{ID: tag.UID{0x850B8DAE8EC87EF2, 0x228AC81879D663ED}, Text: "amp.law.Withdraw"},         // 451-f6ux3q8gvt-252q831wxd-sze
+	LawChronicleCompact: tag.Name{ID: tag.UID{0x676690B113088905, 0x7CC4040AF65DD11E}, Text: "amp.law.ChronicleCompact"}, // 37d-u8c24s8j42-rtj041cv5v-n8y
 	// Substrate-agnostic Member Kind (AOM SD-substrate-agnostic-members.md).  MemberEpoch.Kind is a Tag
 	// resolving to one of these UIDs.  Communities + apps may register
 	// additional Kinds in their own consts.sdl.  Zero UID = unspecified.
@@ -574,6 +576,7 @@ func init() {
 	RegisterAttrDeclared(Attr.LawPlanetOrigin, &amp.PlanetOrigin{}, amp.EditFlow_Fold)
 	RegisterAttrDeclared(Attr.LawEquivalence, &amp.Equivalence{}, amp.EditFlow_Fold)
 	RegisterAttrDeclared(Attr.LawWithdraw, &amp.Withdraw{}, amp.EditFlow_Fold)
+	RegisterAttrDeclared(Attr.LawChronicleCompact, &amp.ChronicleCompact{}, amp.EditFlow_Fold)
 	RegisterAttrDeclared(Attr.LedgerAttestation, &amp.Attestation{}, amp.EditFlow_Fold)
 	RegisterAttrDeclared(Attr.PlanetInvite, &amp.PlanetInvite{}, amp.EditFlow_Fold)
 	RegisterAttrDeclared(Attr.PlanetInviteOp, &amp.PlanetInviteOp{}, amp.EditFlow_Fold)
@@ -71,5 +71,6 @@ func IsGovernanceLawAttr(attrID tag.UID) bool {
 		attrID == Attr.LawMemberEpoch.ID ||
 		attrID == Attr.LawPlanetEpoch.ID ||
 		attrID == Attr.LawPlanetOrigin.ID ||
-		attrID == Attr.LawEquivalence.ID
+		attrID == Attr.LawEquivalence.ID ||
+		attrID == Attr.LawChronicleCompact.ID
 }
@@ -0,0 +1,453 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Datagram is an unreliable packet link — a LoRa or packet-radio driver, say.
+// Packets may be lost, duplicated, or reordered, but never arrive altered
+// (a driver discards frames failing its own CRC) or longer than the MTU.
+type Datagram interface {
+
+	// SendPacket sends one packet of at most the link's MTU bytes.
+	SendPacket(pkt []byte) error
+
+	// RecvPacket blocks until a packet arrives; ErrNotConnected once closed.
+	RecvPacket() ([]byte, error)
+
+	Close() error
+}
+
+// Fragment packets (big endian):
+//
+//	Data  'D' | TxID (16) | index u16 | count u16 | payload
+//	Poll  'P' | TxID (16)                          sender asks what arrived
+//	Ack   'A' | TxID (16) | base u16 | bitmap      fragments from base held (bit i = base+i; MSB first)
+//	Done  'F' | TxID (16)                          whole TxMsg received
+//
+// The sender sends every fragment, then polls after RetransmitTimeout of
+// silence; each Ack names the window from the receiver's first missing
+// fragment, and only the fragments it lacks are resent.  The receiver answers
+// Done once a TxMsg completes — and again for any later fragment or poll of a
+// recently delivered TxID, so a lost Done or a resent TxMsg is never delivered
+// twice.
+const (
+	fragData = 'D'
+	fragPoll = 'P'
+	fragAck  = 'A'
+	fragDone = 'F'
+
+	fragHeaderSize = 1 + tag.UID_Size
+	fragDataHeader = fragHeaderSize + 4
+
+	// MinFragmentMTU is the smallest MTU a Fragmenter accepts.
+	MinFragmentMTU = fragDataHeader + 8
+)
+
+// FragmentOpts configures a Fragmenter.
+type FragmentOpts struct {
+	Label             string        // Info().Label ("" = "fragment")
+	MTU               int           // largest packet the link carries (required, ≥ MinFragmentMTU)
+	RetransmitTimeout time.Duration // sender silence before polling (0 = 1s)
+	MaxRetries        int           // unanswered polls before SendTx gives up (0 = 8)
+	ReassemblyTimeout time.Duration // receiver drops an idle partial TxMsg after this (0 = (MaxRetries+2) × RetransmitTimeout)
+	RecentTxIDs       int           // delivered TxIDs remembered for duplicate suppression (0 = 1024)
+	RecvQueue         int           // completed TxMsgs buffered for RecvTx (0 = 64)
+}
+
+// Fragmenter adapts a Datagram link into an amp.Transport: each TxMsg is cut
+// into MTU-sized fragments, reassembled at the far end, selectively
+// retransmitted until acknowledged, and delivered at most once per TxID.
+// SendTx blocks until the peer acknowledges the whole TxMsg (or MaxRetries
+// polls go unanswered); concurrent SendTx calls proceed in parallel.  Every
+// TxMsg sent must carry a TxID.
+type Fragmenter struct {
+	link Datagram
+	opts FragmentOpts
+
+	sendMu sync.Mutex // serializes link.SendPacket
+
+	mu        sync.Mutex
+	outbound  map[tag.UID]*fragOut
+	inbound   map[tag.UID]*fragIn
+	delivered map[tag.UID]struct{}
+	recent    []tag.UID // delivered, oldest first (ring of RecentTxIDs)
+	recentPos int
+
+	recvQ    chan *amp.TxMsg
+	closed   chan struct{}
+	stopOnce sync.Once
+	linkOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+var _ amp.Transport = (*Fragmenter)(nil)
+
+// fragOut is one TxMsg being sent.
+type fragOut struct {
+	frags    [][]byte // encoded Data packets
+	held     []bool   // fragments the receiver holds
+	deadline time.Time
+	polls    int
+	done     chan error
+}
+
+// fragIn is one TxMsg being reassembled.
+type fragIn struct {
+	frags    [][]byte
+	have     int
+	lastSeen time.Time
+}
+
+// NewFragmenter starts a Fragmenter over link; Close closes link too.
+func NewFragmenter(link Datagram, opts FragmentOpts) (*Fragmenter, error) {
+	if opts.MTU < MinFragmentMTU {
+		return nil, status.Code_BadRequest.Errorf("transport: Fragmenter MTU %d under %d", opts.MTU, MinFragmentMTU)
+	}
+	if opts.Label == "" {
+		opts.Label = "fragment"
+	}
+	if opts.RetransmitTimeout <= 0 {
+		opts.RetransmitTimeout = time.Second
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 8
+	}
+	if opts.ReassemblyTimeout <= 0 {
+		opts.ReassemblyTimeout = time.Duration(opts.MaxRetries+2) * opts.RetransmitTimeout
+	}
+	if opts.RecentTxIDs <= 0 {
+		opts.RecentTxIDs = 1024
+	}
+	if opts.RecvQueue <= 0 {
+		opts.RecvQueue = 64
+	}
+	frag := &Fragmenter{
+		link:      link,
+		opts:      opts,
+		outbound:  make(map[tag.UID]*fragOut),
+		inbound:   make(map[tag.UID]*fragIn),
+		delivered: make(map[tag.UID]struct{}),
+		recent:    make([]tag.UID, opts.RecentTxIDs),
+		recvQ:     make(chan *amp.TxMsg, opts.RecvQueue),
+		closed:    make(chan struct{}),
+	}
+	frag.wg.Go(frag.readLoop)
+	frag.wg.Go(frag.tickLoop)
+	return frag, nil
+}
+
+// Info implements amp.Transport.
+func (frag *Fragmenter) Info() amp.TransportInfo {
+	return amp.TransportInfo{
+		Label:        frag.opts.Label,
+		RequiresAuth: true,
+	}
+}
+
+// SendTx fragments tx and blocks until the peer holds all of it.
+func (frag *Fragmenter) SendTx(tx *amp.TxMsg) error {
+	if tx == nil {
+		return status.Code_BadRequest.Error("transport: SendTx: nil TxMsg")
+	}
+	txID := tx.TxID()
+	if txID.IsNil() {
+		return status.Code_BadRequest.Error("transport: Fragmenter requires a TxID")
+	}
+	var wire []byte
+	tx.MarshalToBuffer(&wire)
+
+	span := frag.opts.MTU - fragDataHeader
+	count := (len(wire) + span - 1) / span
+	if count > 0xFFFF {
+		return status.Code_BadRequest.Errorf("transport: TxMsg of %d bytes needs %d fragments at MTU %d", len(wire), count, frag.opts.MTU)
+	}
+	out := &fragOut{
+		frags: make([][]byte, count),
+		held:  make([]bool, count),
+		done:  make(chan error, 1),
+	}
+	for ii := range count {
+		pkt := make([]byte, fragDataHeader, frag.opts.MTU)
+		pkt[0] = fragData
+		txID.AppendTo(pkt[1:1])
+		binary.BigEndian.PutUint16(pkt[fragHeaderSize:], uint16(ii))
+		binary.BigEndian.PutUint16(pkt[fragHeaderSize+2:], uint16(count))
+		out.frags[ii] = append(pkt, wire[ii*span:min(len(wire), (ii+1)*span)]...)
+	}
+
+	frag.mu.Lock()
+	if _, busy := frag.outbound[txID]; busy {
+		frag.mu.Unlock()
+		return status.Code_BadRequest.Errorf("transport: TxMsg %v is already being sent", txID)
+	}
+	out.deadline = time.Now().Add(frag.opts.RetransmitTimeout)
+	frag.outbound[txID] = out
+	frag.mu.Unlock()
+
+	for _, pkt := range out.frags {
+		if err := frag.send(pkt); err != nil {
+			frag.finish(txID, err)
+			break
+		}
+	}
+	select {
+	case err := <-out.done:
+		return err
+	case <-frag.closed:
+		return status.ErrNotConnected
+	}
+}
+
+// RecvTx blocks until a whole TxMsg has arrived.
+func (frag *Fragmenter) RecvTx() (*amp.TxMsg, error) {
+	select {
+	case tx := <-frag.recvQ:
+		return tx, nil
+	case <-frag.closed:
+		select {
+		case tx := <-frag.recvQ:
+			return tx, nil
+		default:
+			return nil, status.ErrNotConnected
+		}
+	}
+}
+
+// Close stops the Fragmenter and closes its link; pending sends fail.
+func (frag *Fragmenter) Close() error {
+	var err error
+	frag.stop()
+	frag.linkOnce.Do(func() {
+		err = frag.link.Close()
+		frag.wg.Wait()
+	})
+	return err
+}
+
+// stop closes the Fragmenter to callers, failing pending sends.
+func (frag *Fragmenter) stop() {
+	frag.stopOnce.Do(func() { close(frag.closed) })
+}
+
+func (frag *Fragmenter) send(pkt []byte) error {
+	frag.sendMu.Lock()
+	defer frag.sendMu.Unlock()
+	return frag.link.SendPacket(pkt)
+}
+
+// sendCtl sends a Poll, Ack, or Done for txID.
+func (frag *Fragmenter) sendCtl(kind byte, txID tag.UID, body []byte) {
+	pkt := make([]byte, 0, fragHeaderSize+len(body))
+	pkt = append(pkt, kind)
+	pkt = txID.AppendTo(pkt)
+	frag.send(append(pkt, body...))
+}
+
+// finish completes a pending SendTx.
+func (frag *Fragmenter) finish(txID tag.UID, err error) {
+	frag.mu.Lock()
+	out := frag.outbound[txID]
+	delete(frag.outbound, txID)
+	frag.mu.Unlock()
+	if out != nil {
+		out.done <- err
+	}
+}
+
+func (frag *Fragmenter) readLoop() {
+	for {
+		pkt, err := frag.link.RecvPacket()
+		if err != nil {
+			frag.stop()
+			return
+		}
+		if len(pkt) < fragHeaderSize {
+			continue
+		}
+		txID := tag.UID{binary.BigEndian.Uint64(pkt[1:9]), binary.BigEndian.Uint64(pkt[9:17])}
+		body := pkt[fragHeaderSize:]
+		switch pkt[0] {
+		case fragData:
+			frag.onData(txID, body)
+		case fragPoll:
+			frag.onPoll(txID)
+		case fragAck:
+			frag.onAck(txID, body)
+		case fragDone:
+			frag.finish(txID, nil)
+		}
+	}
+}
+
+func (frag *Fragmenter) onData(txID tag.UID, body []byte) {
+	if len(body) < 4 {
+		return
+	}
+	index := int(binary.BigEndian.Uint16(body[0:2]))
+	count := int(binary.BigEndian.Uint16(body[2:4]))
+	if count == 0 || index >= count {
+		return
+	}
+
+	frag.mu.Lock()
+	if _, dup := frag.delivered[txID]; dup {
+		frag.mu.Unlock()
+		frag.sendCtl(fragDone, txID, nil)
+		return
+	}
+	in := frag.inbound[txID]
+	if in == nil {
+		in = &fragIn{frags: make([][]byte, count)}
+		frag.inbound[txID] = in
+	}
+	in.lastSeen = time.Now()
+	if len(in.frags) != count || in.frags[index] != nil {
+		frag.mu.Unlock()
+		return
+	}
+	in.frags[index] = bytes.Clone(body[4:])
+	if in.have++; in.have < count {
+		frag.mu.Unlock()
+		return
+	}
+	delete(frag.inbound, txID)
+	frag.remember(txID)
+	frag.mu.Unlock()
+
+	frag.sendCtl(fragDone, txID, nil)
+	tx, err := amp.ReadTxMsg(bytes.NewReader(bytes.Join(in.frags, nil)))
+	if err != nil {
+		return // a peer bug, not link loss: nothing to retransmit
+	}
+	select {
+	case frag.recvQ <- tx:
+	case <-frag.closed:
+	}
+}
+
+// remember records txID as delivered; mu must be held.
+func (frag *Fragmenter) remember(txID tag.UID) {
+	if old := frag.recent[frag.recentPos]; !old.IsNil() {
+		delete(frag.delivered, old)
+	}
+	frag.recent[frag.recentPos] = txID
+	frag.recentPos = (frag.recentPos + 1) % len(frag.recent)
+	frag.delivered[txID] = struct{}{}
+}
+
+// onPoll answers with Done, or an Ack window from the first missing fragment.
+func (frag *Fragmenter) onPoll(txID tag.UID) {
+	frag.mu.Lock()
+	if _, dup := frag.delivered[txID]; dup {
+		frag.mu.Unlock()
+		frag.sendCtl(fragDone, txID, nil)
+		return
+	}
+	var ack []byte
+	if in := frag.inbound[txID]; in != nil {
+		base := 0
+		for base < len(in.frags) && in.frags[base] != nil {
+			base++
+		}
+		maxBits := (frag.opts.MTU - fragHeaderSize - 2) * 8
+		bits := min(len(in.frags)-base, maxBits)
+		ack = make([]byte, 2+(bits+7)/8)
+		binary.BigEndian.PutUint16(ack, uint16(base))
+		for ii := range bits {
+			if in.frags[base+ii] != nil {
+				ack[2+ii/8] |= 0x80 >> (ii % 8)
+			}
+		}
+	} else {
+		ack = []byte{0, 0} // nothing held: base 0, empty window
+	}
+	frag.mu.Unlock()
+	frag.sendCtl(fragAck, txID, ack)
+}
+
+// onAck resends the fragments the receiver's window shows missing.
+func (frag *Fragmenter) onAck(txID tag.UID, body []byte) {
+	if len(body) < 2 {
+		return
+	}
+	base := int(binary.BigEndian.Uint16(body[0:2]))
+	bitmap := body[2:]
+
+	frag.mu.Lock()
+	out := frag.outbound[txID]
+	if out == nil {
+		frag.mu.Unlock()
+		return
+	}
+	for ii := range min(base, len(out.held)) {
+		out.held[ii] = true
+	}
+	window := min(len(out.held)-min(base, len(out.held)), len(bitmap)*8)
+	if len(bitmap) == 0 {
+		window = len(out.held) - min(base, len(out.held))
+	}
+	var resend [][]byte
+	for ii := range window {
+		idx := base + ii
+		if ii < len(bitmap)*8 && bitmap[ii/8]&(0x80>>(ii%8)) != 0 {
+			out.held[idx] = true
+		} else if !out.held[idx] {
+			resend = append(resend, out.frags[idx])
+		}
+	}
+	out.polls = 0
+	out.deadline = time.Now().Add(frag.opts.RetransmitTimeout)
+	frag.mu.Unlock()
+
+	for _, pkt := range resend {
+		frag.send(pkt)
+	}
+}
+
+// tickLoop polls silent sends, fails exhausted ones, and drops stale partials.
+func (frag *Fragmenter) tickLoop() {
+	ticker := time.NewTicker(max(frag.opts.RetransmitTimeout/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-frag.closed:
+			return
+		case now := <-ticker.C:
+			var polls, failed []tag.UID
+			frag.mu.Lock()
+			for txID, out := range frag.outbound {
+				if now.Before(out.deadline) {
+					continue
+				}
+				if out.polls >= frag.opts.MaxRetries {
+					failed = append(failed, txID)
+					continue
+				}
+				out.polls++
+				out.deadline = now.Add(frag.opts.RetransmitTimeout)
+				polls = append(polls, txID)
+			}
+			for txID, in := range frag.inbound {
+				if now.Sub(in.lastSeen) > frag.opts.ReassemblyTimeout {
+					delete(frag.inbound, txID)
+				}
+			}
+			frag.mu.Unlock()
+
+			for _, txID := range polls {
+				frag.sendCtl(fragPoll, txID, nil)
+			}
+			for _, txID := range failed {
+				frag.finish(txID, status.Code_Timeout.Errorf("transport: TxMsg %v unacknowledged after %d polls", txID, frag.opts.MaxRetries))
+			}
+		}
+	}
+}
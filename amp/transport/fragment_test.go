@@ -0,0 +1,115 @@
+package transport
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+const testMTU = 200
+
+func newFragmenterPair(t *testing.T, link PipeOpts, opts FragmentOpts) (*Fragmenter, *Fragmenter) {
+	t.Helper()
+	opts.MTU = testMTU
+	linkA, linkB := NewDatagramPipe(testMTU, link)
+	endA, err := NewFragmenter(linkA, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endB, err := NewFragmenter(linkB, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		endA.Close()
+		endB.Close()
+	})
+	return endA, endB
+}
+
+// bigTx returns a TxMsg spanning many fragments at testMTU.
+func bigTx(t *testing.T, seq int) *amp.TxMsg {
+	t.Helper()
+	tx := amp.TxNew()
+	tx.SetTxID(tag.NowID())
+	text := strings.Repeat(string(rune('a'+seq%26)), 1500+seq*37)
+	if err := tx.Upsert(tag.UID{0x7e, 0x57}, tag.UID{0xa7, 0x7e}, tag.UID{0, 1}, &amp.Tag{I: int64(seq), Text: text}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestFragmenterLossyLink(t *testing.T) {
+	const sends = 24
+	endA, endB := newFragmenterPair(t, PipeOpts{
+		Jitter:  2 * time.Millisecond,
+		Reorder: 0.1,
+		Drop:    0.2,
+		Seed:    11,
+	}, FragmentOpts{
+		RetransmitTimeout: 20 * time.Millisecond,
+		MaxRetries:        50,
+	})
+
+	sent := make(map[tag.UID]*amp.TxMsg)
+	for seq := range sends {
+		tx := bigTx(t, seq)
+		sent[tx.TxID()] = tx
+	}
+
+	var wg sync.WaitGroup
+	for _, tx := range sent {
+		wg.Go(func() {
+			if err := endA.SendTx(tx); err != nil {
+				t.Errorf("SendTx %v: %v", tx.TxID(), err)
+			}
+		})
+	}
+
+	wg.Wait()
+
+	// Resending a delivered TxMsg is acknowledged but not delivered again.
+	for _, tx := range sent {
+		if err := endA.SendTx(tx); err != nil {
+			t.Fatalf("resend: %v", err)
+		}
+		break
+	}
+
+	got := make(map[tag.UID]bool)
+	for range sends {
+		tx, err := endB.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sent[tx.TxID()]
+		if want == nil || got[tx.TxID()] {
+			t.Fatalf("unexpected or duplicate TxMsg %v", tx.TxID())
+		}
+		got[tx.TxID()] = true
+		status.Require(t, txSeq(t, tx), txSeq(t, want))
+		status.Require(t, len(tx.DataStore), len(want.DataStore))
+	}
+
+	endA.Close()
+	if tx, err := endB.RecvTx(); err != status.ErrNotConnected {
+		t.Fatalf("after close: got %v, %v", tx, err)
+	}
+}
+
+func TestFragmenterGivesUp(t *testing.T) {
+	endA, _ := newFragmenterPair(t, PipeOpts{Drop: 1}, FragmentOpts{
+		RetransmitTimeout: 5 * time.Millisecond,
+		MaxRetries:        3,
+	})
+	err := endA.SendTx(bigTx(t, 0))
+	status.Require(t, status.GetCode(err), status.Code_Timeout)
+
+	_, err = NewFragmenter(nil, FragmentOpts{MTU: MinFragmentMTU - 1})
+	status.Require(t, status.GetCode(err), status.Code_BadRequest)
+}
@@ -0,0 +1,296 @@
+// Package transport provides amp.Transport implementations and adapters that
+// carry TxMsgs between a client and a host over links other than the host's
+// own services.
+package transport
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+// PipeOpts configures a pipe pair.  The zero value is an instant, lossless,
+// in-order pipe handing each *TxMsg across as is.
+type PipeOpts struct {
+	Label string // Info().Label prefix ("" = "pipe")
+
+	// Wire round-trips every TxMsg through SealTx / OpenTx, so the receiver
+	// gets a fresh TxMsg decoded from the real wire format.  Crypto seals and
+	// opens (nil = the unsealed local-session format); with SignerPubKey set
+	// the receiver verifies the author signature against it.
+	Wire         bool
+	Crypto       amp.CryptoProvider
+	SignerPubKey []byte
+	SignerKit    safe.CryptoKitID
+
+	// Fault injection, applied per TxMsg in each direction.
+	Latency      time.Duration // fixed delay before a TxMsg can be received
+	Jitter       time.Duration // uniform extra delay in [0, Jitter)
+	Reorder      float64       // chance a TxMsg is held back by ReorderDelay, letting later ones overtake it
+	ReorderDelay time.Duration // 0 = Latency + Jitter + 1ms
+	Drop         float64       // chance a TxMsg is silently lost
+	Seed         uint64        // fault RNG seed (0 = random)
+}
+
+// Pipe is one end of an in-process amp.Transport pair made by NewPipeTransport.
+//
+// Closing either end closes the pair: TxMsgs still in flight (delayed and not
+// yet due) are lost, those already arrived stay receivable, and once drained
+// RecvTx and SendTx return status.ErrNotConnected.  Without Wire the receiver
+// gets the sender's *TxMsg itself, so a sender must not alter a TxMsg once sent.
+type Pipe struct {
+	label string
+	opts  *PipeOpts
+	pair  *pipePair
+	in    *pipeLink // TxMsgs for this end
+	out   *pipeLink // TxMsgs for the other end
+}
+
+var _ amp.Transport = (*Pipe)(nil)
+
+// NewPipeTransport returns the two connected ends of an in-process pipe.
+func NewPipeTransport(opts PipeOpts) (*Pipe, *Pipe) {
+	if opts.Label == "" {
+		opts.Label = "pipe"
+	}
+	if opts.ReorderDelay <= 0 {
+		opts.ReorderDelay = opts.Latency + opts.Jitter + time.Millisecond
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	pair := &pipePair{
+		rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+	}
+	ab, ba := newPipeLink(), newPipeLink()
+	pair.links = [2]*pipeLink{ab, ba}
+	endA := &Pipe{label: opts.Label + ".a", opts: &opts, pair: pair, in: ba, out: ab}
+	endB := &Pipe{label: opts.Label + ".b", opts: &opts, pair: pair, in: ab, out: ba}
+	return endA, endB
+}
+
+// Info implements amp.Transport.
+func (pipe *Pipe) Info() amp.TransportInfo {
+	return amp.TransportInfo{
+		Label: pipe.label,
+	}
+}
+
+// SendTx queues tx for the other end, subject to the pair's faults.
+func (pipe *Pipe) SendTx(tx *amp.TxMsg) error {
+	if tx == nil {
+		return status.Code_BadRequest.Error("transport: SendTx: nil TxMsg")
+	}
+	item := pipeItem{tx: tx}
+	if pipe.opts.Wire {
+		if err := amp.SealTx(tx, pipe.opts.Crypto, &item.raw); err != nil {
+			return err
+		}
+		item.tx = nil
+	}
+	return pipe.deliver(item)
+}
+
+// deliver queues item for the other end after rolling its faults.
+func (pipe *Pipe) deliver(item pipeItem) error {
+	delay, drop := pipe.pair.roll(pipe.opts)
+	if drop {
+		if pipe.out.isClosed() {
+			return status.ErrNotConnected
+		}
+		return nil
+	}
+	item.due = time.Now().Add(delay)
+	return pipe.out.push(item)
+}
+
+// RecvTx blocks until a TxMsg is due or the pair is closed and drained.
+func (pipe *Pipe) RecvTx() (*amp.TxMsg, error) {
+	item, err := pipe.in.pop()
+	if err != nil {
+		return nil, err
+	}
+	if item.tx != nil {
+		return item.tx, nil
+	}
+	opts := pipe.opts
+	if opts.Crypto != nil && len(opts.SignerPubKey) == 0 {
+		return amp.OpenTxSansVerify(item.raw, opts.Crypto)
+	}
+	return amp.OpenTx(item.raw, opts.Crypto, opts.SignerPubKey, opts.SignerKit)
+}
+
+// Close closes both ends of the pair.
+func (pipe *Pipe) Close() error {
+	for _, link := range pipe.pair.links {
+		link.close()
+	}
+	return nil
+}
+
+// NewDatagramPipe returns the two ends of an in-process packet link carrying
+// packets of at most mtu bytes, subject to opts' faults (Wire and its crypto
+// fields do not apply) — a stand-in for a radio link when exercising a
+// Fragmenter or a driver's protocol.  Closing either end closes both.
+func NewDatagramPipe(mtu int, opts PipeOpts) (Datagram, Datagram) {
+	endA, endB := NewPipeTransport(opts)
+	return &pipeDatagram{Pipe: endA, mtu: mtu}, &pipeDatagram{Pipe: endB, mtu: mtu}
+}
+
+// pipeDatagram carries packets over one end of a pipe pair.
+type pipeDatagram struct {
+	*Pipe
+	mtu int
+}
+
+func (dg *pipeDatagram) SendPacket(pkt []byte) error {
+	if len(pkt) > dg.mtu {
+		return status.Code_BadRequest.Errorf("transport: packet of %d bytes exceeds MTU %d", len(pkt), dg.mtu)
+	}
+	return dg.deliver(pipeItem{raw: bytes.Clone(pkt)})
+}
+
+func (dg *pipeDatagram) RecvPacket() ([]byte, error) {
+	item, err := dg.in.pop()
+	return item.raw, err
+}
+
+// pipePair is the state both ends share.
+type pipePair struct {
+	links [2]*pipeLink
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// roll draws the faults for one TxMsg.
+func (pair *pipePair) roll(opts *PipeOpts) (delay time.Duration, drop bool) {
+	if opts.Drop <= 0 && opts.Reorder <= 0 && opts.Jitter <= 0 {
+		return opts.Latency, false
+	}
+	pair.rngMu.Lock()
+	defer pair.rngMu.Unlock()
+	if opts.Drop > 0 && pair.rng.Float64() < opts.Drop {
+		return 0, true
+	}
+	delay = opts.Latency
+	if opts.Jitter > 0 {
+		delay += time.Duration(pair.rng.Int64N(int64(opts.Jitter)))
+	}
+	if opts.Reorder > 0 && pair.rng.Float64() < opts.Reorder {
+		delay += opts.ReorderDelay
+	}
+	return delay, false
+}
+
+type pipeItem struct {
+	due time.Time
+	tx  *amp.TxMsg // handed across as is (no Wire)
+	raw []byte     // wire bytes (Wire)
+}
+
+// pipeLink is one direction of a pair: a queue ordered by due time.
+type pipeLink struct {
+	mu     sync.Mutex
+	queue  []pipeItem // ascending due; equal due keeps send order
+	closed bool
+	wake   chan struct{} // an item was pushed
+	done   chan struct{} // closed on close
+}
+
+func newPipeLink() *pipeLink {
+	return &pipeLink{
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+}
+
+func (link *pipeLink) isClosed() bool {
+	link.mu.Lock()
+	defer link.mu.Unlock()
+	return link.closed
+}
+
+func (link *pipeLink) push(item pipeItem) error {
+	link.mu.Lock()
+	if link.closed {
+		link.mu.Unlock()
+		return status.ErrNotConnected
+	}
+	pos := len(link.queue)
+	for pos > 0 && link.queue[pos-1].due.After(item.due) {
+		pos--
+	}
+	link.queue = slices.Insert(link.queue, pos, item)
+	link.mu.Unlock()
+	link.signal()
+	return nil
+}
+
+func (link *pipeLink) pop() (pipeItem, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		link.mu.Lock()
+		var wait time.Duration
+		if len(link.queue) > 0 {
+			if wait = time.Until(link.queue[0].due); wait <= 0 {
+				item := link.queue[0]
+				link.queue = link.queue[1:]
+				link.mu.Unlock()
+				return item, nil
+			}
+		} else if link.closed {
+			link.mu.Unlock()
+			return pipeItem{}, status.ErrNotConnected
+		}
+		link.mu.Unlock()
+
+		var due <-chan time.Time
+		if wait > 0 {
+			if timer == nil {
+				timer = time.NewTimer(wait)
+			} else {
+				timer.Reset(wait)
+			}
+			due = timer.C
+		}
+		select {
+		case <-link.wake:
+		case <-link.done:
+		case <-due:
+		}
+	}
+}
+
+// close drops what is still in flight and wakes any receiver.
+func (link *pipeLink) close() {
+	link.mu.Lock()
+	if !link.closed {
+		link.closed = true
+		now := time.Now()
+		link.queue = slices.DeleteFunc(link.queue, func(item pipeItem) bool {
+			return item.due.After(now)
+		})
+		close(link.done)
+	}
+	link.mu.Unlock()
+}
+
+func (link *pipeLink) signal() {
+	select {
+	case link.wake <- struct{}{}:
+	default:
+	}
+}
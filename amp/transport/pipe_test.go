@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// testTx returns a TxMsg carrying seq as its one op's value.
+func testTx(t *testing.T, seq int) *amp.TxMsg {
+	t.Helper()
+	tx := amp.TxNew()
+	tx.SetTxID(tag.NowID())
+	if err := tx.Upsert(tag.UID{0x7e, 0x57}, tag.UID{0xa7, 0x7e}, tag.UID{0, uint64(seq + 1)}, &amp.Tag{I: int64(seq), Text: "payload"}); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func txSeq(t *testing.T, tx *amp.TxMsg) int {
+	t.Helper()
+	val := &amp.Tag{}
+	if err := tx.UnmarshalOpValue(0, val); err != nil {
+		t.Fatal(err)
+	}
+	return int(val.I)
+}
+
+func TestPipeRoundTrip(t *testing.T) {
+	for _, wire := range []bool{false, true} {
+		endA, endB := NewPipeTransport(PipeOpts{Wire: wire})
+		for seq := range 10 {
+			sent := testTx(t, seq)
+			if err := endA.SendTx(sent); err != nil {
+				t.Fatal(err)
+			}
+			got, err := endB.RecvTx()
+			if err != nil {
+				t.Fatal(err)
+			}
+			status.Require(t, got == sent, !wire)
+			status.Require(t, got.TxID(), sent.TxID())
+			status.Require(t, txSeq(t, got), seq)
+		}
+
+		// Either end's Close drains, then reports ErrNotConnected on both.
+		endB.SendTx(testTx(t, 99))
+		endB.Close()
+		got, err := endA.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, txSeq(t, got), 99)
+		_, err = endA.RecvTx()
+		status.Require(t, err, status.ErrNotConnected)
+		status.Require(t, endA.SendTx(testTx(t, 0)), status.ErrNotConnected)
+	}
+}
+
+func TestPipeLatency(t *testing.T) {
+	const latency = 30 * time.Millisecond
+	endA, endB := NewPipeTransport(PipeOpts{Latency: latency})
+	defer endA.Close()
+
+	start := time.Now()
+	for seq := range 5 {
+		endA.SendTx(testTx(t, seq))
+	}
+	for seq := range 5 {
+		got, err := endB.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, txSeq(t, got), seq) // fixed latency keeps order
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Fatalf("received after %v, latency is %v", elapsed, latency)
+	}
+}
+
+func TestPipeFaults(t *testing.T) {
+	const sent = 400
+	endA, endB := NewPipeTransport(PipeOpts{
+		Jitter:  time.Millisecond,
+		Reorder: 0.2,
+		Drop:    0.1,
+		Seed:    7,
+	})
+
+	var (
+		wg       sync.WaitGroup
+		received []int
+	)
+	wg.Go(func() {
+		for {
+			tx, err := endB.RecvTx()
+			if err != nil {
+				return
+			}
+			received = append(received, txSeq(t, tx))
+		}
+	})
+	for seq := range sent {
+		if err := endA.SendTx(testTx(t, seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(50 * time.Millisecond) // let every delayed TxMsg come due
+	endA.Close()
+	wg.Wait()
+
+	seen := make(map[int]bool)
+	reordered := 0
+	for ii, seq := range received {
+		if seen[seq] {
+			t.Fatalf("TxMsg %d delivered twice", seq)
+		}
+		seen[seq] = true
+		if ii > 0 && seq < received[ii-1] {
+			reordered++
+		}
+	}
+	lost := sent - len(received)
+	if lost < sent/20 || lost > sent/5 {
+		t.Fatalf("lost %d of %d at Drop 0.1", lost, sent)
+	}
+	if reordered == 0 {
+		t.Fatal("no TxMsg overtook another at Reorder 0.2")
+	}
+}
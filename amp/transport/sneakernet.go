@@ -0,0 +1,809 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobgc"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Sneakernet bundle layout, as dropped into a shared directory (a USB stick,
+// a synced folder, a mail attachment unpacked):
+//
+//	<dir>/amp-bundle-<BundleID hex>/manifest.json                      what the bundle holds
+//	<dir>/amp-bundle-<BundleID hex>/manifest.sum                       hex digest of manifest.json
+//	<dir>/amp-bundle-<BundleID hex>/tx/<n>.tx                          one TxMsg, sealed wire form
+//	<dir>/amp-bundle-<BundleID hex>/blob/<PlanetID hex>/<BlobID hex>   one blob, as stored
+//
+// A bundle is built under a dot-prefixed temp name and renamed into place
+// whole, so an importer never sees a partial one.  manifest.json lists every
+// file with its size and digest; import checks manifest.json against
+// manifest.sum, then each file against the manifest, before anything is stored
+// or delivered.  Blobs travel as stored — ciphertext for a sealed blob — and
+// land through StoreValidated, so each is also checked against its content
+// address.  A bundle is never modified once written: its BundleID is its
+// identity for deduplication.
+
+const (
+	// BundleVersion is the manifest version this package writes and reads.
+	BundleVersion = 1
+
+	bundlePrefix    = "amp-bundle-"
+	bundleTmpPrefix = ".tmp-"
+	manifestFile    = "manifest.json"
+	manifestSumFile = "manifest.sum"
+	ledgerFile      = "sneakernet.imported"
+
+	// DefaultSneakernetPoll is how often RecvTx rescans an idle drop directory.
+	DefaultSneakernetPoll = 2 * time.Second
+)
+
+// BundleManifest describes one bundle's contents.
+type BundleManifest struct {
+	Version  int
+	BundleID tag.UID
+	HashKit  safe.HashKitID // digests every file (and manifest.sum)
+	Txs      []BundleTx     // in send order
+	Blobs    []BundleBlob
+}
+
+// BundleTx is one TxMsg file of a bundle.
+type BundleTx struct {
+	TxID   tag.UID
+	File   string // bundle-relative, slash-separated
+	Size   int64
+	Digest string // hex
+}
+
+// BundleBlob is one blob file of a bundle.
+type BundleBlob struct {
+	PlanetID  tag.UID
+	BlobID    tag.UID        // storage identity (BlobTag.UID)
+	HashKitID safe.HashKitID // the blob's content-address hash
+	File      string
+	Size      int64
+	Digest    string
+}
+
+// SneakernetOpts configures a Sneakernet.
+type SneakernetOpts struct {
+	Label string // Info().Label ("" = "sneakernet:" + Dir)
+	Dir   string // drop directory: bundles are written to and imported from here
+
+	// StateDir holds the ledger of bundles already imported (or written) here,
+	// so a drop directory can be re-attached any number of times and each
+	// bundle delivers once.  "" keeps the ledger in memory for this instance only.
+	StateDir string
+
+	Crypto  amp.CryptoProvider // seals outbound, opens inbound TxMsgs (nil = unsealed local-session form)
+	Blobs   amp.BlobStore      // outbound blob source, inbound blob sink (nil = TxMsgs only)
+	Walker  *blobgc.Walker     // finds the blobs a TxMsg references (nil = blobgc.NewWalker(Crypto))
+	HashKit safe.HashKitID     // bundle file digests (0 = Blake2s_256)
+
+	BundleTxs int           // SendTx writes out the bundle once it holds this many TxMsgs (0 = only on Flush / Close)
+	Poll      time.Duration // RecvTx rescans Dir this often while idle (0 = DefaultSneakernetPoll)
+}
+
+// Sneakernet is an amp.Transport over a shared directory.
+//
+// SendTx seals each TxMsg into the bundle being built, along with every blob it
+// references (per Walker) that Blobs holds; Flush writes that bundle out.
+// RecvTx imports bundles found in Dir — verifying each whole before storing its
+// blobs into Blobs and delivering its TxMsgs in send order — and waits,
+// rescanning, while there are none.  A bundle enters the ledger once RecvTx has
+// returned its last TxMsg, so one interrupted mid-delivery is delivered again
+// by the next instance — at least once, which hosts absorb by TxID.  Bundles
+// already in the ledger are skipped, as is any TxMsg this instance has already
+// delivered.  Inbound TxMsgs are opened without checking the author signature
+// (see amp.OpenTxSansVerify); the receiving host verifies them as it would any
+// peer's.
+type Sneakernet struct {
+	opts SneakernetOpts
+	info amp.TransportInfo
+
+	sendMu  sync.Mutex
+	scrap   []byte
+	staging *bundleBuilder
+
+	ledgerMu sync.Mutex // guards ledger, which both sides update
+	ledger   map[tag.UID]struct{}
+
+	recvMu   sync.Mutex
+	rejected map[string]struct{} // bundle dirs that failed verification
+	seenTx   map[tag.UID]struct{}
+	queue    []queuedTx
+	pending  map[tag.UID]int // BundleID → its TxMsgs queued but not yet returned
+	wake     chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ amp.Transport = (*Sneakernet)(nil)
+
+// queuedTx is an imported TxMsg awaiting RecvTx, with the bundle it came from.
+type queuedTx struct {
+	tx       *amp.TxMsg
+	bundleID tag.UID
+}
+
+// OpenSneakernet opens a Sneakernet on opts.Dir, creating it (and StateDir) if
+// needed, and discards bundles left half-written by an interrupted Flush.
+func OpenSneakernet(opts SneakernetOpts) (*Sneakernet, error) {
+	if opts.Dir == "" {
+		return nil, status.Code_BadRequest.Error("transport: sneakernet requires a Dir")
+	}
+	if opts.Label == "" {
+		opts.Label = "sneakernet:" + opts.Dir
+	}
+	if opts.Walker == nil {
+		opts.Walker = blobgc.NewWalker(opts.Crypto)
+	}
+	if opts.Poll <= 0 {
+		opts.Poll = DefaultSneakernetPoll
+	}
+	if _, err := safe.GetHashKit(opts.HashKit); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	stale, _ := filepath.Glob(filepath.Join(opts.Dir, bundleTmpPrefix+bundlePrefix+"*"))
+	for _, path := range stale {
+		os.RemoveAll(path)
+	}
+
+	sn := &Sneakernet{
+		opts: opts,
+		info: amp.TransportInfo{
+			Label:        opts.Label,
+			RequiresAuth: true, // whoever last held the stick is a stranger
+		},
+		ledger:   make(map[tag.UID]struct{}),
+		rejected: make(map[string]struct{}),
+		seenTx:   make(map[tag.UID]struct{}),
+		pending:  make(map[tag.UID]int),
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+	if err := sn.loadLedger(); err != nil {
+		return nil, err
+	}
+	return sn, nil
+}
+
+// Info implements amp.Transport.
+func (sn *Sneakernet) Info() amp.TransportInfo {
+	return sn.info
+}
+
+// SendTx adds tx, and the stored blobs it references, to the bundle being built.
+// A referenced blob that Blobs does not hold is left out; the receiver fetches
+// it through other means.
+func (sn *Sneakernet) SendTx(tx *amp.TxMsg) error {
+	if tx == nil {
+		return status.Code_BadRequest.Error("transport: SendTx: nil TxMsg")
+	}
+	sn.sendMu.Lock()
+	defer sn.sendMu.Unlock()
+	if sn.isClosed() {
+		return status.ErrNotConnected
+	}
+
+	if sn.staging == nil {
+		staging, err := sn.newBundleBuilder()
+		if err != nil {
+			return err
+		}
+		sn.staging = staging
+	}
+	if err := amp.SealTx(tx, sn.opts.Crypto, &sn.scrap); err != nil {
+		return err
+	}
+	if sn.opts.Blobs != nil {
+		var blobErr error
+		err := sn.opts.Walker.WalkTx(tx, tx.PlanetID(), func(ref *amp.BlobRef) {
+			if blobErr == nil {
+				blobErr = sn.staging.addBlob(sn.opts.Blobs, ref)
+			}
+		})
+		if err == nil {
+			err = blobErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := sn.staging.addTx(tx.TxID(), sn.scrap); err != nil {
+		return err
+	}
+	if sn.opts.BundleTxs > 0 && len(sn.staging.manifest.Txs) >= sn.opts.BundleTxs {
+		return sn.flushLocked()
+	}
+	return nil
+}
+
+// Flush writes out the bundle being built, if it holds anything, and returns
+// its BundleID (nil when there was nothing to write).
+func (sn *Sneakernet) Flush() (tag.UID, error) {
+	sn.sendMu.Lock()
+	defer sn.sendMu.Unlock()
+	var bundleID tag.UID
+	if sn.staging != nil {
+		bundleID = sn.staging.manifest.BundleID
+	}
+	return bundleID, sn.flushLocked()
+}
+
+func (sn *Sneakernet) flushLocked() error {
+	staging := sn.staging
+	if staging == nil {
+		return nil
+	}
+	sn.staging = nil
+
+	// Ledger first: an instance never imports its own bundle.
+	if err := sn.addToLedger(staging.manifest.BundleID); err != nil {
+		staging.discard()
+		return err
+	}
+	return staging.publish()
+}
+
+// RecvTx returns the next imported TxMsg, importing any new bundles in Dir when
+// none are queued and waiting for more while there are none.  Bundles that fail
+// verification are skipped for the life of this instance; see Import for the
+// reason.  Once closed, queued TxMsgs drain, then status.ErrNotConnected.
+func (sn *Sneakernet) RecvTx() (*amp.TxMsg, error) {
+	for {
+		sn.recvMu.Lock()
+		if len(sn.queue) == 0 && !sn.isClosed() {
+			sn.importLocked()
+		}
+		if len(sn.queue) > 0 {
+			next := sn.queue[0]
+			sn.queue[0] = queuedTx{}
+			sn.queue = sn.queue[1:]
+			sn.delivered(next.bundleID)
+			sn.recvMu.Unlock()
+			return next.tx, nil
+		}
+		sn.recvMu.Unlock()
+
+		if sn.isClosed() {
+			return nil, status.ErrNotConnected
+		}
+		select {
+		case <-sn.closed:
+		case <-sn.wake:
+		case <-time.After(sn.opts.Poll):
+		}
+	}
+}
+
+// Import scans Dir now, queuing the TxMsgs of each new bundle for RecvTx, and
+// returns how many were queued.  A bundle failing verification is skipped (and
+// not retried by this instance) and reported in the returned error; a bundle in
+// the ledger is skipped silently.
+func (sn *Sneakernet) Import() (int, error) {
+	sn.recvMu.Lock()
+	defer sn.recvMu.Unlock()
+	return sn.importLocked()
+}
+
+func (sn *Sneakernet) importLocked() (int, error) {
+	entries, err := os.ReadDir(sn.opts.Dir)
+	if err != nil {
+		return 0, status.Code_StorageFailure.Wrap(err)
+	}
+	var errs []error
+	queued := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, bundlePrefix) {
+			continue
+		}
+		bundleID, err := tag.UID_ParseBase16(strings.TrimPrefix(name, bundlePrefix))
+		if err != nil {
+			continue
+		}
+		if _, delivering := sn.pending[bundleID]; delivering || sn.inLedger(bundleID) {
+			continue
+		}
+		if _, bad := sn.rejected[name]; bad {
+			continue
+		}
+		n, err := sn.importBundle(filepath.Join(sn.opts.Dir, name), bundleID)
+		if err != nil {
+			sn.rejected[name] = struct{}{}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		queued += n
+	}
+	if queued > 0 {
+		select {
+		case sn.wake <- struct{}{}:
+		default:
+		}
+	}
+	return queued, errors.Join(errs...)
+}
+
+// importBundle verifies a bundle whole, stores its blobs, and queues its
+// TxMsgs.  The bundle is recorded in the ledger once RecvTx has returned the
+// last of them (see delivered) — right away if it queued none.
+func (sn *Sneakernet) importBundle(path string, bundleID tag.UID) (int, error) {
+	manifest, txs, err := readBundle(path)
+	if err != nil {
+		return 0, err
+	}
+	if manifest.BundleID != bundleID {
+		return 0, status.Code_DataFailure.Errorf("transport: bundle names itself %v", manifest.BundleID)
+	}
+
+	opened := make([]*amp.TxMsg, 0, len(txs))
+	for i, raw := range txs {
+		tx, err := amp.OpenTxSansVerify(raw, sn.opts.Crypto)
+		if err != nil {
+			return 0, status.Code_MalformedTx.Errorf("transport: bundle %s: %v", manifest.Txs[i].File, err)
+		}
+		if tx.TxID() != manifest.Txs[i].TxID {
+			return 0, status.Code_DataFailure.Errorf("transport: bundle %s holds TxMsg %v", manifest.Txs[i].File, tx.TxID())
+		}
+		opened = append(opened, tx)
+	}
+
+	// Blobs land before the TxMsgs that reference them are delivered.
+	if sn.opts.Blobs != nil {
+		for _, blob := range manifest.Blobs {
+			if err := storeBundleBlob(sn.opts.Blobs, path, &blob); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	queued := 0
+	for _, tx := range opened {
+		if _, seen := sn.seenTx[tx.TxID()]; seen {
+			continue
+		}
+		sn.seenTx[tx.TxID()] = struct{}{}
+		sn.queue = append(sn.queue, queuedTx{tx, bundleID})
+		queued++
+	}
+	if queued == 0 {
+		return 0, sn.addToLedger(bundleID)
+	}
+	sn.pending[bundleID] = queued
+	return queued, nil
+}
+
+// delivered notes that RecvTx returned one of bundleID's TxMsgs, and records
+// the bundle in the ledger once it has returned them all.  A failed ledger
+// write is not fatal: the bundle stays out of the ledger, so the next scan
+// re-imports it, finds every TxMsg already delivered, and records it then.
+func (sn *Sneakernet) delivered(bundleID tag.UID) {
+	sn.pending[bundleID]--
+	if sn.pending[bundleID] > 0 {
+		return
+	}
+	delete(sn.pending, bundleID)
+	sn.addToLedger(bundleID)
+}
+
+func storeBundleBlob(blobs amp.BlobStore, bundlePath string, blob *BundleBlob) error {
+	if blobs.Has(blob.PlanetID, blob.BlobID) {
+		return nil
+	}
+	path, err := bundleFilePath(bundlePath, blob.File)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	defer file.Close()
+	ref := &amp.BlobRef{
+		PlanetID_0: blob.PlanetID[0],
+		PlanetID_1: blob.PlanetID[1],
+		HashKitID:  blob.HashKitID,
+		BlobTag: &amp.Tag{
+			UID_0: blob.BlobID[0],
+			UID_1: blob.BlobID[1],
+			I:     blob.Size,
+			Units: amp.Units_Bytes,
+		},
+	}
+	return blobs.StoreValidated(blob.PlanetID, ref, file)
+}
+
+// Close writes out the bundle being built and closes the transport.
+func (sn *Sneakernet) Close() error {
+	sn.sendMu.Lock()
+	err := sn.flushLocked()
+	sn.closeOnce.Do(func() {
+		close(sn.closed)
+	})
+	sn.sendMu.Unlock()
+	return err
+}
+
+func (sn *Sneakernet) isClosed() bool {
+	select {
+	case <-sn.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// loadLedger reads the persisted ledger, one BundleID hex per line.
+func (sn *Sneakernet) loadLedger() error {
+	if sn.opts.StateDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(sn.opts.StateDir, 0700); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	file, err := os.Open(filepath.Join(sn.opts.StateDir, ledgerFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	defer file.Close()
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		if bundleID, err := tag.UID_ParseBase16(strings.TrimSpace(lines.Text())); err == nil {
+			sn.ledger[bundleID] = struct{}{}
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	return nil
+}
+
+func (sn *Sneakernet) inLedger(bundleID tag.UID) bool {
+	sn.ledgerMu.Lock()
+	defer sn.ledgerMu.Unlock()
+	_, has := sn.ledger[bundleID]
+	return has
+}
+
+// addToLedger records a bundle as seen, persisting it first when StateDir is set.
+func (sn *Sneakernet) addToLedger(bundleID tag.UID) error {
+	sn.ledgerMu.Lock()
+	defer sn.ledgerMu.Unlock()
+	if _, has := sn.ledger[bundleID]; has {
+		return nil
+	}
+	if sn.opts.StateDir != "" {
+		file, err := os.OpenFile(filepath.Join(sn.opts.StateDir, ledgerFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+		_, err = file.WriteString(bundleID.Base16() + "\n")
+		if err == nil {
+			err = file.Sync()
+		}
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return status.Code_StorageFailure.Wrap(err)
+		}
+	}
+	sn.ledger[bundleID] = struct{}{}
+	return nil
+}
+
+// VerifyBundle checks a bundle directory whole — manifest.json against
+// manifest.sum, then every listed file's size and digest — and returns its
+// manifest.  It does not open TxMsgs or check blobs against their content
+// addresses; importing does both.
+func VerifyBundle(path string) (*BundleManifest, error) {
+	manifest, _, err := readBundle(path)
+	return manifest, err
+}
+
+// readBundle verifies a bundle and returns its manifest and TxMsg files.
+func readBundle(path string) (*BundleManifest, [][]byte, error) {
+	manifestBytes, err := os.ReadFile(filepath.Join(path, manifestFile))
+	if err != nil {
+		return nil, nil, status.Code_StorageFailure.Wrap(err)
+	}
+	sum, err := os.ReadFile(filepath.Join(path, manifestSumFile))
+	if err != nil {
+		return nil, nil, status.Code_StorageFailure.Wrap(err)
+	}
+	manifest := &BundleManifest{}
+	if err := json.Unmarshal(manifestBytes, manifest); err != nil {
+		return nil, nil, status.Code_ParseFailed.Errorf("transport: bundle manifest: %v", err)
+	}
+	if manifest.Version != BundleVersion {
+		return nil, nil, status.Code_ParseFailed.Errorf("transport: bundle version %d unsupported", manifest.Version)
+	}
+	digest, err := digestOf(manifest.HashKit, bytes.NewReader(manifestBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+	if digest != strings.TrimSpace(string(sum)) {
+		return nil, nil, status.Code_DataFailure.Error("transport: bundle manifest does not match manifest.sum")
+	}
+
+	txs := make([][]byte, len(manifest.Txs))
+	for i, entry := range manifest.Txs {
+		if txs[i], err = readBundleFile(path, entry.File, entry.Size, entry.Digest, manifest.HashKit); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, entry := range manifest.Blobs {
+		if err = checkBundleFile(path, entry.File, entry.Size, entry.Digest, manifest.HashKit); err != nil {
+			return nil, nil, err
+		}
+	}
+	return manifest, txs, nil
+}
+
+// bundleFilePath resolves a manifest-relative name, refusing any that escapes
+// the bundle directory.
+func bundleFilePath(bundlePath, name string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return "", status.Code_DataFailure.Errorf("transport: bundle names file %q outside itself", name)
+	}
+	return filepath.Join(bundlePath, filepath.FromSlash(name)), nil
+}
+
+// readBundleFile reads a TxMsg-sized bundle file and checks it against its
+// manifest entry.
+func readBundleFile(bundlePath, name string, size int64, digest string, hashKit safe.HashKitID) ([]byte, error) {
+	path, err := bundleFilePath(bundlePath, name)
+	if err != nil {
+		return nil, err
+	}
+	if size > amp.DefaultMaxTxMsgSize {
+		return nil, status.Code_DataFailure.Errorf("transport: bundle %s exceeds %d bytes", name, amp.DefaultMaxTxMsgSize)
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	if int64(len(buf)) != size {
+		return nil, status.Code_DataFailure.Errorf("transport: bundle %s is %d bytes, manifest says %d", name, len(buf), size)
+	}
+	got, err := digestOf(hashKit, bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+	if got != digest {
+		return nil, status.Code_DataFailure.Errorf("transport: bundle %s does not match its digest", name)
+	}
+	return buf, nil
+}
+
+// checkBundleFile streams a bundle file (of any size) against its manifest entry.
+func checkBundleFile(bundlePath, name string, size int64, digest string, hashKit safe.HashKitID) error {
+	path, err := bundleFilePath(bundlePath, name)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	defer file.Close()
+	counted := &countingReader{src: file}
+	got, err := digestOf(hashKit, counted)
+	if err != nil {
+		return err
+	}
+	if counted.n != size {
+		return status.Code_DataFailure.Errorf("transport: bundle %s is %d bytes, manifest says %d", name, counted.n, size)
+	}
+	if got != digest {
+		return status.Code_DataFailure.Errorf("transport: bundle %s does not match its digest", name)
+	}
+	return nil
+}
+
+// digestOf returns the hex hashKit digest of src.
+func digestOf(hashKitID safe.HashKitID, src io.Reader) (string, error) {
+	kit, err := safe.NewHashKit(hashKitID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(kit.Hasher, src); err != nil {
+		return "", status.Code_StorageFailure.Wrap(err)
+	}
+	return hex.EncodeToString(kit.Hasher.Sum(nil)), nil
+}
+
+type countingReader struct {
+	src io.Reader
+	n   int64
+}
+
+func (cr *countingReader) Read(buf []byte) (int, error) {
+	n, err := cr.src.Read(buf)
+	cr.n += int64(n)
+	return n, err
+}
+
+// bundleBuilder is a bundle under construction in its temp directory.
+type bundleBuilder struct {
+	dir      string // final bundle path
+	tmpDir   string
+	manifest BundleManifest
+	blobs    map[[2]tag.UID]struct{}
+}
+
+func (sn *Sneakernet) newBundleBuilder() (*bundleBuilder, error) {
+	bundleID := tag.NowID()
+	name := bundlePrefix + bundleID.Base16()
+	bb := &bundleBuilder{
+		dir:    filepath.Join(sn.opts.Dir, name),
+		tmpDir: filepath.Join(sn.opts.Dir, bundleTmpPrefix+name),
+		manifest: BundleManifest{
+			Version:  BundleVersion,
+			BundleID: bundleID,
+			HashKit:  sn.opts.HashKit,
+		},
+		blobs: make(map[[2]tag.UID]struct{}),
+	}
+	if err := os.MkdirAll(filepath.Join(bb.tmpDir, "tx"), 0700); err != nil {
+		return nil, status.Code_StorageFailure.Wrap(err)
+	}
+	return bb, nil
+}
+
+func (bb *bundleBuilder) addTx(txID tag.UID, wire []byte) error {
+	name := fmt.Sprintf("tx/%06d.tx", len(bb.manifest.Txs))
+	if err := writeSynced(filepath.Join(bb.tmpDir, filepath.FromSlash(name)), wire); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	digest, err := digestOf(bb.manifest.HashKit, bytes.NewReader(wire))
+	if err != nil {
+		return err
+	}
+	bb.manifest.Txs = append(bb.manifest.Txs, BundleTx{
+		TxID:   txID,
+		File:   name,
+		Size:   int64(len(wire)),
+		Digest: digest,
+	})
+	return nil
+}
+
+// addBlob copies one referenced blob into the bundle, once.
+func (bb *bundleBuilder) addBlob(blobs amp.BlobStore, ref *amp.BlobRef) error {
+	planetID := tag.UID{ref.PlanetID_0, ref.PlanetID_1}
+	blobID := ref.StorageUID()
+	key := [2]tag.UID{planetID, blobID}
+	if _, has := bb.blobs[key]; has || blobID.IsNil() || !blobs.Has(planetID, blobID) {
+		return nil
+	}
+	src, err := blobs.Retrieve(planetID, blobID)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	name := "blob/" + planetID.Base16() + "/" + blobID.Base16()
+	path := filepath.Join(bb.tmpDir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	kit, err := safe.NewHashKit(bb.manifest.HashKit)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	size, err := io.Copy(io.MultiWriter(file, kit.Hasher), src)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	bb.blobs[key] = struct{}{}
+	bb.manifest.Blobs = append(bb.manifest.Blobs, BundleBlob{
+		PlanetID:  planetID,
+		BlobID:    blobID,
+		HashKitID: ref.HashKitID,
+		File:      name,
+		Size:      size,
+		Digest:    hex.EncodeToString(kit.Hasher.Sum(nil)),
+	})
+	return nil
+}
+
+// publish writes the manifest and its sum, then renames the bundle into place.
+func (bb *bundleBuilder) publish() error {
+	err := bb.writeManifest()
+	if err == nil {
+		err = filepath.WalkDir(bb.tmpDir, func(path string, entry os.DirEntry, err error) error {
+			if err == nil && entry.IsDir() {
+				err = syncDir(path)
+			}
+			return err
+		})
+	}
+	if err == nil {
+		err = os.Rename(bb.tmpDir, bb.dir)
+	}
+	if err != nil {
+		bb.discard()
+		return status.Code_StorageFailure.Wrap(err)
+	}
+	syncDir(filepath.Dir(bb.dir))
+	return nil
+}
+
+func (bb *bundleBuilder) writeManifest() error {
+	manifestBytes, err := json.MarshalIndent(&bb.manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	digest, err := digestOf(bb.manifest.HashKit, bytes.NewReader(manifestBytes))
+	if err != nil {
+		return err
+	}
+	if err = writeSynced(filepath.Join(bb.tmpDir, manifestFile), manifestBytes); err != nil {
+		return err
+	}
+	return writeSynced(filepath.Join(bb.tmpDir, manifestSumFile), []byte(digest+"\n"))
+}
+
+func (bb *bundleBuilder) discard() {
+	os.RemoveAll(bb.tmpDir)
+}
+
+func writeSynced(path string, buf []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(buf)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// syncDir flushes a directory's entries, so a rename into it survives a yanked stick.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
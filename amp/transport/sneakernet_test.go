@@ -0,0 +1,180 @@
+package transport
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/blobstore"
+	"github.com/art-media-platform/amp.SDK/amp/std"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+func openSneakernet(t *testing.T, opts SneakernetOpts) *Sneakernet {
+	t.Helper()
+	sn, err := OpenSneakernet(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sn.Close() })
+	return sn
+}
+
+func openBlobDir(t *testing.T) *blobstore.Dir {
+	t.Helper()
+	store, err := blobstore.OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func TestSneakernetExchange(t *testing.T) {
+	stick := t.TempDir()
+	planetID := tag.NewID()
+
+	// Sender: three TxMsgs, the first referencing a stored blob.
+	senderBlobs := openBlobDir(t)
+	ref := &amp.BlobRef{PlanetID_0: planetID[0], PlanetID_1: planetID[1]}
+	blobText := []byte("carried across on a stick")
+	if err := senderBlobs.StoreHashed(ref, bytes.NewReader(blobText), nil); err != nil {
+		t.Fatal(err)
+	}
+	sender := openSneakernet(t, SneakernetOpts{Dir: stick, Blobs: senderBlobs})
+	sent := make([]*amp.TxMsg, 3)
+	for seq := range sent {
+		tx := testTx(t, seq)
+		tx.SetPlanetID(planetID)
+		if seq == 0 {
+			tx.Upsert(tag.NewID(), std.Attr.BlobRef.ID, tag.UID{1}, ref)
+		}
+		if err := sender.SendTx(tx); err != nil {
+			t.Fatal(err)
+		}
+		sent[seq] = tx
+	}
+	bundleID, err := sender.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := VerifyBundle(filepath.Join(stick, bundlePrefix+bundleID.Base16()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(manifest.Txs), 3)
+	status.Require(t, len(manifest.Blobs), 1)
+
+	// The sender never imports its own bundle.
+	n, err := sender.Import()
+	status.Require(t, n, 0)
+	status.Require(t, err, nil)
+
+	// Receiver: blobs land before the TxMsgs arrive, in send order.
+	state := t.TempDir()
+	recvBlobs := openBlobDir(t)
+	receiver := openSneakernet(t, SneakernetOpts{Dir: stick, StateDir: state, Blobs: recvBlobs})
+	for seq, want := range sent {
+		got, err := receiver.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, got.TxID(), want.TxID())
+		status.Require(t, txSeq(t, got), seq)
+	}
+	blob, err := recvBlobs.Retrieve(planetID, ref.StorageUID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(blob)
+	blob.Close()
+	status.Require(t, string(got), string(blobText))
+
+	// Plugging the stick in again — same instance or a fresh one on the same
+	// state — delivers nothing twice.
+	n, _ = receiver.Import()
+	status.Require(t, n, 0)
+	receiver.Close()
+	again := openSneakernet(t, SneakernetOpts{Dir: stick, StateDir: state})
+	n, err = again.Import()
+	status.Require(t, n, 0)
+	status.Require(t, err, nil)
+}
+
+func TestSneakernetRedeliversInterrupted(t *testing.T) {
+	stick, state := t.TempDir(), t.TempDir()
+	sender := openSneakernet(t, SneakernetOpts{Dir: stick})
+	for seq := range 3 {
+		if err := sender.SendTx(testTx(t, seq)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := sender.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closed with the bundle only part delivered: it stays out of the ledger.
+	receiver := openSneakernet(t, SneakernetOpts{Dir: stick, StateDir: state})
+	got, err := receiver.RecvTx()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, txSeq(t, got), 0)
+	receiver.Close()
+
+	// The next instance on the same state delivers it again, whole, and
+	// records it only after the last TxMsg is returned.
+	again := openSneakernet(t, SneakernetOpts{Dir: stick, StateDir: state})
+	n, err := again.Import()
+	status.Require(t, n, 3)
+	status.Require(t, err, nil)
+	for seq := range 3 {
+		got, err := again.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, txSeq(t, got), seq)
+	}
+	again.Close()
+	last := openSneakernet(t, SneakernetOpts{Dir: stick, StateDir: state})
+	n, err = last.Import()
+	status.Require(t, n, 0)
+	status.Require(t, err, nil)
+}
+
+func TestSneakernetRejectsTampered(t *testing.T) {
+	stick := t.TempDir()
+	sender := openSneakernet(t, SneakernetOpts{Dir: stick})
+	sender.SendTx(testTx(t, 0))
+	bundleID, err := sender.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(stick, bundlePrefix+bundleID.Base16())
+	txFile := filepath.Join(bundle, "tx", "000000.tx")
+	wire, err := os.ReadFile(txFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wire[len(wire)-1] ^= 1
+	if err := os.WriteFile(txFile, wire, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = VerifyBundle(bundle)
+	status.Require(t, status.GetCode(err), status.Code_DataFailure)
+
+	receiver := openSneakernet(t, SneakernetOpts{Dir: stick, Poll: 10 * time.Millisecond})
+	n, err := receiver.Import()
+	status.Require(t, n, 0)
+	if err == nil {
+		t.Fatal("tampered bundle imported")
+	}
+	receiver.Close()
+	_, err = receiver.RecvTx()
+	status.Require(t, err, status.ErrNotConnected)
+}
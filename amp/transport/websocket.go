@@ -0,0 +1,227 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"golang.org/x/net/websocket"
+)
+
+// WebSocket framing: the client opens with one binary message holding
+// amp.ClientLoginMagic — the same connection-type prefix a TCP client writes —
+// then each binary message in either direction carries exactly one TxMsg in
+// its local-session wire form (TxMsg.MarshalToBuffer / amp.ReadTxMsg).  Text
+// messages, or a message holding anything but one whole TxMsg, end the
+// connection.  Riding plain HTTP(S) upgrades, the transport passes the same
+// reverse proxies and TLS terminators a browser session does.
+
+// WebSocketOpts configures either end of a WebSocket transport.
+type WebSocketOpts struct {
+	Label     string      // Info().Label ("" = "ws:" + the remote address)
+	MaxTxSize int         // largest message accepted (0 = amp.DefaultMaxTxMsgSize)
+	Origin    string      // client: Origin sent on the handshake ("" = the URL's http(s) origin)
+	Header    http.Header // client: extra handshake headers (e.g. proxy credentials)
+
+	// CheckOrigin admits a server handshake; nil admits any origin, since a
+	// session authenticates in-band with its login challenge and a cookie
+	// grants nothing here.
+	CheckOrigin func(req *http.Request) bool
+}
+
+// WebSocket is an amp.Transport over one WebSocket connection.  SendTx may be
+// called concurrently with RecvTx; a read failure or Close on either side
+// closes the transport, after which both report status.ErrNotConnected.
+type WebSocket struct {
+	conn   *websocket.Conn
+	info   amp.TransportInfo
+	sendMu sync.Mutex
+	scrap  []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ amp.Transport = (*WebSocket)(nil)
+
+// binaryMessage is websocket.Message restricted to binary frames.
+var binaryMessage = websocket.Codec{
+	Marshal: func(v any) ([]byte, byte, error) {
+		return v.([]byte), websocket.BinaryFrame, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v any) error {
+		if payloadType != websocket.BinaryFrame {
+			return status.Code_BadRequest.Error("transport: WebSocket message is not binary")
+		}
+		*(v.(*[]byte)) = data
+		return nil
+	},
+}
+
+// DialWebSocket connects to a host's WebSocket endpoint (a ws:// or wss:// URL)
+// and sends the login prefix.
+func DialWebSocket(ctx context.Context, rawURL string, opts WebSocketOpts) (*WebSocket, error) {
+	origin := opts.Origin
+	if origin == "" {
+		target, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, status.Code_BadRequest.Wrap(err)
+		}
+		scheme := "http"
+		if target.Scheme == "wss" {
+			scheme = "https"
+		}
+		origin = scheme + "://" + target.Host
+	}
+	config, err := websocket.NewConfig(rawURL, origin)
+	if err != nil {
+		return nil, status.Code_BadRequest.Wrap(err)
+	}
+	for key, vals := range opts.Header {
+		for _, val := range vals {
+			config.Header.Add(key, val)
+		}
+	}
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, status.Code_NotConnected.Wrap(err)
+	}
+	if opts.Label == "" {
+		opts.Label = "ws:" + rawURL
+	}
+	ws := newWebSocket(conn, opts, false)
+	if err := binaryMessage.Send(conn, []byte(amp.ClientLoginMagic)); err != nil {
+		ws.Close()
+		return nil, status.Code_NotConnected.Wrap(err)
+	}
+	return ws, nil
+}
+
+// NewWebSocketHandler returns an http.Handler, mountable on any net/http mux,
+// that upgrades each request, checks the login prefix, and hands the
+// connection to onConnect as a *WebSocket.  The connection lives until the
+// transport is closed, by either side.
+func NewWebSocketHandler(onConnect func(ws *WebSocket), opts WebSocketOpts) http.Handler {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			if opts.CheckOrigin != nil && !opts.CheckOrigin(req) {
+				return status.Code_AuthFailed.Error("transport: WebSocket origin refused")
+			}
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			label := opts.Label
+			if label == "" {
+				label = "ws:" + conn.Request().RemoteAddr
+			}
+			serverOpts := opts
+			serverOpts.Label = label
+			ws := newWebSocket(conn, serverOpts, true)
+			defer ws.Close()
+
+			var magic []byte
+			if err := binaryMessage.Receive(conn, &magic); err != nil || string(magic) != amp.ClientLoginMagic {
+				return
+			}
+			onConnect(ws)
+			<-ws.closed
+		},
+	}
+}
+
+func newWebSocket(conn *websocket.Conn, opts WebSocketOpts, server bool) *WebSocket {
+	conn.MaxPayloadBytes = opts.MaxTxSize
+	if conn.MaxPayloadBytes <= 0 {
+		conn.MaxPayloadBytes = int(amp.DefaultMaxTxMsgSize)
+	}
+	return &WebSocket{
+		conn: conn,
+		info: amp.TransportInfo{
+			Label:        opts.Label,
+			RequiresAuth: server,
+		},
+		closed: make(chan struct{}),
+	}
+}
+
+// Info implements amp.Transport.
+func (ws *WebSocket) Info() amp.TransportInfo {
+	return ws.info
+}
+
+// SendTx writes tx as one binary message.
+func (ws *WebSocket) SendTx(tx *amp.TxMsg) error {
+	if tx == nil {
+		return status.Code_BadRequest.Error("transport: SendTx: nil TxMsg")
+	}
+	ws.sendMu.Lock()
+	defer ws.sendMu.Unlock()
+	if ws.isClosed() {
+		return status.ErrNotConnected
+	}
+	tx.MarshalToBuffer(&ws.scrap)
+	if err := binaryMessage.Send(ws.conn, ws.scrap); err != nil {
+		err = ws.connErr(err)
+		ws.Close()
+		return err
+	}
+	return nil
+}
+
+// RecvTx blocks until the next TxMsg arrives or the connection ends.
+func (ws *WebSocket) RecvTx() (*amp.TxMsg, error) {
+	var msg []byte
+	if err := binaryMessage.Receive(ws.conn, &msg); err != nil {
+		if errors.Is(err, websocket.ErrFrameTooLarge) {
+			err = status.Code_BadRequest.Errorf("transport: TxMsg exceeds %d bytes", ws.conn.MaxPayloadBytes)
+		} else {
+			err = ws.connErr(err)
+		}
+		ws.Close()
+		return nil, err
+	}
+	src := bytes.NewReader(msg)
+	tx, err := amp.ReadTxMsg(src)
+	if err == nil && src.Len() != 0 {
+		err = status.ErrMalformedTx
+	}
+	if err != nil {
+		ws.Close()
+		return nil, status.Code_MalformedTx.Wrap(err)
+	}
+	return tx, nil
+}
+
+// Close sends a close frame (when still open) and releases the connection.
+func (ws *WebSocket) Close() error {
+	ws.closeOnce.Do(func() {
+		close(ws.closed)
+		ws.conn.Close()
+	})
+	return nil
+}
+
+func (ws *WebSocket) isClosed() bool {
+	select {
+	case <-ws.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// connErr maps a connection error to ErrNotConnected when the stream ended or
+// this side closed it.
+func (ws *WebSocket) connErr(err error) error {
+	if ws.isClosed() || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		return status.ErrNotConnected
+	}
+	return status.Code_NotConnected.Wrap(err)
+}
@@ -0,0 +1,99 @@
+package transport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"golang.org/x/net/websocket"
+)
+
+// echoServer mounts a WebSocket handler that echoes every TxMsg back.
+func echoServer(t *testing.T, opts WebSocketOpts) (*httptest.Server, chan *WebSocket) {
+	t.Helper()
+	accepted := make(chan *WebSocket, 4)
+	mux := http.NewServeMux()
+	mux.Handle("/amp", NewWebSocketHandler(func(ws *WebSocket) {
+		accepted <- ws
+		go func() {
+			for {
+				tx, err := ws.RecvTx()
+				if err != nil {
+					return
+				}
+				if ws.SendTx(tx) != nil {
+					return
+				}
+			}
+		}()
+	}, opts))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, accepted
+}
+
+func TestWebSocketRoundTrip(t *testing.T) {
+	srv, accepted := echoServer(t, WebSocketOpts{})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/amp"
+
+	client, err := DialWebSocket(context.Background(), wsURL, WebSocketOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for seq := range 20 {
+		sent := testTx(t, seq)
+		if err := client.SendTx(sent); err != nil {
+			t.Fatal(err)
+		}
+		got, err := client.RecvTx()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status.Require(t, got.TxID(), sent.TxID())
+		status.Require(t, txSeq(t, got), seq)
+	}
+	server := <-accepted
+	status.Require(t, server.Info().RequiresAuth, true)
+
+	// The server closing ends the client's stream.
+	server.Close()
+	_, err = client.RecvTx()
+	status.Require(t, err, status.ErrNotConnected)
+	status.Require(t, client.SendTx(testTx(t, 0)), status.ErrNotConnected)
+}
+
+func TestWebSocketRejects(t *testing.T) {
+	srv, accepted := echoServer(t, WebSocketOpts{MaxTxSize: 512})
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/amp"
+
+	// A connection without the login prefix is dropped before onConnect.
+	conn, err := websocket.Dial(wsURL, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryMessage.Send(conn, []byte("AMPV"))
+	var reply []byte
+	if err := binaryMessage.Receive(conn, &reply); err == nil {
+		t.Fatal("connection without ClientLoginMagic stayed open")
+	}
+	conn.Close()
+	status.Require(t, len(accepted), 0)
+
+	// An oversized TxMsg ends the session.
+	client, err := DialWebSocket(context.Background(), wsURL, WebSocketOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	big := amp.TxNew()
+	big.Upsert(tag.UID{0x7e, 0x57}, tag.UID{0xa7, 0x7e}, tag.UID{0, 1}, &amp.Tag{Text: strings.Repeat("x", 2048)})
+	client.SendTx(big)
+	if _, err := client.RecvTx(); status.GetCode(err) != status.Code_NotConnected {
+		t.Fatalf("oversized TxMsg: got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package amp
+
+import (
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// goldenRangeHash pins the canonical RangeHash derivation — every TxJournal
+// (SDK reference stores and the vault's own) must fold entries identically or
+// Merkle bisection never converges across implementations.
+//
+//	Blake2s_256( ID_a ‖ Blake2s_256("alpha") ‖ ID_b ‖ Blake2s_256("beta") )[:16]
+var goldenRangeHash = tag.UID{0xbceaa57c0fc900d3, 0x801bb39a6a924c5c}
+
+func TestTxRangeDigestGolden(t *testing.T) {
+	digest := NewTxRangeDigest()
+	if !digest.Sum().IsNil() {
+		t.Fatal("empty digest must be the nil UID")
+	}
+	digest.Add(tag.UID{0x1122334455667788, 0x99aabbccddeeff00}, []byte("alpha"))
+	digest.Add(tag.UID{0x1122334455667789, 0x0000000000000001}, []byte("beta"))
+
+	if digest.Count() != 2 {
+		t.Fatalf("Count: got %d, want 2", digest.Count())
+	}
+	if got := digest.Sum(); got != goldenRangeHash {
+		t.Fatalf("RangeHash drift: got %#x %#x, want %#x %#x", got[0], got[1], goldenRangeHash[0], goldenRangeHash[1])
+	}
+}
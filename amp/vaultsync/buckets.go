@@ -0,0 +1,82 @@
+package vaultsync
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// ── Combined per-day bucket fingerprint ───────────────────────────────────────
+//
+// A Combined SyncRangeOffer folds the RangeHash of each non-empty UTC day bucket
+// over [Start, End] into one fingerprint, so two converged journals agree in a
+// single probe however long their history.  Peers must fold identically:
+//
+//	bucket(day)  = TxTimeIDs whose Unix seconds fall in [day·86400, (day+1)·86400), clipped to [Start, End]
+//	for each non-empty bucket, ascending:  clipped start (16, big-endian) ‖ RangeHash(bucket) (16)
+//	Combined     = H(buckets...)[:16]   — nil UID when no bucket holds an entry
+//
+// H is amp.TxRangeHashKit.  A bucket is non-empty when it holds a live entry.
+
+// DayBucketSeconds is the span of one Combined fingerprint bucket.
+const DayBucketSeconds = 24 * 60 * 60
+
+// dayOf returns the day bucket holding txTimeID.
+func dayOf(txTimeID tag.UID) uint64 {
+	return uint64(txTimeID.Unix()) / DayBucketSeconds
+}
+
+// dayBounds returns the inclusive TxTimeID bounds of a day bucket, clipped to [start, end].
+func dayBounds(day uint64, start, end tag.UID) (lo, hi tag.UID) {
+	lo = tag.UID{day * DayBucketSeconds << 16, 0}
+	hi = tag.UID{(day+1)*DayBucketSeconds<<16 - 1, math.MaxUint64}
+	if lo.CompareTo(start) < 0 {
+		lo = start
+	}
+	if hi.CompareTo(end) > 0 {
+		hi = end
+	}
+	return lo, hi
+}
+
+// rangeDays returns the day buckets holding a live entry in [start, end], ascending.
+func (e *Engine) rangeDays(planetID, start, end tag.UID) ([]uint64, error) {
+	var days []uint64
+	err := readRange(e.journal, planetID, start, end, func(txTimeID tag.UID, raw []byte) {
+		if day := dayOf(txTimeID); len(days) == 0 || days[len(days)-1] != day {
+			days = append(days, day)
+		}
+	})
+	return days, err
+}
+
+// combinedHash returns the Combined per-day bucket fingerprint over [start, end].
+func (e *Engine) combinedHash(planetID, start, end tag.UID) (tag.UID, error) {
+	days, err := e.rangeDays(planetID, start, end)
+	if err != nil || len(days) == 0 {
+		return tag.UID{}, err
+	}
+	kit, err := safe.NewHashKit(amp.TxRangeHashKit)
+	if err != nil {
+		return tag.UID{}, err
+	}
+	var scrap []byte
+	for _, day := range days {
+		lo, hi := dayBounds(day, start, end)
+		bucket, err := e.journal.RangeHash(planetID, lo, hi)
+		if err != nil {
+			return tag.UID{}, err
+		}
+		scrap = lo.AppendTo(scrap[:0])
+		scrap = bucket.AppendTo(scrap)
+		kit.Hasher.Write(scrap)
+	}
+	digest := kit.Hasher.Sum(nil)
+	return tag.UID{
+		binary.BigEndian.Uint64(digest[0:8]),
+		binary.BigEndian.Uint64(digest[8:16]),
+	}, nil
+}
@@ -0,0 +1,78 @@
+package vaultsync
+
+import (
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Converge syncs planetIDs between journals a and b over an in-process
+// loopback, delivering traffic until both engines fall quiet, and returns each
+// side's Stats.  It is the reference harness for testing a journal or a
+// protocol change without a transport.
+func Converge(a, b amp.TxJournal, planetIDs []tag.UID, opts Opts) (Stats, Stats, error) {
+	toA, toB := &loopQueue{}, &loopQueue{}
+	engineA := NewEngine(a, toB, opts)
+	engineB := NewEngine(b, toA, opts)
+
+	if err := engineA.Watch(planetIDs...); err != nil {
+		return Stats{}, Stats{}, err
+	}
+	if err := engineB.Watch(planetIDs...); err != nil {
+		return Stats{}, Stats{}, err
+	}
+	for toA.pending() || toB.pending() {
+		if err := toA.deliver(engineA); err != nil {
+			return engineA.Stats(), engineB.Stats(), err
+		}
+		if err := toB.deliver(engineB); err != nil {
+			return engineA.Stats(), engineB.Stats(), err
+		}
+	}
+	return engineA.Stats(), engineB.Stats(), nil
+}
+
+// loopFrame is one queued message or entry.
+type loopFrame struct {
+	msg      *amp.SyncMsg
+	planetID tag.UID
+	txTimeID tag.UID
+	raw      []byte
+}
+
+// loopQueue is a Pipe that queues frames for in-order delivery to an Engine.
+type loopQueue struct {
+	frames []loopFrame
+}
+
+func (q *loopQueue) SendMsg(msg *amp.SyncMsg) error {
+	q.frames = append(q.frames, loopFrame{msg: msg})
+	return nil
+}
+
+func (q *loopQueue) SendEntry(planetID, txTimeID tag.UID, raw []byte) error {
+	q.frames = append(q.frames, loopFrame{planetID: planetID, txTimeID: txTimeID, raw: raw})
+	return nil
+}
+
+func (q *loopQueue) pending() bool {
+	return len(q.frames) > 0
+}
+
+// deliver hands the queued frames to engine; frames it sends in reply queue
+// on the opposite side.
+func (q *loopQueue) deliver(engine *Engine) error {
+	frames := q.frames
+	q.frames = nil
+	for _, frame := range frames {
+		var err error
+		if frame.msg != nil {
+			err = engine.HandleMsg(frame.msg)
+		} else {
+			err = engine.HandleEntry(frame.planetID, frame.txTimeID, frame.raw)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
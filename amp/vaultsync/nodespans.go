@@ -0,0 +1,328 @@
+package vaultsync
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// DefaultMaxNodeSpans caps the spans in one SyncNodeSpans answer.
+const DefaultMaxNodeSpans = 256
+
+// NodeIndex maps each NodeID of a planet to the coalesced TxTimeID spans of
+// the journal entries that touch it — the 'N' index a peer answers
+// SyncNodeSpanRequest from, so a client pinning one channel pulls just the
+// entries behind that subtree rather than the whole planet.
+//
+// A span covers a run of consecutive journal entries that each hold an op on
+// the node; an entry not touching the node closes the span.  The index
+// follows its journal incrementally: a query reads only the entries past the
+// indexed mark, and only when the journal's HighWater has moved past it.  An
+// entry landing below the mark is not seen that way — its writer reports it
+// through Appended (an Engine with Opts.Nodes reports each entry it appends),
+// and the index then patches only the spans around it.
+//
+// The index reads through ReadSince, so an entry already quarantined when it
+// is reached is never indexed, and spans run across it; an entry quarantined
+// later stays indexed.  Either way a request over the span simply returns the
+// entries the peer still serves.
+type NodeIndex struct {
+	journal amp.TxJournal
+	crypto  amp.CryptoProvider
+
+	mu      sync.Mutex
+	planets map[tag.UID]*nodePlanet
+}
+
+// nodePlanet is one planet's index state.
+type nodePlanet struct {
+	entries   []tag.UID            // TxTimeIDs of the indexed entries, ascending
+	backfill  []tag.UID            // reported appends below the indexed mark, not yet indexed
+	lastNodes map[tag.UID]struct{} // NodeIDs touched by the last indexed entry
+	spans     map[tag.UID][]Span   // NodeID → coalesced spans, ascending
+}
+
+// NewNodeIndex returns an index over journal.  crypto opens sealed entries (see
+// amp.OpenTxSansVerify); nil reads the unsealed local-session format only.
+func NewNodeIndex(journal amp.TxJournal, crypto amp.CryptoProvider) *NodeIndex {
+	return &NodeIndex{
+		journal: journal,
+		crypto:  crypto,
+		planets: make(map[tag.UID]*nodePlanet),
+	}
+}
+
+// Appended reports an entry appended to planetID's journal.  Entries past the
+// indexed mark need no report; one below it is indexed on the next query.
+func (idx *NodeIndex) Appended(planetID, txTimeID tag.UID) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	planet := idx.planets[planetID]
+	if planet == nil || txTimeID.CompareTo(planet.indexed()) >= 0 {
+		return
+	}
+	planet.backfill = append(planet.backfill, txTimeID)
+}
+
+// Spans returns the coalesced TxTimeID spans of the entries touching nodeID,
+// ascending, after catching the index up with the journal.
+func (idx *NodeIndex) Spans(planetID, nodeID tag.UID) ([]Span, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	planet, err := idx.update(planetID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(planet.spans[nodeID]), nil
+}
+
+// Answer builds the SyncNodeSpans reply to req, holding at most maxSpans spans
+// (≤ 0 means DefaultMaxNodeSpans).  A truncated answer keeps the oldest spans
+// and sets Complete to false.
+func (idx *NodeIndex) Answer(req *amp.SyncNodeSpanRequest, maxSpans int) (*amp.SyncNodeSpans, error) {
+	if maxSpans <= 0 {
+		maxSpans = DefaultMaxNodeSpans
+	}
+	planetID := tag.UID{req.PlanetID_0, req.PlanetID_1}
+	nodeID := tag.UID{req.NodeID_0, req.NodeID_1}
+	spans, err := idx.Spans(planetID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	answer := &amp.SyncNodeSpans{
+		PlanetID_0: req.PlanetID_0,
+		PlanetID_1: req.PlanetID_1,
+		NodeID_0:   req.NodeID_0,
+		NodeID_1:   req.NodeID_1,
+		Complete:   len(spans) <= maxSpans,
+	}
+	for _, span := range spans[:min(len(spans), maxSpans)] {
+		answer.Spans = append(answer.Spans, span.UIDRange())
+	}
+	return answer, nil
+}
+
+// update indexes the entries appended to planetID since the last call.
+func (idx *NodeIndex) update(planetID tag.UID) (*nodePlanet, error) {
+	planet := idx.planets[planetID]
+	if planet == nil {
+		planet = &nodePlanet{spans: make(map[tag.UID][]Span)}
+		idx.planets[planetID] = planet
+	}
+	err := idx.insertBackfill(planetID, planet)
+	if err == nil {
+		err = idx.readTail(planetID, planet)
+	}
+	if err != nil {
+		delete(idx.planets, planetID) // partially indexed; rebuild on the next call
+		return nil, err
+	}
+	return planet, nil
+}
+
+// readTail indexes the entries past the indexed mark, if the journal's
+// HighWater says there are any.
+func (idx *NodeIndex) readTail(planetID tag.UID, planet *nodePlanet) error {
+	indexed := planet.indexed()
+	high, err := idx.journal.HighWater(planetID)
+	if err != nil {
+		return err
+	}
+	if high.CompareTo(indexed) <= 0 {
+		return nil
+	}
+	var openErr error
+	err = idx.journal.ReadSince(planetID, indexed, func(txTimeID tag.UID, raw []byte) bool {
+		tx, err := amp.OpenTxSansVerify(raw, idx.crypto)
+		if err != nil {
+			openErr = status.Code_DecryptFailed.Errorf("vaultsync: TxMsg %v: %v", txTimeID, err)
+			return false
+		}
+		planet.add(txTimeID, tx)
+		return true
+	})
+	if err == nil {
+		err = openErr
+	}
+	return err
+}
+
+// insertBackfill indexes the reported entries below the indexed mark, each
+// patching just the spans around it.
+func (idx *NodeIndex) insertBackfill(planetID tag.UID, planet *nodePlanet) error {
+	backfill := planet.backfill
+	planet.backfill = nil
+	slices.SortFunc(backfill, tag.UID.CompareTo)
+	for _, txTimeID := range slices.Compact(backfill) {
+		at, indexed := slices.BinarySearchFunc(planet.entries, txTimeID, tag.UID.CompareTo)
+		if indexed {
+			continue // a duplicate the journal absorbed
+		}
+		nodes, found, err := idx.nodesAt(planetID, txTimeID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue // quarantined, so not indexed
+		}
+		var prev tag.UID
+		if at > 0 {
+			prev = planet.entries[at-1]
+		}
+		next := planet.entries[at]
+		bridging, err := idx.bridgeCandidates(planetID, prev, next)
+		if err != nil {
+			return err
+		}
+		planet.insert(txTimeID, nodes, prev, next, bridging)
+		planet.entries = slices.Insert(planet.entries, at, txTimeID)
+	}
+	return nil
+}
+
+// bridgeCandidates returns the nodes a span running from prev to next could
+// belong to — those a neighbor still served by the journal touches — or nil
+// when neither is served (both quarantined since indexing), meaning any node.
+func (idx *NodeIndex) bridgeCandidates(planetID, prev, next tag.UID) (map[tag.UID]struct{}, error) {
+	for _, neighbor := range []tag.UID{prev, next} {
+		if neighbor.IsNil() {
+			continue
+		}
+		nodes, found, err := idx.nodesAt(planetID, neighbor)
+		if err != nil || found {
+			return nodes, err
+		}
+	}
+	return nil, nil
+}
+
+// nodesAt reads the journal entry at txTimeID and returns the NodeIDs it
+// touches; found is false if ReadSince does not serve it.
+func (idx *NodeIndex) nodesAt(planetID, txTimeID tag.UID) (nodes map[tag.UID]struct{}, found bool, err error) {
+	after := txTimeID
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	var openErr error
+	err = idx.journal.ReadSince(planetID, after, func(entryID tag.UID, raw []byte) bool {
+		if entryID != txTimeID {
+			return false
+		}
+		tx, err := amp.OpenTxSansVerify(raw, idx.crypto)
+		if err != nil {
+			openErr = status.Code_DecryptFailed.Errorf("vaultsync: TxMsg %v: %v", txTimeID, err)
+			return false
+		}
+		nodes, found = touched(tx), true
+		return false
+	})
+	if err == nil {
+		err = openErr
+	}
+	return nodes, found, err
+}
+
+// indexed returns the TxTimeID of the last indexed entry.
+func (planet *nodePlanet) indexed() tag.UID {
+	if len(planet.entries) == 0 {
+		return tag.UID{}
+	}
+	return planet.entries[len(planet.entries)-1]
+}
+
+// add indexes one entry past the indexed mark, extending the span of each
+// node the previous entry also touched.
+func (planet *nodePlanet) add(txTimeID tag.UID, tx *amp.TxMsg) {
+	nodes := touched(tx)
+	for nodeID := range nodes {
+		spans := planet.spans[nodeID]
+		if _, running := planet.lastNodes[nodeID]; running && len(spans) > 0 {
+			spans[len(spans)-1].End = txTimeID
+		} else {
+			planet.spans[nodeID] = append(spans, Span{txTimeID, txTimeID})
+		}
+	}
+	planet.entries = append(planet.entries, txTimeID)
+	planet.lastNodes = nodes
+}
+
+// insert indexes one entry landing between the indexed entries prev and next
+// (prev is nil when it lands first): a span running from prev to next splits
+// around it unless it touches that node, and otherwise it joins the spans
+// ending at prev or starting at next.  Whether a node touches prev or next is
+// read from its spans rather than the journal, which no longer serves an
+// entry quarantined since it was indexed.  bridging limits the nodes whose
+// span may run from prev to next (nil: any node).
+func (planet *nodePlanet) insert(txTimeID tag.UID, nodes map[tag.UID]struct{}, prev, next tag.UID, bridging map[tag.UID]struct{}) {
+	split := func(nodeID tag.UID) {
+		if _, touches := nodes[nodeID]; touches || !planet.covers(nodeID, prev) || !planet.covers(nodeID, next) {
+			return
+		}
+		spans := planet.spans[nodeID]
+		i := spanHolding(spans, prev)
+		head, tail := Span{spans[i].Start, prev}, Span{next, spans[i].End}
+		planet.spans[nodeID] = slices.Replace(spans, i, i+1, head, tail)
+	}
+	if bridging == nil {
+		for nodeID := range planet.spans {
+			split(nodeID)
+		}
+	} else {
+		for nodeID := range bridging {
+			split(nodeID)
+		}
+	}
+
+	for nodeID := range nodes {
+		spans := planet.spans[nodeID]
+		afterPrev := planet.covers(nodeID, prev)
+		beforeNext := planet.covers(nodeID, next)
+		switch {
+		case afterPrev && beforeNext: // already inside a span
+		case afterPrev:
+			spans[spanHolding(spans, prev)].End = txTimeID
+		case beforeNext:
+			spans[spanHolding(spans, next)].Start = txTimeID
+		default:
+			i, _ := slices.BinarySearchFunc(spans, txTimeID, func(span Span, target tag.UID) int {
+				return span.Start.CompareTo(target)
+			})
+			planet.spans[nodeID] = slices.Insert(spans, i, Span{txTimeID, txTimeID})
+		}
+	}
+}
+
+// covers reports whether the indexed entry txTimeID touches nodeID, i.e. lies
+// within one of its spans.
+func (planet *nodePlanet) covers(nodeID, txTimeID tag.UID) bool {
+	if txTimeID.IsNil() {
+		return false
+	}
+	spans := planet.spans[nodeID]
+	i := spanHolding(spans, txTimeID)
+	return i < len(spans) && spans[i].Contains(txTimeID)
+}
+
+// spanHolding returns the index of the first span in spans ending at or after
+// txTimeID — the one containing it, if any.
+func spanHolding(spans []Span, txTimeID tag.UID) int {
+	i, _ := slices.BinarySearchFunc(spans, txTimeID, func(span Span, target tag.UID) int {
+		return span.End.CompareTo(target)
+	})
+	return i
+}
+
+// touched returns the NodeIDs tx holds a persisted op on.
+func touched(tx *amp.TxMsg) map[tag.UID]struct{} {
+	nodes := make(map[tag.UID]struct{})
+	for _, op := range tx.Ops {
+		if op.Flags&amp.TxOpFlags_MetaOp != 0 {
+			continue
+		}
+		nodes[op.Addr.NodeID] = struct{}{}
+	}
+	return nodes
+}
@@ -0,0 +1,189 @@
+package vaultsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// appendTouching appends an entry at the given second with one op on each of nodeIDs.
+func appendTouching(t *testing.T, j amp.TxJournal, planetID tag.UID, seconds int, nodeIDs ...tag.UID) {
+	t.Helper()
+	txID := txAt(seconds)
+	tx := amp.TxNew()
+	tx.SetTxID(txID)
+	tx.SetPlanetID(planetID)
+	for _, nodeID := range nodeIDs {
+		tx.Upsert(nodeID, tag.UID{0x5e, 0x11}, txID, &amp.Tag{Text: txID.String()})
+	}
+	var raw []byte
+	tx.MarshalToBuffer(&raw)
+	if err := j.Append(planetID, txID, raw); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNodeIndex(t *testing.T) {
+	planetID, nodeA, nodeB := tag.NewID(), tag.NewID(), tag.NewID()
+	j := newJournal(t)
+	for _, sec := range []int{10, 20, 30, 90, 100} {
+		appendTouching(t, j, planetID, sec, nodeA)
+	}
+	appendTouching(t, j, planetID, 40, nodeB)
+	appendTouching(t, j, planetID, 50, nodeB)
+	appendTouching(t, j, planetID, 60, nodeA, nodeB)
+	appendTouching(t, j, planetID, 70, tag.NewID())
+
+	idx := NewNodeIndex(j, nil)
+	spans, err := idx.Spans(planetID, nodeA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSpans(t, "node A", spans, spanAt(10, 30), spanAt(60, 60), spanAt(90, 100))
+	spans, _ = idx.Spans(planetID, nodeB)
+	requireSpans(t, "node B", spans, spanAt(40, 60))
+
+	// Appends past the high-water mark extend the index incrementally.
+	appendTouching(t, j, planetID, 110, nodeA)
+	spans, _ = idx.Spans(planetID, nodeA)
+	requireSpans(t, "appended", spans, spanAt(10, 30), spanAt(60, 60), spanAt(90, 110))
+
+	// A backfilled entry beneath the high-water mark, once reported, splits
+	// node B's span.
+	appendTouching(t, j, planetID, 45, nodeA)
+	idx.Appended(planetID, txAt(45))
+	spans, _ = idx.Spans(planetID, nodeB)
+	requireSpans(t, "backfill B", spans, spanAt(40, 40), spanAt(50, 60))
+	spans, _ = idx.Spans(planetID, nodeA)
+	requireSpans(t, "backfill A", spans, spanAt(10, 30), spanAt(45, 45), spanAt(60, 60), spanAt(90, 110))
+
+	// A capped answer keeps the oldest spans and flags truncation.
+	req := &amp.SyncNodeSpanRequest{PlanetID_0: planetID[0], PlanetID_1: planetID[1], NodeID_0: nodeA[0], NodeID_1: nodeA[1]}
+	answer, err := idx.Answer(req, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, answer.Complete, false)
+	status.Require(t, len(answer.Spans), 2)
+	status.Require(t, SpanOf(answer.Spans[1]), spanAt(45, 45))
+	answer, _ = idx.Answer(req, 0)
+	status.Require(t, answer.Complete, true)
+	status.Require(t, len(answer.Spans), 4)
+}
+
+func TestNodeIndexBackfill(t *testing.T) {
+	planetID := tag.NewID()
+	nodes := []tag.UID{tag.NewID(), tag.NewID(), tag.NewID()}
+	j := newJournal(t)
+	touching := func(sec int) []tag.UID {
+		var touched []tag.UID
+		for i, nodeID := range nodes {
+			if (sec/3)%(i+2) != 0 {
+				touched = append(touched, nodeID)
+			}
+		}
+		return touched
+	}
+	for sec := 30; sec < 60; sec++ {
+		appendTouching(t, j, planetID, sec, touching(sec)...)
+	}
+	idx := NewNodeIndex(j, nil)
+	if _, err := idx.Spans(planetID, nodes[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reported backfills — before the first entry, between entries, and
+	// repeated — patch the index to match one built from scratch.
+	for _, sec := range []int{5, 29, 12, 61, 0, 29, 1, 2, 3, 4, 17, 13, 14, 15, 16} {
+		appendTouching(t, j, planetID, sec, touching(sec)...)
+		idx.Appended(planetID, txAt(sec))
+	}
+	fresh := NewNodeIndex(j, nil)
+	for _, nodeID := range nodes {
+		got, err := idx.Spans(planetID, nodeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := fresh.Spans(planetID, nodeID)
+		requireSpans(t, nodeID.String(), got, want...)
+	}
+}
+
+// TestNodeIndexQuarantinedNeighbor backfills beside indexed entries
+// quarantined since: their spans must still be judged from the index.
+func TestNodeIndexQuarantinedNeighbor(t *testing.T) {
+	planetID, node, other := tag.NewID(), tag.NewID(), tag.NewID()
+	j := newJournal(t)
+	for _, sec := range []int{10, 30, 40, 50, 60, 70, 80} {
+		appendTouching(t, j, planetID, sec, node)
+	}
+	idx := NewNodeIndex(j, nil)
+	if _, err := idx.Spans(planetID, node); err != nil {
+		t.Fatal(err)
+	}
+	for _, sec := range []int{30, 60} {
+		if err := j.Quarantine(planetID, txAt(sec), time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Between 10 and quarantined 30, an entry on another node splits the span
+	// without dropping 40..80 behind 30.
+	appendTouching(t, j, planetID, 20, other)
+	idx.Appended(planetID, txAt(20))
+	spans, _ := idx.Spans(planetID, node)
+	requireSpans(t, "next quarantined", spans, spanAt(10, 10), spanAt(30, 80))
+
+	// After quarantined 60, an entry on the node keeps 30..60 in its span.
+	appendTouching(t, j, planetID, 65, node)
+	idx.Appended(planetID, txAt(65))
+	spans, _ = idx.Spans(planetID, node)
+	requireSpans(t, "prev quarantined", spans, spanAt(10, 10), spanAt(30, 80))
+	spans, _ = idx.Spans(planetID, other)
+	requireSpans(t, "other", spans, spanAt(20, 20))
+}
+
+func TestPullNode(t *testing.T) {
+	planetID, channelID := tag.NewID(), tag.NewID()
+	server, client := newJournal(t), newJournal(t)
+	for i := range 100 {
+		if i%10 < 3 {
+			appendTouching(t, server, planetID, i, channelID)
+		} else {
+			appendTouching(t, server, planetID, i, tag.NewID())
+		}
+	}
+
+	toServer, toClient := &loopQueue{}, &loopQueue{}
+	serverEngine := NewEngine(server, toClient, Opts{Nodes: NewNodeIndex(server, nil)})
+	var answered *amp.SyncNodeSpans
+	clientEngine := NewEngine(client, toServer, Opts{
+		OnNodeSpans: func(answer *amp.SyncNodeSpans) { answered = answer },
+	})
+	if err := serverEngine.Watch(planetID); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientEngine.PullNode(planetID, channelID); err != nil {
+		t.Fatal(err)
+	}
+	for toServer.pending() || toClient.pending() {
+		if err := toServer.deliver(serverEngine); err != nil {
+			t.Fatal(err)
+		}
+		if err := toClient.deliver(clientEngine); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The client pulled exactly the channel's entries, one request per span.
+	if answered == nil || !answered.Complete {
+		t.Fatal("expected a Complete SyncNodeSpans answer")
+	}
+	status.Require(t, len(answered.Spans), 10)
+	status.Require(t, clientEngine.Stats().RequestsSent, int64(10))
+	status.Require(t, countEntries(t, client, planetID), 30)
+	status.Require(t, serverEngine.Stats().EntriesSent, int64(30))
+}
@@ -0,0 +1,169 @@
+package vaultsync
+
+import (
+	"slices"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// RetentionPolicy is how much of a planet's journal the local node keeps.
+type RetentionPolicy struct {
+	Mode   amp.ArchiveMode // Archive keeps every entry; Suffix keeps a recent window
+	Window time.Duration   // Suffix: keep TxTimeIDs newer than now − Window (≤ 0 keeps only Pinned)
+	Pinned []Span          // Suffix: ranges kept regardless of Window
+}
+
+// RangeFetch is one range to request from one peer.
+type RangeFetch struct {
+	PeerID tag.UID
+	Span
+}
+
+// Request returns the SyncRangeRequest that pulls this fetch.
+func (fetch RangeFetch) Request(planetID tag.UID) *amp.SyncRangeRequest {
+	after := fetch.Start
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	return &amp.SyncRangeRequest{
+		PlanetID_0: planetID[0],
+		PlanetID_1: planetID[1],
+		After_0:    after[0],
+		After_1:    after[1],
+		End_0:      fetch.End[0],
+		End_1:      fetch.End[1],
+	}
+}
+
+// ReplicationPlan is what a node should fetch, evict, and advertise for one
+// planet to meet its RetentionPolicy given what its peers hold.
+type ReplicationPlan struct {
+	HoldSince   tag.UID      // start of the retained suffix (nil for Archive)
+	Fetch       []RangeFetch // wanted ranges the node lacks, each from a peer holding it
+	Unavailable []Span       // wanted ranges no advertised peer holds
+	Evict       []Span       // held ranges outside the policy that another peer also holds
+	Stranded    []Span       // held ranges outside the policy kept because no other peer holds them
+	Held        []Span       // ranges below HoldSince still held once Evict applies (pins and Stranded)
+}
+
+// Holdings returns the TxTimeID ranges a SyncPlanetStatus advertises.  An
+// Archive (or pre-manifest) peer holds everything up to its high-water mark;
+// a Suffix peer holds [HoldSince, HighWater] plus its Held segments.
+func Holdings(status *amp.SyncPlanetStatus) []Span {
+	high := tag.UID{status.HighWater_0, status.HighWater_1}
+	if status.ArchiveMode != amp.ArchiveMode_Suffix {
+		if high.IsNil() {
+			return nil
+		}
+		return []Span{{tag.UID{}, high}}
+	}
+	spans := []Span{{tag.UID{status.HoldSince_0, status.HoldSince_1}, high}}
+	for _, rng := range status.Held {
+		spans = append(spans, SpanOf(rng))
+	}
+	return unionSpans(spans...)
+}
+
+// PlanReplication works out a node's replication for one planet from its own
+// advertised status, its peers' statuses (keyed by peer ID), and its policy.
+//
+// Wanted ranges run up to the highest advertised high-water mark; each range
+// the node lacks is fetched from the first peer holding it — Archive peers
+// before Suffix peers, then by ascending peer ID — so the plan is
+// deterministic.  Under Suffix, a held range outside the window and pins is
+// evicted only where some peer also holds it: a range held by no other
+// advertised peer is never evicted, only reported Stranded.
+func PlanReplication(local *amp.SyncPlanetStatus, peers map[tag.UID]*amp.SyncPlanetStatus, policy RetentionPolicy, now time.Time) *ReplicationPlan {
+	plan := &ReplicationPlan{}
+	held := spanSet(Holdings(local))
+
+	head := tag.UID{local.HighWater_0, local.HighWater_1}
+	peerIDs := make([]tag.UID, 0, len(peers))
+	for peerID, peer := range peers {
+		peerIDs = append(peerIDs, peerID)
+		if high := (tag.UID{peer.HighWater_0, peer.HighWater_1}); high.CompareTo(head) > 0 {
+			head = high
+		}
+	}
+	slices.SortFunc(peerIDs, func(a, b tag.UID) int {
+		archiveA := peers[a].ArchiveMode != amp.ArchiveMode_Suffix
+		archiveB := peers[b].ArchiveMode != amp.ArchiveMode_Suffix
+		if archiveA != archiveB {
+			if archiveA {
+				return -1
+			}
+			return 1
+		}
+		return a.CompareTo(b)
+	})
+
+	var want spanSet
+	if policy.Mode == amp.ArchiveMode_Suffix {
+		plan.HoldSince = head
+		if policy.Window > 0 {
+			plan.HoldSince = tag.UID_FromTime(now.Add(-policy.Window))
+		}
+		want = unionSpans(append(slices.Clone(policy.Pinned), Span{plan.HoldSince, head})...)
+	} else if !head.IsNil() {
+		want = spanSet{{tag.UID{}, head}}
+	}
+
+	// Fetch what is wanted and missing, peer by peer in preference order.
+	missing := want.subtract(held)
+	for _, peerID := range peerIDs {
+		if len(missing) == 0 {
+			break
+		}
+		peerHeld := spanSet(Holdings(peers[peerID]))
+		for _, span := range missing.intersect(peerHeld) {
+			plan.Fetch = append(plan.Fetch, RangeFetch{PeerID: peerID, Span: span})
+		}
+		missing = missing.subtract(peerHeld)
+	}
+	plan.Unavailable = missing
+	slices.SortFunc(plan.Fetch, func(a, b RangeFetch) int {
+		return a.Start.CompareTo(b.Start)
+	})
+	if policy.Mode != amp.ArchiveMode_Suffix {
+		return plan
+	}
+
+	// Evict only what another peer also holds.
+	var elsewhere spanSet
+	for _, peer := range peers {
+		elsewhere = elsewhere.union(Holdings(peer))
+	}
+	surplus := held.subtract(want)
+	plan.Evict = surplus.intersect(elsewhere)
+	plan.Stranded = surplus.subtract(elsewhere)
+
+	below := spanSet{}
+	if floor := plan.HoldSince; floor.Decrement() {
+		below = spanSet{{tag.UID{}, floor}}
+	}
+	plan.Held = held.subtract(plan.Evict).intersect(below)
+	return plan
+}
+
+// Status returns the SyncPlanetStatus the node advertises once the plan's
+// evictions apply: its retained suffix from HoldSince, with pins and stranded
+// ranges below it listed as Held.
+func (plan *ReplicationPlan) Status(planetID, highWater tag.UID) *amp.SyncPlanetStatus {
+	status := &amp.SyncPlanetStatus{
+		PlanetID_0:  planetID[0],
+		PlanetID_1:  planetID[1],
+		HighWater_0: highWater[0],
+		HighWater_1: highWater[1],
+		HoldSince_0: plan.HoldSince[0],
+		HoldSince_1: plan.HoldSince[1],
+	}
+	if !plan.HoldSince.IsNil() {
+		status.ArchiveMode = amp.ArchiveMode_Suffix
+	}
+	for _, span := range plan.Held {
+		status.Held = append(status.Held, span.UIDRange())
+	}
+	return status
+}
@@ -0,0 +1,148 @@
+package vaultsync
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+func suffixStatus(holdSince, highWater tag.UID, held ...Span) *amp.SyncPlanetStatus {
+	status := &amp.SyncPlanetStatus{
+		HighWater_0: highWater[0],
+		HighWater_1: highWater[1],
+		HoldSince_0: holdSince[0],
+		HoldSince_1: holdSince[1],
+		ArchiveMode: amp.ArchiveMode_Suffix,
+	}
+	for _, span := range held {
+		status.Held = append(status.Held, span.UIDRange())
+	}
+	return status
+}
+
+func archiveStatus(highWater tag.UID) *amp.SyncPlanetStatus {
+	return &amp.SyncPlanetStatus{
+		HighWater_0: highWater[0],
+		HighWater_1: highWater[1],
+	}
+}
+
+func spanAt(start, end int) Span {
+	return Span{txAt(start), txAt(end)}
+}
+
+func requireSpans(t *testing.T, what string, got []Span, want ...Span) {
+	t.Helper()
+	if !slices.Equal(got, want) {
+		t.Fatalf("%s: got %v, want %v", what, got, want)
+	}
+}
+
+func TestSpanSet(t *testing.T) {
+	set := unionSpans(spanAt(30, 40), spanAt(10, 20), spanAt(15, 25), Span{txAt(50), txAt(45)})
+	requireSpans(t, "union", set, spanAt(10, 25), spanAt(30, 40))
+
+	// Adjacent spans coalesce.
+	after25, before30 := txAt(25), txAt(30)
+	after25.Increment()
+	before30.Decrement()
+	requireSpans(t, "adjacent", set.union(spanSet{{after25, before30}}), spanAt(10, 40))
+
+	requireSpans(t, "intersect", set.intersect(spanSet{spanAt(20, 35)}), spanAt(20, 25), spanAt(30, 35))
+
+	cut := set.subtract(spanSet{spanAt(12, 14), spanAt(22, 32)})
+	before12, after14, before22, after32 := txAt(12), txAt(14), txAt(22), txAt(32)
+	before12.Decrement()
+	after14.Increment()
+	before22.Decrement()
+	after32.Increment()
+	requireSpans(t, "subtract", cut, Span{txAt(10), before12}, Span{after14, before22}, Span{after32, txAt(40)})
+	requireSpans(t, "subtract all", set.subtract(spanSet{{tag.UID{}, tag.MaxID()}}))
+}
+
+func TestPlanFetch(t *testing.T) {
+	now := testEpoch.Add(100 * time.Second)
+	archiveID, suffixID := tag.UID{0, 2}, tag.UID{0, 1}
+	peers := map[tag.UID]*amp.SyncPlanetStatus{
+		suffixID:  suffixStatus(txAt(50), txAt(100)),
+		archiveID: archiveStatus(txAt(90)),
+	}
+
+	// A fresh Archive node pulls history from the Archive peer and the newest
+	// suffix from the only peer holding it.
+	plan := PlanReplication(&amp.SyncPlanetStatus{}, peers, RetentionPolicy{}, now)
+	status.Require(t, len(plan.Fetch), 2)
+	status.Require(t, plan.Fetch[0].PeerID, archiveID)
+	status.Require(t, plan.Fetch[0].Span, Span{tag.UID{}, txAt(90)})
+	status.Require(t, plan.Fetch[1].PeerID, suffixID)
+	status.Require(t, plan.Fetch[1].End, txAt(100))
+	requireSpans(t, "unavailable", plan.Unavailable)
+	requireSpans(t, "evict", plan.Evict)
+
+	req := plan.Fetch[1].Request(tag.UID{7, 7})
+	status.Require(t, tag.UID{req.After_0, req.After_1}, txAt(90))
+	status.Require(t, tag.UID{req.End_0, req.End_1}, txAt(100))
+
+	// A Suffix node wanting the last 30s plus a pin below any peer's suffix.
+	policy := RetentionPolicy{
+		Mode:   amp.ArchiveMode_Suffix,
+		Window: 30 * time.Second,
+		Pinned: []Span{spanAt(10, 20)},
+	}
+	delete(peers, archiveID)
+	plan = PlanReplication(&amp.SyncPlanetStatus{}, peers, policy, now)
+	status.Require(t, plan.HoldSince, txAt(70))
+	requireSpans(t, "unavailable", plan.Unavailable, spanAt(10, 20))
+	status.Require(t, len(plan.Fetch), 1)
+	status.Require(t, plan.Fetch[0].Span, spanAt(70, 100))
+}
+
+func TestPlanEvict(t *testing.T) {
+	now := testEpoch.Add(100 * time.Second)
+	policy := RetentionPolicy{
+		Mode:   amp.ArchiveMode_Suffix,
+		Window: 40 * time.Second,
+		Pinned: []Span{spanAt(5, 8)},
+	}
+	local := archiveStatus(txAt(100)) // holds everything so far
+
+	// One peer holds [20, 100] and a segment at [0, 3]; nothing else holds
+	// (3, 20), so it must stay put.
+	peerID := tag.NewID()
+	peers := map[tag.UID]*amp.SyncPlanetStatus{
+		peerID: suffixStatus(txAt(20), txAt(100), Span{tag.UID{}, txAt(3)}),
+	}
+	plan := PlanReplication(local, peers, policy, now)
+	status.Require(t, plan.HoldSince, txAt(60))
+	status.Require(t, len(plan.Fetch), 0)
+
+	after3, before5, after8, before20, before60 := txAt(3), txAt(5), txAt(8), txAt(20), txAt(60)
+	after3.Increment()
+	before5.Decrement()
+	after8.Increment()
+	before20.Decrement()
+	before60.Decrement()
+	requireSpans(t, "evict", plan.Evict, Span{tag.UID{}, txAt(3)}, Span{txAt(20), before60})
+	requireSpans(t, "stranded", plan.Stranded, Span{after3, before5}, Span{after8, before20})
+
+	// Never evict a range no other peer holds.
+	for _, span := range plan.Evict {
+		if spanSet(Holdings(peers[peerID])).intersect(spanSet{span})[0] != span {
+			t.Fatalf("evicting %v, which no peer holds", span)
+		}
+	}
+
+	// The advertised manifest keeps the pin and stranded history as Held.
+	manifest := plan.Status(tag.UID{1, 2}, txAt(100))
+	status.Require(t, manifest.ArchiveMode, amp.ArchiveMode_Suffix)
+	requireSpans(t, "held", Holdings(manifest), Span{after3, before20}, spanAt(60, 100))
+
+	// With no peers at all nothing is evicted.
+	plan = PlanReplication(local, nil, policy, now)
+	requireSpans(t, "evict alone", plan.Evict)
+	requireSpans(t, "stranded alone", plan.Stranded, Span{tag.UID{}, before5}, Span{after8, before60})
+}
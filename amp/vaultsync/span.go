@@ -0,0 +1,123 @@
+package vaultsync
+
+import (
+	"slices"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// Span is an inclusive TxTimeID range [Start, End].
+type Span struct {
+	Start tag.UID
+	End   tag.UID
+}
+
+// SpanOf returns the Span a wire UIDRange describes.
+func SpanOf(rng *amp.UIDRange) Span {
+	return Span{
+		Start: tag.UID{rng.Start_0, rng.Start_1},
+		End:   tag.UID{rng.End_0, rng.End_1},
+	}
+}
+
+// UIDRange returns the wire form of span.
+func (span Span) UIDRange() *amp.UIDRange {
+	return &amp.UIDRange{
+		Start_0: span.Start[0],
+		Start_1: span.Start[1],
+		End_0:   span.End[0],
+		End_1:   span.End[1],
+	}
+}
+
+// Contains reports whether txTimeID falls within span.
+func (span Span) Contains(txTimeID tag.UID) bool {
+	return span.Start.CompareTo(txTimeID) <= 0 && txTimeID.CompareTo(span.End) <= 0
+}
+
+// spanSet is a sorted list of disjoint, non-adjacent Spans.
+type spanSet []Span
+
+// unionSpans returns the normalized union of the given spans; inverted spans are dropped.
+func unionSpans(spans ...Span) spanSet {
+	var set spanSet
+	for _, span := range spans {
+		if span.End.CompareTo(span.Start) >= 0 {
+			set = append(set, span)
+		}
+	}
+	slices.SortFunc(set, func(a, b Span) int {
+		return a.Start.CompareTo(b.Start)
+	})
+	merged := set[:0]
+	for _, span := range set {
+		if n := len(merged); n > 0 {
+			last := &merged[n-1]
+			joinAt := last.End
+			if !joinAt.Increment() || span.Start.CompareTo(joinAt) <= 0 {
+				if span.End.CompareTo(last.End) > 0 {
+					last.End = span.End
+				}
+				continue
+			}
+		}
+		merged = append(merged, span)
+	}
+	return merged
+}
+
+// union returns set ∪ other.
+func (set spanSet) union(other spanSet) spanSet {
+	return unionSpans(append(slices.Clone(set), other...)...)
+}
+
+// intersect returns set ∩ other.
+func (set spanSet) intersect(other spanSet) spanSet {
+	var out spanSet
+	for i, j := 0, 0; i < len(set) && j < len(other); {
+		a, b := set[i], other[j]
+		lo, hi := a.Start, a.End
+		if b.Start.CompareTo(lo) > 0 {
+			lo = b.Start
+		}
+		if b.End.CompareTo(hi) < 0 {
+			hi = b.End
+		}
+		if lo.CompareTo(hi) <= 0 {
+			out = append(out, Span{lo, hi})
+		}
+		if a.End.CompareTo(b.End) < 0 {
+			i++
+		} else {
+			j++
+		}
+	}
+	return out
+}
+
+// subtract returns set − other.
+func (set spanSet) subtract(other spanSet) spanSet {
+	var out spanSet
+	j := 0
+	for _, span := range set {
+		start := span.Start
+		remains := true
+		for ; j < len(other) && other[j].End.CompareTo(start) < 0; j++ {
+		}
+		for k := j; remains && k < len(other) && other[k].Start.CompareTo(span.End) <= 0; k++ {
+			cut := other[k]
+			if cut.Start.CompareTo(start) > 0 {
+				end := cut.Start
+				end.Decrement()
+				out = append(out, Span{start, end})
+			}
+			start = cut.End
+			remains = start.CompareTo(span.End) < 0 && start.Increment()
+		}
+		if remains {
+			out = append(out, Span{start, span.End})
+		}
+	}
+	return out
+}
@@ -0,0 +1,543 @@
+// Package vaultsync implements the vault-to-vault SyncMsg protocol: two peers
+// announce the planets they hold (SyncWatchList), compare TxRangeDigest
+// fingerprints over TxTimeID ranges (SyncRangeOffer), bisect each mismatch down
+// to a small range, and stream the entries that differ (SyncRangeRequest).
+//
+// The Engine is a transport-agnostic state machine over one amp.TxJournal: a
+// connection feeds it inbound messages and entries, and it answers through a
+// Pipe.  Any in-order message channel can carry it, so third-party vaults that
+// speak the same messages interoperate.
+//
+// Reconciliation of a shared planet opens with a Combined offer — the fold of
+// per-day bucket digests over the whole journal — so converged peers settle in
+// one round trip.  A mismatch drills down to per-bucket offers, then bisects a
+// differing bucket at the local median entry.  At a leaf (few local entries)
+// the engine pulls the peer's entries with a SyncRangeRequest and pushes its
+// own; journals absorb the duplicates, so both sides converge on the union.
+//
+// A client that materializes one channel instead of a whole planet calls
+// PullNode: the peer answers from its NodeIndex with the spans touching that
+// node (SyncNodeSpans) and the engine requests just those ranges.
+package vaultsync
+
+import (
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// DefaultLeafSize is the local entry count at or below which bisection stops
+// and a range is exchanged whole.
+const DefaultLeafSize = 32
+
+// Pipe carries one peer connection's outbound traffic: SyncMsg control
+// messages and raw journal entries.  Delivery must preserve order.
+type Pipe interface {
+	SendMsg(msg *amp.SyncMsg) error
+	SendEntry(planetID, txTimeID tag.UID, raw []byte) error
+}
+
+// Opts tunes an Engine.
+type Opts struct {
+	LeafSize int // bisection stops at this many local entries in a range (0 = DefaultLeafSize)
+
+	// Admit optionally gates each inbound entry before it is appended — e.g. a
+	// vault checking the TxMsg's membership proof.  A non-nil error drops the
+	// entry and is returned from HandleEntry.
+	Admit func(planetID, txTimeID tag.UID, raw []byte) error
+
+	Nodes        *NodeIndex // answers SyncNodeSpanRequest and is told of each appended entry (nil answers every request empty and incomplete)
+	MaxNodeSpans int        // spans per SyncNodeSpans answer (0 = DefaultMaxNodeSpans)
+
+	// OnNodeSpans optionally observes each SyncNodeSpans answer after its spans
+	// are requested — e.g. to fall back to watching the whole planet when the
+	// answer is not Complete.
+	OnNodeSpans func(answer *amp.SyncNodeSpans)
+}
+
+// Stats counts an Engine's protocol traffic.
+type Stats struct {
+	OffersSent      int64 // SyncRangeOffers sent
+	RequestsSent    int64 // SyncRangeRequests sent
+	EntriesSent     int64 // entries streamed to the peer (requested or pushed)
+	EntriesAppended int64 // inbound entries admitted and appended
+}
+
+// Engine syncs one local TxJournal with one peer.  HandleMsg and HandleEntry
+// are called by the connection's reader as traffic arrives; all methods are
+// threadsafe, though a connection normally delivers from one goroutine.
+type Engine struct {
+	journal  amp.TxJournal
+	pipe     Pipe
+	leafSize int
+	admit    func(planetID, txTimeID tag.UID, raw []byte) error
+	nodes    *NodeIndex
+	maxSpans int
+	onSpans  func(answer *amp.SyncNodeSpans)
+
+	mu      sync.Mutex
+	watched map[tag.UID]struct{}
+	pulling map[tag.UID]struct{} // planets with a PullNode underway
+	peer    map[tag.UID]*amp.SyncPlanetStatus
+	stats   Stats
+}
+
+// NewEngine returns an Engine syncing journal over pipe.  Nothing is sent
+// until Watch.
+func NewEngine(journal amp.TxJournal, pipe Pipe, opts Opts) *Engine {
+	leafSize := opts.LeafSize
+	if leafSize <= 0 {
+		leafSize = DefaultLeafSize
+	}
+	return &Engine{
+		journal:  journal,
+		pipe:     pipe,
+		leafSize: leafSize,
+		admit:    opts.Admit,
+		nodes:    opts.Nodes,
+		maxSpans: opts.MaxNodeSpans,
+		onSpans:  opts.OnNodeSpans,
+		watched:  make(map[tag.UID]struct{}),
+		pulling:  make(map[tag.UID]struct{}),
+		peer:     make(map[tag.UID]*amp.SyncPlanetStatus),
+	}
+}
+
+// Watch adds planets to the local watch list, announces the updated list to
+// the peer, and opens reconciliation of any the peer already announced.
+func (e *Engine) Watch(planetIDs ...tag.UID) error {
+	e.mu.Lock()
+	for _, planetID := range planetIDs {
+		e.watched[planetID] = struct{}{}
+	}
+	e.mu.Unlock()
+	if err := e.Announce(); err != nil {
+		return err
+	}
+	for _, planetID := range planetIDs {
+		if remote, known := e.PeerStatus(planetID); known {
+			if err := e.open(planetID, remote); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PullNode asks the peer for the journal spans touching nodeID and, on its
+// answer, requests those ranges.  The planet need not be watched: its entries
+// are accepted from then on without announcing or reconciling the planet.
+func (e *Engine) PullNode(planetID, nodeID tag.UID) error {
+	e.mu.Lock()
+	e.pulling[planetID] = struct{}{}
+	e.mu.Unlock()
+	return e.pipe.SendMsg(&amp.SyncMsg{
+		NodeSpanRequest: &amp.SyncNodeSpanRequest{
+			PlanetID_0: planetID[0],
+			PlanetID_1: planetID[1],
+			NodeID_0:   nodeID[0],
+			NodeID_1:   nodeID[1],
+		},
+	})
+}
+
+// Announce sends the local watch list with each planet's current high-water
+// mark.  Call it on connect and whenever a watched journal advances.
+func (e *Engine) Announce() error {
+	list := &amp.SyncWatchList{}
+	for _, planetID := range e.watchedIDs() {
+		local, err := e.LocalStatus(planetID)
+		if err != nil {
+			return err
+		}
+		list.Planets = append(list.Planets, local)
+	}
+	return e.pipe.SendMsg(&amp.SyncMsg{WatchList: list})
+}
+
+// LocalStatus returns the SyncPlanetStatus this engine advertises for planetID.
+func (e *Engine) LocalStatus(planetID tag.UID) (*amp.SyncPlanetStatus, error) {
+	high, err := e.journal.HighWater(planetID)
+	if err != nil {
+		return nil, err
+	}
+	return &amp.SyncPlanetStatus{
+		PlanetID_0:  planetID[0],
+		PlanetID_1:  planetID[1],
+		HighWater_0: high[0],
+		HighWater_1: high[1],
+	}, nil
+}
+
+// PeerStatus returns the peer's most recently announced status for planetID.
+func (e *Engine) PeerStatus(planetID tag.UID) (*amp.SyncPlanetStatus, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	remote, ok := e.peer[planetID]
+	return remote, ok
+}
+
+// Stats returns a snapshot of this engine's traffic counters.
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// HandleMsg processes one inbound SyncMsg.  Messages for planets outside the
+// local watch list are ignored.
+func (e *Engine) HandleMsg(msg *amp.SyncMsg) error {
+	switch {
+	case msg.WatchList != nil:
+		return e.onWatchList(msg.WatchList)
+	case msg.RangeOffer != nil:
+		return e.onOffer(msg.RangeOffer)
+	case msg.RangeRequest != nil:
+		return e.onRequest(msg.RangeRequest)
+	case msg.NodeSpanRequest != nil:
+		return e.onNodeSpanRequest(msg.NodeSpanRequest)
+	case msg.NodeSpans != nil:
+		return e.onNodeSpans(msg.NodeSpans)
+	}
+	return status.Code_BadRequest.Error("vaultsync: SyncMsg carries no body")
+}
+
+// HandleEntry appends one inbound journal entry after checking that its
+// envelope matches the planet and TxTimeID it was sent under.
+func (e *Engine) HandleEntry(planetID, txTimeID tag.UID, raw []byte) error {
+	if !e.isWatched(planetID) && !e.isPulling(planetID) {
+		return nil
+	}
+	env, err := amp.ParseTxEnvelope(raw)
+	if err != nil {
+		return err
+	}
+	if env.PlanetID() != planetID || env.TxID() != txTimeID {
+		return status.Code_BadRequest.Errorf("vaultsync: entry %v does not match its envelope", txTimeID)
+	}
+	if e.admit != nil {
+		if err := e.admit(planetID, txTimeID, raw); err != nil {
+			return err
+		}
+	}
+	if err := e.journal.Append(planetID, txTimeID, raw); err != nil {
+		return err
+	}
+	if e.nodes != nil {
+		e.nodes.Appended(planetID, txTimeID)
+	}
+	e.count(func(stats *Stats) { stats.EntriesAppended++ })
+	return nil
+}
+
+// onWatchList records the peer's statuses and opens reconciliation of each
+// shared planet.
+func (e *Engine) onWatchList(list *amp.SyncWatchList) error {
+	for _, remote := range list.Planets {
+		planetID := tag.UID{remote.PlanetID_0, remote.PlanetID_1}
+		e.mu.Lock()
+		e.peer[planetID] = remote
+		_, watched := e.watched[planetID]
+		e.mu.Unlock()
+		if !watched {
+			continue
+		}
+		if err := e.open(planetID, remote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// open sends the Combined offer over the whole local journal that starts a
+// planet's reconciliation.  The side holding the newer head opens (both do on
+// a tie): bisection is two-sided, so one opener suffices to converge.
+func (e *Engine) open(planetID tag.UID, remote *amp.SyncPlanetStatus) error {
+	localHigh, err := e.journal.HighWater(planetID)
+	if err != nil {
+		return err
+	}
+	remoteHigh := tag.UID{remote.HighWater_0, remote.HighWater_1}
+	if localHigh.IsNil() || localHigh.CompareTo(remoteHigh) < 0 {
+		return nil
+	}
+	hash, err := e.combinedHash(planetID, tag.UID{}, localHigh)
+	if err != nil {
+		return err
+	}
+	return e.sendOffer(planetID, tag.UID{}, localHigh, hash, true)
+}
+
+// onOffer compares the peer's fingerprint over a range against the local one
+// and, on a mismatch, drills down or exchanges the range.
+func (e *Engine) onOffer(offer *amp.SyncRangeOffer) error {
+	planetID := tag.UID{offer.PlanetID_0, offer.PlanetID_1}
+	if !e.isWatched(planetID) {
+		return nil
+	}
+	start := tag.UID{offer.Start_0, offer.Start_1}
+	end := tag.UID{offer.End_0, offer.End_1}
+	remote := tag.UID{offer.RangeHash_0, offer.RangeHash_1}
+	if end.CompareTo(start) < 0 {
+		return status.Code_BadRequest.Errorf("vaultsync: offer range %v..%v is inverted", start, end)
+	}
+
+	var local tag.UID
+	var err error
+	if offer.Combined {
+		local, err = e.combinedHash(planetID, start, end)
+	} else {
+		local, err = e.journal.RangeHash(planetID, start, end)
+	}
+	if err != nil || local == remote {
+		return err
+	}
+
+	txIDs, err := e.rangeIDs(planetID, start, end)
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(txIDs) == 0:
+		return e.sendRequest(planetID, start, end)
+	case remote.IsNil():
+		return e.pushRange(planetID, start, end)
+	case offer.Combined:
+		return e.offerBuckets(planetID, start, end)
+	case len(txIDs) <= e.leafSize:
+		if err := e.sendRequest(planetID, start, end); err != nil {
+			return err
+		}
+		return e.pushRange(planetID, start, end)
+	}
+
+	// Bisect at the local median so each half carries half the local entries.
+	mid := txIDs[len(txIDs)/2-1]
+	next := mid
+	next.Increment()
+	for _, half := range [2][2]tag.UID{{start, mid}, {next, end}} {
+		hash, err := e.journal.RangeHash(planetID, half[0], half[1])
+		if err != nil {
+			return err
+		}
+		if err := e.sendOffer(planetID, half[0], half[1], hash, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// onRequest streams the live entries in (After, End] to the peer.
+func (e *Engine) onRequest(req *amp.SyncRangeRequest) error {
+	planetID := tag.UID{req.PlanetID_0, req.PlanetID_1}
+	if !e.isWatched(planetID) {
+		return nil
+	}
+	after := tag.UID{req.After_0, req.After_1}
+	end := tag.UID{req.End_0, req.End_1}
+	if end.IsNil() {
+		end = tag.MaxID()
+	}
+	return e.streamRange(planetID, after, end)
+}
+
+// onNodeSpanRequest answers from the local NodeIndex.  Without one, the answer
+// is empty and not Complete, so the client knows to look elsewhere.
+func (e *Engine) onNodeSpanRequest(req *amp.SyncNodeSpanRequest) error {
+	planetID := tag.UID{req.PlanetID_0, req.PlanetID_1}
+	if !e.isWatched(planetID) {
+		return nil
+	}
+	answer := &amp.SyncNodeSpans{
+		PlanetID_0: req.PlanetID_0,
+		PlanetID_1: req.PlanetID_1,
+		NodeID_0:   req.NodeID_0,
+		NodeID_1:   req.NodeID_1,
+	}
+	if e.nodes != nil {
+		var err error
+		if answer, err = e.nodes.Answer(req, e.maxSpans); err != nil {
+			return err
+		}
+	}
+	return e.pipe.SendMsg(&amp.SyncMsg{NodeSpans: answer})
+}
+
+// onNodeSpans requests each span of a PullNode answer.
+func (e *Engine) onNodeSpans(answer *amp.SyncNodeSpans) error {
+	planetID := tag.UID{answer.PlanetID_0, answer.PlanetID_1}
+	if !e.isWatched(planetID) && !e.isPulling(planetID) {
+		return nil
+	}
+	for _, rng := range answer.Spans {
+		span := SpanOf(rng)
+		if span.End.CompareTo(span.Start) < 0 {
+			return status.Code_BadRequest.Errorf("vaultsync: node span %v..%v is inverted", span.Start, span.End)
+		}
+		if err := e.sendRequest(planetID, span.Start, span.End); err != nil {
+			return err
+		}
+	}
+	if e.onSpans != nil {
+		e.onSpans(answer)
+	}
+	return nil
+}
+
+// offerBuckets answers a mismatched Combined offer with plain offers that
+// partition [start, end]: one per local non-empty day bucket, plus the gaps
+// between them, so a bucket only the peer holds still surfaces as a mismatch.
+func (e *Engine) offerBuckets(planetID, start, end tag.UID) error {
+	days, err := e.rangeDays(planetID, start, end)
+	if err != nil {
+		return err
+	}
+	var spans [][2]tag.UID
+	cursor, covered := start, false
+	for _, day := range days {
+		lo, hi := dayBounds(day, start, end)
+		if lo.CompareTo(cursor) > 0 {
+			gapEnd := lo
+			gapEnd.Decrement()
+			spans = append(spans, [2]tag.UID{cursor, gapEnd})
+		}
+		spans = append(spans, [2]tag.UID{lo, hi})
+		cursor = hi
+		if covered = hi == end || !cursor.Increment(); covered {
+			break
+		}
+	}
+	if !covered {
+		spans = append(spans, [2]tag.UID{cursor, end})
+	}
+	for _, span := range spans {
+		hash, err := e.journal.RangeHash(planetID, span[0], span[1])
+		if err != nil {
+			return err
+		}
+		if err := e.sendOffer(planetID, span[0], span[1], hash, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) sendOffer(planetID, start, end, hash tag.UID, combined bool) error {
+	e.count(func(stats *Stats) { stats.OffersSent++ })
+	return e.pipe.SendMsg(&amp.SyncMsg{
+		RangeOffer: &amp.SyncRangeOffer{
+			PlanetID_0:  planetID[0],
+			PlanetID_1:  planetID[1],
+			Start_0:     start[0],
+			Start_1:     start[1],
+			End_0:       end[0],
+			End_1:       end[1],
+			Combined:    combined,
+			RangeHash_0: hash[0],
+			RangeHash_1: hash[1],
+		},
+	})
+}
+
+// sendRequest asks the peer for its entries in [start, end].
+func (e *Engine) sendRequest(planetID, start, end tag.UID) error {
+	after := start
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	e.count(func(stats *Stats) { stats.RequestsSent++ })
+	return e.pipe.SendMsg(&amp.SyncMsg{
+		RangeRequest: &amp.SyncRangeRequest{
+			PlanetID_0: planetID[0],
+			PlanetID_1: planetID[1],
+			After_0:    after[0],
+			After_1:    after[1],
+			End_0:      end[0],
+			End_1:      end[1],
+		},
+	})
+}
+
+// pushRange streams the local entries in [start, end] unrequested.
+func (e *Engine) pushRange(planetID, start, end tag.UID) error {
+	after := start
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	return e.streamRange(planetID, after, end)
+}
+
+// streamRange sends the live entries in (after, end] to the peer.
+func (e *Engine) streamRange(planetID, after, end tag.UID) error {
+	var sendErr error
+	err := e.journal.ReadSince(planetID, after, func(txTimeID tag.UID, raw []byte) bool {
+		if txTimeID.CompareTo(end) > 0 {
+			return false
+		}
+		if sendErr = e.pipe.SendEntry(planetID, txTimeID, raw); sendErr != nil {
+			return false
+		}
+		e.count(func(stats *Stats) { stats.EntriesSent++ })
+		return true
+	})
+	if err == nil {
+		err = sendErr
+	}
+	return err
+}
+
+// rangeIDs returns the TxTimeIDs of the live entries in [start, end].
+func (e *Engine) rangeIDs(planetID, start, end tag.UID) ([]tag.UID, error) {
+	var txIDs []tag.UID
+	err := readRange(e.journal, planetID, start, end, func(txTimeID tag.UID, raw []byte) {
+		txIDs = append(txIDs, txTimeID)
+	})
+	return txIDs, err
+}
+
+func (e *Engine) isWatched(planetID tag.UID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, watched := e.watched[planetID]
+	return watched
+}
+
+func (e *Engine) isPulling(planetID tag.UID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, pulling := e.pulling[planetID]
+	return pulling
+}
+
+func (e *Engine) watchedIDs() []tag.UID {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	planetIDs := make([]tag.UID, 0, len(e.watched))
+	for planetID := range e.watched {
+		planetIDs = append(planetIDs, planetID)
+	}
+	return planetIDs
+}
+
+func (e *Engine) count(fn func(stats *Stats)) {
+	e.mu.Lock()
+	fn(&e.stats)
+	e.mu.Unlock()
+}
+
+// readRange calls fn for each live entry with start <= TxTimeID <= end.
+func readRange(journal amp.TxJournal, planetID, start, end tag.UID, fn func(txTimeID tag.UID, raw []byte)) error {
+	after := start
+	if !after.Decrement() {
+		after = tag.UID{}
+	}
+	return journal.ReadSince(planetID, after, func(txTimeID tag.UID, raw []byte) bool {
+		if txTimeID.CompareTo(end) > 0 {
+			return false
+		}
+		fn(txTimeID, raw)
+		return true
+	})
+}
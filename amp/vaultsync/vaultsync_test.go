@@ -0,0 +1,204 @@
+package vaultsync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/amp/journal"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+var testEpoch = time.Unix(1_700_000_000, 0)
+
+// txAt returns a TxTimeID the given number of seconds after testEpoch.
+func txAt(seconds int) tag.UID {
+	return tag.UID_FromTime(testEpoch.Add(time.Duration(seconds) * time.Second))
+}
+
+// entryBytes returns wire bytes whose envelope names planetID and txID.
+func entryBytes(t *testing.T, planetID, txID tag.UID) []byte {
+	t.Helper()
+	tx := amp.TxNew()
+	tx.SetTxID(txID)
+	tx.SetPlanetID(planetID)
+	tx.Upsert(planetID, tag.UID{0x5e, 0x11}, txID, &amp.Tag{Text: txID.String()})
+	var raw []byte
+	if err := amp.SealTx(tx, nil, &raw); err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func appendAt(t *testing.T, j amp.TxJournal, planetID tag.UID, seconds ...int) {
+	t.Helper()
+	for _, sec := range seconds {
+		txID := txAt(sec)
+		if err := j.Append(planetID, txID, entryBytes(t, planetID, txID)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newJournal(t *testing.T) *journal.Memory {
+	j := journal.NewMemory()
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func countEntries(t *testing.T, j amp.TxJournal, planetID tag.UID) int {
+	t.Helper()
+	n := 0
+	if err := j.ReadSince(planetID, tag.UID{}, func(tag.UID, []byte) bool { n++; return true }); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func requireConverged(t *testing.T, a, b amp.TxJournal, planetID tag.UID, entries int) {
+	t.Helper()
+	hashA, _ := a.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	hashB, _ := b.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	if hashA != hashB {
+		t.Fatal("journals did not converge")
+	}
+	status.Require(t, countEntries(t, a, planetID), entries)
+	status.Require(t, countEntries(t, b, planetID), entries)
+}
+
+func TestConverge(t *testing.T) {
+	planetID := tag.NewID()
+	a, b := newJournal(t), newJournal(t)
+
+	// A shared history over ten days, then divergent writes — some in old
+	// buckets, some new — on each side.
+	day := DayBucketSeconds
+	for i := range 200 {
+		appendAt(t, a, planetID, i*day/20)
+		appendAt(t, b, planetID, i*day/20)
+	}
+	appendAt(t, a, planetID, 2*day+7, 5*day+11, 12*day)
+	appendAt(t, b, planetID, 3*day+5, 5*day+13, 11*day)
+
+	if _, _, err := Converge(a, b, []tag.UID{planetID}, Opts{LeafSize: 4}); err != nil {
+		t.Fatal(err)
+	}
+	requireConverged(t, a, b, planetID, 206)
+
+	// Converged journals settle on the Combined probe alone.
+	statsA, statsB, err := Converge(a, b, []tag.UID{planetID}, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, statsA.OffersSent+statsB.OffersSent, int64(2))
+	status.Require(t, statsA.EntriesSent+statsB.EntriesSent, int64(0))
+}
+
+func TestConvergeFresh(t *testing.T) {
+	planetID := tag.NewID()
+	a, b := newJournal(t), newJournal(t)
+	for i := range 300 {
+		appendAt(t, a, planetID, i*600)
+	}
+
+	statsA, statsB, err := Converge(a, b, []tag.UID{planetID}, Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireConverged(t, a, b, planetID, 300)
+
+	// An empty peer pulls the whole journal in one request.
+	status.Require(t, statsB.RequestsSent, int64(1))
+	status.Require(t, statsA.EntriesSent, int64(300))
+	status.Require(t, statsB.EntriesAppended, int64(300))
+}
+
+func TestConvergeBisects(t *testing.T) {
+	planetID := tag.NewID()
+	a, b := newJournal(t), newJournal(t)
+	for i := range 2000 {
+		appendAt(t, a, planetID, 2*i)
+		appendAt(t, b, planetID, 2*i)
+	}
+	appendAt(t, a, planetID, 777777)
+	appendAt(t, b, planetID, 1001, 1501)
+
+	statsA, statsB, err := Converge(a, b, []tag.UID{planetID}, Opts{LeafSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireConverged(t, a, b, planetID, 2003)
+
+	// Bisection confines the exchange to a few leaves, not the shared history.
+	if sent := statsA.EntriesSent + statsB.EntriesSent; sent > 64 {
+		t.Fatalf("streamed %d entries to reconcile 3", sent)
+	}
+}
+
+func TestHandleEntry(t *testing.T) {
+	planetID, otherID := tag.NewID(), tag.NewID()
+	j := newJournal(t)
+
+	var refused tag.UID
+	engine := NewEngine(j, &loopQueue{}, Opts{
+		Admit: func(planetID, txTimeID tag.UID, raw []byte) error {
+			if txTimeID == refused {
+				return status.Code_AuthFailed.Error("no membership proof")
+			}
+			return nil
+		},
+	})
+	if err := engine.Watch(planetID); err != nil {
+		t.Fatal(err)
+	}
+
+	txID := txAt(1)
+	if err := engine.HandleEntry(planetID, txAt(2), entryBytes(t, planetID, txID)); !status.IsError(err, status.Code_BadRequest) {
+		t.Fatalf("expected BadRequest for a mislabeled entry, got %v", err)
+	}
+	refused = txAt(3)
+	if err := engine.HandleEntry(planetID, refused, entryBytes(t, planetID, refused)); !status.IsError(err, status.Code_AuthFailed) {
+		t.Fatalf("expected the Admit error, got %v", err)
+	}
+	if err := engine.HandleEntry(otherID, txID, entryBytes(t, otherID, txID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.HandleEntry(planetID, txID, entryBytes(t, planetID, txID)); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, countEntries(t, j, planetID), 1)
+	status.Require(t, countEntries(t, j, otherID), 0)
+	status.Require(t, engine.Stats().EntriesAppended, int64(1))
+}
+
+func TestCombinedHash(t *testing.T) {
+	planetID := tag.NewID()
+	a, b := newJournal(t), newJournal(t)
+	appendAt(t, a, planetID, 10, DayBucketSeconds+10)
+	appendAt(t, b, planetID, DayBucketSeconds+10, 10)
+
+	engineA := NewEngine(a, &loopQueue{}, Opts{})
+	engineB := NewEngine(b, &loopQueue{}, Opts{})
+	hashA, _ := engineA.combinedHash(planetID, tag.UID{}, tag.MaxID())
+	hashB, _ := engineB.combinedHash(planetID, tag.UID{}, tag.MaxID())
+	if hashA.IsNil() || hashA != hashB {
+		t.Fatal("Combined fingerprint is not a function of the journal's entries")
+	}
+
+	// The fold differs from a plain RangeHash over the same span.
+	plain, _ := a.RangeHash(planetID, tag.UID{}, tag.MaxID())
+	if plain == hashA {
+		t.Fatal("Combined fingerprint collides with the plain RangeHash")
+	}
+
+	appendAt(t, b, planetID, 2*DayBucketSeconds)
+	hashB, _ = engineB.combinedHash(planetID, tag.UID{}, tag.MaxID())
+	if hashA == hashB {
+		t.Fatal("Combined fingerprint missed a new bucket")
+	}
+	empty, _ := engineA.combinedHash(tag.NewID(), tag.UID{}, tag.MaxID())
+	if !empty.IsNil() {
+		t.Fatal("an empty planet's Combined fingerprint is not nil")
+	}
+}
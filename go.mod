@@ -3,8 +3,10 @@ module github.com/art-media-platform/amp.SDK
 go 1.26.3
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0
 	github.com/pkg/errors v0.9.1
 	golang.org/x/crypto v0.54.0
+	golang.org/x/net v0.56.0
 	google.golang.org/protobuf v1.36.11
 )
 
@@ -0,0 +1,399 @@
+// Package blake3 is a dependency-free BLAKE3-256 (hash mode) in pure Go.
+//
+// BLAKE3 splits its input into 1 KiB chunks, compresses each chunk to a
+// chaining value independently of the others, and folds those values up a
+// binary tree to the root.  Hasher exploits the tree: a large Write hashes
+// whole aligned subtrees with their chunks spread across cores, then folds the
+// subtree root into the running state as one tree node — so the digest is
+// identical however the input is segmented, and throughput scales with
+// GOMAXPROCS on large blobs.
+//
+// Hasher implements encoding.BinaryMarshaler, so a long stream can checkpoint
+// and resume (safe.HashKit.MarshalState).
+package blake3
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+const (
+	// Size is the digest length in bytes.
+	Size = 32
+
+	// BlockSize is the compression block length.
+	BlockSize = 64
+
+	// ChunkSize is the length of one tree leaf.
+	ChunkSize = 1024
+
+	// parallelChunks is the smallest subtree (in chunks) a Write hashes across
+	// cores; below it the fan-out costs more than it saves.
+	parallelChunks = 64
+
+	// maxSubtreeChunks bounds one parallel subtree (16 MiB) and so the chaining
+	// values held at once.
+	maxSubtreeChunks = 1 << 14
+
+	// minChunksPerWorker keeps each goroutine's share worth its start-up.
+	minChunksPerWorker = 16
+
+	maxDepth = 54 // 2^54 chunks = 2^64 bytes
+)
+
+const (
+	flagChunkStart = 1 << iota
+	flagChunkEnd
+	flagParent
+	flagRoot
+)
+
+var iv = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+// schedule is the message-word order of each of the 7 rounds: the identity,
+// then the BLAKE3 permutation applied once more per round.
+var schedule = [7][16]uint8{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8},
+	{3, 4, 10, 12, 13, 2, 7, 14, 6, 5, 9, 0, 11, 15, 8, 1},
+	{10, 7, 12, 9, 14, 3, 13, 15, 4, 0, 11, 2, 5, 8, 1, 6},
+	{12, 13, 9, 11, 15, 10, 14, 8, 7, 2, 5, 3, 0, 1, 6, 4},
+	{9, 14, 11, 5, 8, 12, 15, 1, 13, 3, 0, 10, 2, 6, 4, 7},
+	{11, 15, 5, 0, 1, 9, 8, 6, 14, 10, 2, 12, 3, 4, 7, 13},
+}
+
+func g(state *[16]uint32, a, b, c, d int, mx, my uint32) {
+	state[a] = state[a] + state[b] + mx
+	state[d] = bits.RotateLeft32(state[d]^state[a], -16)
+	state[c] = state[c] + state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -12)
+	state[a] = state[a] + state[b] + my
+	state[d] = bits.RotateLeft32(state[d]^state[a], -8)
+	state[c] = state[c] + state[d]
+	state[b] = bits.RotateLeft32(state[b]^state[c], -7)
+}
+
+// compress runs the BLAKE3 compression function, returning all 16 output words.
+func compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	state := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		iv[0], iv[1], iv[2], iv[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	for round := range schedule {
+		sched := &schedule[round]
+		g(&state, 0, 4, 8, 12, block[sched[0]], block[sched[1]])
+		g(&state, 1, 5, 9, 13, block[sched[2]], block[sched[3]])
+		g(&state, 2, 6, 10, 14, block[sched[4]], block[sched[5]])
+		g(&state, 3, 7, 11, 15, block[sched[6]], block[sched[7]])
+		g(&state, 0, 5, 10, 15, block[sched[8]], block[sched[9]])
+		g(&state, 1, 6, 11, 12, block[sched[10]], block[sched[11]])
+		g(&state, 2, 7, 8, 13, block[sched[12]], block[sched[13]])
+		g(&state, 3, 4, 9, 14, block[sched[14]], block[sched[15]])
+	}
+	for idx := range 8 {
+		state[idx] ^= state[idx+8]
+		state[idx+8] ^= cv[idx]
+	}
+	return state
+}
+
+func loadBlock(block *[16]uint32, buf []byte) {
+	for idx := range block {
+		block[idx] = binary.LittleEndian.Uint32(buf[4*idx:])
+	}
+}
+
+func firstEight(words [16]uint32) (cv [8]uint32) {
+	copy(cv[:], words[:8])
+	return cv
+}
+
+// parentCV folds two child chaining values into a non-root parent node.
+func parentCV(left, right *[8]uint32) [8]uint32 {
+	var block [16]uint32
+	copy(block[:8], left[:])
+	copy(block[8:], right[:])
+	return firstEight(compress(&iv, &block, 0, BlockSize, flagParent))
+}
+
+// chunkCV hashes one whole (non-final) chunk to its chaining value.
+func chunkCV(chunk []byte, counter uint64) [8]uint32 {
+	cv := iv
+	var block [16]uint32
+	for blockIdx := range ChunkSize / BlockSize {
+		flags := uint32(0)
+		if blockIdx == 0 {
+			flags |= flagChunkStart
+		}
+		if blockIdx == ChunkSize/BlockSize-1 {
+			flags |= flagChunkEnd
+		}
+		loadBlock(&block, chunk[blockIdx*BlockSize:])
+		cv = firstEight(compress(&cv, &block, counter, BlockSize, flags))
+	}
+	return cv
+}
+
+// subtreeCV hashes len(data)/ChunkSize whole chunks (a power of two, the first
+// numbered `counter`) to the chaining value of their subtree, spreading the
+// chunks across cores once there are parallelChunks of them.
+func subtreeCV(data []byte, counter uint64) [8]uint32 {
+	chunkCount := len(data) / ChunkSize
+	if chunkCount == 1 {
+		return chunkCV(data, counter)
+	}
+	cvs := make([][8]uint32, chunkCount)
+	workers := 1
+	if chunkCount >= parallelChunks {
+		workers = min(runtime.GOMAXPROCS(0), chunkCount/minChunksPerWorker)
+	}
+	if workers <= 1 {
+		for idx := range cvs {
+			cvs[idx] = chunkCV(data[idx*ChunkSize:], counter+uint64(idx))
+		}
+	} else {
+		per := (chunkCount + workers - 1) / workers
+		var wg sync.WaitGroup
+		for begin := 0; begin < chunkCount; begin += per {
+			end := min(begin+per, chunkCount)
+			wg.Go(func() {
+				for idx := begin; idx < end; idx++ {
+					cvs[idx] = chunkCV(data[idx*ChunkSize:], counter+uint64(idx))
+				}
+			})
+		}
+		wg.Wait()
+	}
+	for width := chunkCount; width > 1; width /= 2 {
+		for idx := range width / 2 {
+			cvs[idx] = parentCV(&cvs[2*idx], &cvs[2*idx+1])
+		}
+	}
+	return cvs[0]
+}
+
+// Hasher is an incremental BLAKE3-256 hash.Hash.  The zero value is not
+// usable; call New.
+type Hasher struct {
+	// The chunk in progress.
+	cv         [8]uint32
+	counter    uint64 // index of the chunk in progress
+	block      [BlockSize]byte
+	blockLen   int // bytes in block
+	compressed int // blocks of the chunk already compressed
+
+	// Chaining values of completed subtrees, one per set bit of counter.
+	stack    [maxDepth][8]uint32
+	stackLen int
+}
+
+// New returns a Hasher for BLAKE3-256.
+func New() *Hasher {
+	hasher := &Hasher{}
+	hasher.Reset()
+	return hasher
+}
+
+// Sum256 returns the BLAKE3-256 digest of data.
+func Sum256(data []byte) [Size]byte {
+	var digest [Size]byte
+	hasher := New()
+	hasher.Write(data)
+	hasher.Sum(digest[:0])
+	return digest
+}
+
+// Size returns Size.
+func (hasher *Hasher) Size() int { return Size }
+
+// BlockSize returns the chunk length, the unit a Write is best aligned to.
+func (hasher *Hasher) BlockSize() int { return ChunkSize }
+
+// Reset restarts the hash.
+func (hasher *Hasher) Reset() {
+	*hasher = Hasher{cv: iv}
+}
+
+func (hasher *Hasher) chunkLen() int {
+	return hasher.compressed*BlockSize + hasher.blockLen
+}
+
+// Write absorbs p (hash.Hash; never errors).
+func (hasher *Hasher) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		// On a chunk boundary with more than a chunk to come, hash the largest
+		// aligned subtree that leaves input behind (the final chunk must stay
+		// pending for root finalization) straight into the tree.
+		if hasher.chunkLen() == 0 && len(p) > ChunkSize {
+			chunks := uint64(1) << (bits.Len64(uint64(len(p)-1)/ChunkSize) - 1)
+			if hasher.counter != 0 {
+				chunks = min(chunks, uint64(1)<<bits.TrailingZeros64(hasher.counter))
+			}
+			chunks = min(chunks, maxSubtreeChunks)
+			span := int(chunks) * ChunkSize
+			hasher.pushSubtree(subtreeCV(p[:span], hasher.counter), chunks)
+			p = p[span:]
+			continue
+		}
+
+		// Fill the chunk in progress one block at a time; a full block is
+		// compressed only once more input shows it is not the chunk's last.
+		if hasher.blockLen == BlockSize {
+			if hasher.compressed == ChunkSize/BlockSize-1 {
+				out := hasher.chunkOutput()
+				hasher.pushSubtree(out.chainingValue(), 1)
+				continue
+			}
+			hasher.compressBlock()
+		}
+		take := copy(hasher.block[hasher.blockLen:], p)
+		hasher.blockLen += take
+		p = p[take:]
+	}
+	return written, nil
+}
+
+func (hasher *Hasher) compressBlock() {
+	var block [16]uint32
+	loadBlock(&block, hasher.block[:])
+	flags := uint32(0)
+	if hasher.compressed == 0 {
+		flags |= flagChunkStart
+	}
+	hasher.cv = firstEight(compress(&hasher.cv, &block, hasher.counter, BlockSize, flags))
+	hasher.compressed++
+	hasher.blockLen = 0
+	hasher.block = [BlockSize]byte{}
+}
+
+// pushSubtree adds the chaining value of `chunks` whole chunks (a power of two
+// that divides the chunk counter) to the tree, merging completed subtrees, and
+// starts a fresh chunk after them.
+func (hasher *Hasher) pushSubtree(cv [8]uint32, chunks uint64) {
+	level := bits.TrailingZeros64(chunks)
+	hasher.counter += chunks
+	for total := hasher.counter >> level; total&1 == 0; total >>= 1 {
+		hasher.stackLen--
+		cv = parentCV(&hasher.stack[hasher.stackLen], &cv)
+	}
+	hasher.stack[hasher.stackLen] = cv
+	hasher.stackLen++
+
+	hasher.cv = iv
+	hasher.block = [BlockSize]byte{}
+	hasher.blockLen = 0
+	hasher.compressed = 0
+}
+
+// output is a node not yet compressed, so it can still be flagged as root.
+type output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (out *output) chainingValue() [8]uint32 {
+	return firstEight(compress(&out.cv, &out.block, out.counter, out.blockLen, out.flags))
+}
+
+func (hasher *Hasher) chunkOutput() output {
+	out := output{
+		cv:       hasher.cv,
+		counter:  hasher.counter,
+		blockLen: uint32(hasher.blockLen),
+		flags:    flagChunkEnd,
+	}
+	if hasher.compressed == 0 {
+		out.flags |= flagChunkStart
+	}
+	loadBlock(&out.block, hasher.block[:])
+	return out
+}
+
+// Sum appends the digest of the input so far to b; the state is unchanged.
+func (hasher *Hasher) Sum(b []byte) []byte {
+	out := hasher.chunkOutput()
+	for idx := hasher.stackLen - 1; idx >= 0; idx-- {
+		right := out.chainingValue()
+		out = output{cv: iv, flags: flagParent, blockLen: BlockSize}
+		copy(out.block[:8], hasher.stack[idx][:])
+		copy(out.block[8:], right[:])
+	}
+	words := compress(&out.cv, &out.block, out.counter, out.blockLen, out.flags|flagRoot)
+	for _, word := range words[:Size/4] {
+		b = binary.LittleEndian.AppendUint32(b, word)
+	}
+	return b
+}
+
+// ── State checkpoint ───────────────────────────────────────────────────────
+
+const (
+	stateMagic   = "b3\x01"
+	stateFixedSz = len(stateMagic) + 32 + 8 + BlockSize + 1 + 1 + 1
+)
+
+// MarshalBinary captures the running state (encoding.BinaryMarshaler).
+func (hasher *Hasher) MarshalBinary() ([]byte, error) {
+	state := make([]byte, 0, stateFixedSz+32*hasher.stackLen)
+	state = append(state, stateMagic...)
+	state = appendCV(state, &hasher.cv)
+	state = binary.LittleEndian.AppendUint64(state, hasher.counter)
+	state = append(state, hasher.block[:]...)
+	state = append(state, byte(hasher.blockLen), byte(hasher.compressed), byte(hasher.stackLen))
+	for idx := range hasher.stackLen {
+		state = appendCV(state, &hasher.stack[idx])
+	}
+	return state, nil
+}
+
+// UnmarshalBinary restores a state MarshalBinary captured.
+func (hasher *Hasher) UnmarshalBinary(state []byte) error {
+	if len(state) < stateFixedSz || string(state[:len(stateMagic)]) != stateMagic {
+		return errors.New("blake3: invalid hash state")
+	}
+	fixed := state[len(stateMagic):]
+	var restored Hasher
+	readCV(&restored.cv, fixed)
+	restored.counter = binary.LittleEndian.Uint64(fixed[32:])
+	copy(restored.block[:], fixed[40:])
+	restored.blockLen = int(fixed[40+BlockSize])
+	restored.compressed = int(fixed[41+BlockSize])
+	restored.stackLen = int(fixed[42+BlockSize])
+	stack := state[stateFixedSz:]
+	if restored.blockLen > BlockSize ||
+		restored.compressed >= ChunkSize/BlockSize ||
+		restored.stackLen > maxDepth ||
+		restored.stackLen != bits.OnesCount64(restored.counter) ||
+		len(stack) != 32*restored.stackLen {
+		return errors.New("blake3: invalid hash state")
+	}
+	for idx := range restored.stackLen {
+		readCV(&restored.stack[idx], stack[32*idx:])
+	}
+	*hasher = restored
+	return nil
+}
+
+func appendCV(buf []byte, cv *[8]uint32) []byte {
+	for _, word := range cv {
+		buf = binary.LittleEndian.AppendUint32(buf, word)
+	}
+	return buf
+}
+
+func readCV(cv *[8]uint32, buf []byte) {
+	for idx := range cv {
+		cv[idx] = binary.LittleEndian.Uint32(buf[4*idx:])
+	}
+}
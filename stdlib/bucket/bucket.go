@@ -5,6 +5,9 @@
 // A token bucket bounds bursts as well as steady-state rate: a caller may burn
 // a small reserve, then settles into the refill cadence — where fixed-window
 // sleeps would stall the whole queue at every period boundary.
+//
+// Keyed holds one bucket per key (a budget per identity), bounded by LRU
+// eviction.
 package bucket
 
 import (
@@ -92,3 +95,25 @@ func (b *TokenBucket) Reset(prefill float64) {
 	b.lastFill = time.Now()
 	b.mu.Unlock()
 }
+
+// Refund returns `count` tokens to the bucket (capped at capacity) — for a
+// caller that took from this bucket and was then refused by another it must
+// clear in the same step.
+func (b *TokenBucket) Refund(count float64) {
+	b.mu.Lock()
+	b.tokens = min(b.capacity, b.tokens+count)
+	b.mu.Unlock()
+}
+
+// Tokens returns the tokens available now, refill included.
+func (b *TokenBucket) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	elapsed := time.Since(b.lastFill).Seconds()
+	return min(b.capacity, b.tokens+elapsed*b.refillRate)
+}
+
+// Capacity returns the bucket's burst budget.
+func (b *TokenBucket) Capacity() float64 {
+	return b.capacity
+}
@@ -62,3 +62,33 @@ func TestTryTake_BurstThenRefuse(t *testing.T) {
 		t.Fatal("empty bucket admitted a take")
 	}
 }
+
+func TestKeyed_PerKeyBudgetsAndLRU(t *testing.T) {
+	keyed := NewKeyed[string](2, frozenRate, 2)
+	for range 2 {
+		if admitted, _ := keyed.TryTakeN("a", 1); !admitted {
+			t.Fatal("burst capacity not honored")
+		}
+	}
+	if admitted, _ := keyed.TryTakeN("a", 1); admitted {
+		t.Fatal("empty bucket admitted a take")
+	}
+	if admitted, _ := keyed.TryTakeN("b", 2); !admitted {
+		t.Fatal("one key's spend throttled another")
+	}
+
+	// "a" is least recently used; a third key evicts it, and it returns full.
+	keyed.TryTakeN("c", 1)
+	if keyed.Len() != 2 || keyed.Tokens("a") != 2 {
+		t.Fatalf("LRU key not evicted: %d keys, a has %v tokens", keyed.Len(), keyed.Tokens("a"))
+	}
+	snap := keyed.Snapshot()
+	if snap.Evicted != 1 || snap.Admitted != 4 || snap.Rejected != 1 || snap.Keys[0].Key != "c" {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+
+	keyed.Refund("c", 1)
+	if keyed.Tokens("c") != 2 || keyed.Snapshot().Admitted != 3 {
+		t.Fatal("refund did not undo the take")
+	}
+}
@@ -0,0 +1,196 @@
+package bucket
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Keyed holds one TokenBucket per key — a budget per identity — created full on
+// a key's first take.  At most maxKeys buckets are held: taking for a new key
+// beyond that evicts the least recently used one.  An evicted key starts over
+// with a full bucket, so size maxKeys above the number of keys expected to be
+// active within a refill period; Prune drops only keys that have refilled and so
+// lose nothing by eviction.  All methods are threadsafe.
+type Keyed[K comparable] struct {
+	capacity   float64
+	refillRate float64
+	maxKeys    int
+
+	mu       sync.Mutex
+	entries  map[K]*list.Element // of *keyedEntry[K]
+	lru      list.List           // front = most recently used
+	admitted uint64
+	rejected uint64
+	evicted  uint64
+}
+
+type keyedEntry[K comparable] struct {
+	key      K
+	bucket   *TokenBucket
+	admitted uint64
+	rejected uint64
+	lastUsed time.Time
+}
+
+// KeyStats is one key's entry in a KeyedSnapshot.
+type KeyStats[K comparable] struct {
+	Key      K
+	Tokens   float64 // available now
+	Admitted uint64  // takes admitted since the key's bucket was created
+	Rejected uint64  // takes refused since the key's bucket was created
+	LastUsed time.Time
+}
+
+// KeyedSnapshot is a point-in-time view of a Keyed, for operators.
+type KeyedSnapshot[K comparable] struct {
+	Capacity   float64
+	RefillRate float64 // tokens/sec
+	Admitted   uint64  // lifetime totals, across evictions
+	Rejected   uint64
+	Evicted    uint64
+	Keys       []KeyStats[K] // most recently used first
+}
+
+// NewKeyed configures a Keyed whose buckets each have burst `capacity` and
+// refill `refillRatePerSec`, holding at most maxKeys (<= 0 = unbounded).
+func NewKeyed[K comparable](capacity, refillRatePerSec float64, maxKeys int) *Keyed[K] {
+	return &Keyed[K]{
+		capacity:   capacity,
+		refillRate: refillRatePerSec,
+		maxKeys:    maxKeys,
+		entries:    make(map[K]*list.Element),
+	}
+}
+
+// TryTakeN is TokenBucket.TryTakeN against key's bucket.
+func (k *Keyed[K]) TryTakeN(key K, count float64) (bool, time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry := k.touch(key)
+	admitted, wait := entry.bucket.TryTakeN(count)
+	if admitted {
+		entry.admitted++
+		k.admitted++
+	} else {
+		entry.rejected++
+		k.rejected++
+	}
+	return admitted, wait
+}
+
+// Refund undoes an admitted take against key: it returns the tokens (see
+// TokenBucket.Refund) and un-counts the admission.  No-op once key is evicted.
+func (k *Keyed[K]) Refund(key K, count float64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if elem := k.entries[key]; elem != nil {
+		entry := elem.Value.(*keyedEntry[K])
+		entry.bucket.Refund(count)
+		if entry.admitted > 0 {
+			entry.admitted--
+			k.admitted--
+		}
+	}
+}
+
+// Tokens returns the tokens available to key now (a full bucket for a key not held).
+func (k *Keyed[K]) Tokens(key K) float64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if elem := k.entries[key]; elem != nil {
+		return elem.Value.(*keyedEntry[K]).bucket.Tokens()
+	}
+	return k.capacity
+}
+
+// Forget drops key's bucket.
+func (k *Keyed[K]) Forget(key K) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if elem := k.entries[key]; elem != nil {
+		k.lru.Remove(elem)
+		delete(k.entries, key)
+	}
+}
+
+// Prune drops every key idle at least `idle` whose bucket has refilled, and
+// returns how many were dropped.
+func (k *Keyed[K]) Prune(idle time.Duration) int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	cutoff := time.Now().Add(-idle)
+	pruned := 0
+	for elem := k.lru.Back(); elem != nil; {
+		entry := elem.Value.(*keyedEntry[K])
+		if entry.lastUsed.After(cutoff) {
+			break // the rest are more recent
+		}
+		prev := elem.Prev()
+		if entry.bucket.Tokens() >= k.capacity {
+			k.lru.Remove(elem)
+			delete(k.entries, entry.key)
+			pruned++
+		}
+		elem = prev
+	}
+	return pruned
+}
+
+// Len returns the number of keys held.
+func (k *Keyed[K]) Len() int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.entries)
+}
+
+// Snapshot returns the current totals and per-key state.
+func (k *Keyed[K]) Snapshot() KeyedSnapshot[K] {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	snap := KeyedSnapshot[K]{
+		Capacity:   k.capacity,
+		RefillRate: k.refillRate,
+		Admitted:   k.admitted,
+		Rejected:   k.rejected,
+		Evicted:    k.evicted,
+		Keys:       make([]KeyStats[K], 0, len(k.entries)),
+	}
+	for elem := k.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*keyedEntry[K])
+		snap.Keys = append(snap.Keys, KeyStats[K]{
+			Key:      entry.key,
+			Tokens:   entry.bucket.Tokens(),
+			Admitted: entry.admitted,
+			Rejected: entry.rejected,
+			LastUsed: entry.lastUsed,
+		})
+	}
+	return snap
+}
+
+// touch returns key's entry, creating it (and evicting the LRU entry when
+// full), and marks it most recently used.
+func (k *Keyed[K]) touch(key K) *keyedEntry[K] {
+	now := time.Now()
+	if elem := k.entries[key]; elem != nil {
+		k.lru.MoveToFront(elem)
+		entry := elem.Value.(*keyedEntry[K])
+		entry.lastUsed = now
+		return entry
+	}
+	if k.maxKeys > 0 && len(k.entries) >= k.maxKeys {
+		oldest := k.lru.Back()
+		k.lru.Remove(oldest)
+		delete(k.entries, oldest.Value.(*keyedEntry[K]).key)
+		k.evicted++
+	}
+	entry := &keyedEntry[K]{
+		key:      key,
+		bucket:   NewTokenBucket(k.capacity, k.refillRate),
+		lastUsed: now,
+	}
+	k.entries[key] = k.lru.PushFront(entry)
+	return entry
+}
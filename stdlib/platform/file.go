@@ -0,0 +1,35 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AtomicTempPrefix begins the name of every temp file WriteFileAtomic stages, so
+// a store can sweep the leftovers of a crash mid-write.
+const AtomicTempPrefix = ".tmp-"
+
+// WriteFileAtomic publishes data at path via a synced temp file in the same
+// directory and a rename, so a reader sees either the old file or the new one,
+// never a torn write.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), AtomicTempPrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
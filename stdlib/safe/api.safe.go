@@ -3,7 +3,9 @@
 // Architecture:
 //
 //	Guard      — protects/recovers a DEK (Data Encryption Key) using root material.
-//	             Implementations: fileGuard (local passphrase).
+//	             Implementations: fileGuard (local passphrase),
+//	             PassphraseGuard (Argon2id-stretched passphrase),
+//	             ThresholdGuard (k-of-n Shamir shares held by guardians).
 //
 //	TomeStore  — persists a SealedTome to durable storage (file, cloud, etc).
 //
@@ -39,6 +41,8 @@ var ErrStoreClosed = status.Code_NotReady.Error("safe: key store is closed")
 //
 // Implementations:
 //   - fileGuard  — derives a wrapping key from a passphrase via HKDF
+//   - PassphraseGuard — stretches a human passphrase via StretchKey at a recorded, upgradable cost
+//   - ThresholdGuard — derives a wrapping key from a secret recovered from k of n Shamir shares
 type Guard interface {
 
 	// Info returns metadata about this Guard's capabilities.
@@ -55,6 +59,18 @@ type Guard interface {
 	Close() error
 }
 
+// GuardRewrapper is implemented by a Guard whose wraps can fall behind its
+// current settings (PassphraseGuard, after its stretch cost is raised).
+// OpenEnclave offers each unwrapped DEK back to it and persists any re-wrap at
+// once; UnwrapDEK itself never modifies the WrappedDEK it is given.
+type GuardRewrapper interface {
+
+	// RewrapDEK returns dek wrapped anew under the Guard's current settings, or
+	// nil if wrapped is already current.  The DEK is unchanged, so the tome it
+	// seals stays valid under either wrap.
+	RewrapDEK(ctx context.Context, wrapped *WrappedDEK, dek []byte, aad []byte) (*WrappedDEK, error)
+}
+
 // TomeStore persists and retrieves a SealedTome.
 //
 // Implementations:
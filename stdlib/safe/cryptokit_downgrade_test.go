@@ -8,7 +8,8 @@ import (
 	"github.com/art-media-platform/amp.SDK/stdlib/status"
 	"github.com/art-media-platform/amp.SDK/stdlib/tag"
 
-	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/poly25519" // register the Poly25519 suite
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/poly25519"      // register the Poly25519 suite
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/x25519mlkem768" // register the hybrid X25519MLKEM768 suite
 )
 
 // TestCryptoKit_DowngradeFailsClosed proves that an open suite namespace is NOT
@@ -55,3 +56,55 @@ func TestCryptoKit_DowngradeFailsClosed(t *testing.T) {
 		t.Fatalf("downgrade under an unregistered suite must fail closed (ItemNotFound); got %v", err)
 	}
 }
+
+// TestCryptoKit_HybridWrapDowngradeFailsClosed is the key-wrap counterpart: an
+// epoch key wrapped under the hybrid X25519MLKEM768 suite cannot be peeled back
+// to its classical half.  Opening the wrap as a Poly25519 box with only the
+// member's X25519 scalar fails, sealing to a hybrid public key under Poly25519
+// is refused outright, and naming an unregistered suite fails closed.
+func TestCryptoKit_HybridWrapDowngradeFailsClosed(t *testing.T) {
+	hybridID := safe.Crypto.X25519MLKEM768.ID
+	hybrid, err := safe.CryptoKit(hybridID)
+	if err != nil {
+		t.Fatalf("X25519MLKEM768 suite must be registered: %v", err)
+	}
+	classical, err := safe.CryptoKit(safe.Crypto.Poly25519.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kp := safe.KeyPair{
+		Pub: safe.PubKey{CryptoKitID: hybridID, KeyType: safe.KeyType_AsymmetricKey},
+	}
+	if err := hybrid.Encrypt.Generate(rand.Reader, &kp); err != nil {
+		t.Fatalf("generate hybrid key: %v", err)
+	}
+	dek := make([]byte, safe.DEKSize)
+	if _, err := rand.Read(dek); err != nil {
+		t.Fatal(err)
+	}
+	wrapped, err := safe.SealFor(hybridID, kp.Pub.Bytes, dek)
+	if err != nil {
+		t.Fatalf("hybrid wrap: %v", err)
+	}
+
+	// Honest path: the hybrid suite unwraps.
+	if _, err := hybrid.Encrypt.Open(wrapped, kp.Prv); err != nil {
+		t.Fatalf("honest unwrap under X25519MLKEM768 must pass: %v", err)
+	}
+
+	// Downgrade attempt: the X25519 half alone does not open the wrap.
+	if _, err := classical.Encrypt.Open(wrapped, kp.Prv[:32]); err == nil {
+		t.Fatal("hybrid wrap must not open as a Poly25519 box under the X25519 half")
+	}
+
+	// A hybrid public key is not a classical one: Poly25519 refuses to seal to it.
+	if _, err := safe.SealFor(safe.Crypto.Poly25519.ID, kp.Pub.Bytes, dek); status.GetCode(err) != status.Code_BadKeyFormat {
+		t.Fatalf("Poly25519 seal to a hybrid public key must fail (BadKeyFormat); got %v", err)
+	}
+
+	bogus := tag.HashName("net.attacker.downgrade").ID
+	if _, err := safe.SealFor(bogus, kp.Pub.Bytes, dek); status.GetCode(err) != status.Code_ItemNotFound {
+		t.Fatalf("wrap under an unregistered suite must fail closed (ItemNotFound); got %v", err)
+	}
+}
@@ -106,6 +106,24 @@ func OpenEnclave(
 	for _, rec := range tome.Keys {
 		enc.mergeRecord(rec)
 	}
+
+	// A GuardRewrapper may have outgrown the stored wrap (PassphraseGuard
+	// raising its stretch cost).  Persist the upgrade now rather than at the
+	// next reseal.  A store that cannot take it could not persist the session's
+	// keys either, so the failure fails the open; the stored tome is untouched.
+	if rewrapper, ok := guard.(GuardRewrapper); ok {
+		rewrapped, err := rewrapper.RewrapDEK(ctx, sealed.WrappedDEK, dek, enc.aad)
+		if err != nil {
+			return nil, fmt.Errorf("safe: failed to re-wrap DEK: %w", err)
+		}
+		if rewrapped != nil {
+			upgraded := proto.Clone(sealed).(*SealedTome)
+			upgraded.WrappedDEK = rewrapped
+			if err := store.Save(ctx, upgraded); err != nil {
+				return nil, fmt.Errorf("safe: failed to save re-wrapped DEK: %w", err)
+			}
+		}
+	}
 	return enc, nil
 }
 
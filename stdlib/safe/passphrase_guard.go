@@ -0,0 +1,288 @@
+package safe
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+const (
+	// StretchKDFName identifies StretchKey in a WrappedDEK's KDF field.
+	StretchKDFName = "Argon2id"
+
+	// CalibrateMaxMemoryKiB caps the memory cost CalibrateStretch will choose
+	// (256 MiB); beyond it, calibration raises iterations instead.
+	CalibrateMaxMemoryKiB = 256 * 1024
+
+	// maxStretchMemoryKiB bounds the memory cost a WrappedDEK may demand (4 GiB),
+	// so a doctored tome cannot exhaust memory at unlock.
+	maxStretchMemoryKiB = 4 * 1024 * 1024
+
+	// maxStretchTime and maxStretchThreads likewise bound the iterations and
+	// lanes, so a doctored tome cannot pin UnwrapDEK in Argon2id.
+	maxStretchTime    = 64
+	maxStretchThreads = 16
+
+	stretchParamsSize       = 4 + 4 + 1 + 4 // MemoryKiB, Time, Threads, KeyLen
+	passphraseGuardProvider = "passphraseGuard"
+)
+
+// PassphraseGuard implements Guard with a human passphrase hardened by
+// StretchKey (Argon2id), for a member who unlocks by typing rather than
+// holding raw root-key bytes (compare NewFileGuard).
+//
+// Each WrappedDEK records its own salt (Salt), KDF (KDF) and cost
+// (ProviderParams), so a tome always unlocks under the cost it was sealed
+// with.  New wraps use the guard's current cost, and RewrapDEK (see
+// GuardRewrapper) upgrades a wrap sealed under a lower one — OpenEnclave calls
+// it on every unlock and persists the upgrade at once.  No cost is ever below
+// DefaultStretchParams: the guard raises its own to that floor, and UnwrapDEK
+// refuses a WrappedDEK recording less.
+type PassphraseGuard struct {
+	mu         sync.Mutex
+	passphrase []byte        // zeroed on Close
+	keyID      []byte        // stable identifier for this guard instance
+	params     StretchParams // cost for new wraps
+	rand       io.Reader
+}
+
+// stretchFloor is the least cost a PassphraseGuard wraps or unwraps under.
+// Tests lower it to keep Argon2id cheap.
+var stretchFloor = DefaultStretchParams
+
+var (
+	_ Guard          = (*PassphraseGuard)(nil)
+	_ GuardRewrapper = (*PassphraseGuard)(nil)
+)
+
+// NewPassphraseGuard creates a Guard backed by a passphrase, stretched under
+// params for new wraps — DefaultStretchParams, or CalibrateStretch's pick for
+// this device.  keyID tags every WrappedDEK produced by this guard.
+func NewPassphraseGuard(passphrase, keyID []byte, params StretchParams) *PassphraseGuard {
+	return &PassphraseGuard{
+		passphrase: append([]byte(nil), passphrase...),
+		keyID:      append([]byte(nil), keyID...),
+		params:     boundStretch(params),
+		rand:       RandReader,
+	}
+}
+
+// Params returns the cost applied to new wraps.
+func (g *PassphraseGuard) Params() StretchParams {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.params
+}
+
+// SetParams sets the cost applied to new wraps, e.g. after recalibrating on
+// faster hardware.  Wraps under a lower cost upgrade on their next unlock
+// (RewrapDEK), provided no dimension of the new cost is lower.
+func (g *PassphraseGuard) SetParams(params StretchParams) {
+	params = boundStretch(params)
+	g.mu.Lock()
+	g.params = params
+	g.mu.Unlock()
+}
+
+// boundStretch clamps params into the range decodeStretchParams accepts: no
+// lower than stretchFloor, no higher than the max bounds.
+func boundStretch(params StretchParams) StretchParams {
+	params.KeyLen = DEKSize // the stretched key is the wrapping key
+	params.Time = min(max(params.Time, stretchFloor.Time), maxStretchTime)
+	params.Threads = min(max(params.Threads, stretchFloor.Threads), maxStretchThreads)
+	params.MemoryKiB = min(max(params.MemoryKiB, stretchFloor.MemoryKiB, 8*uint32(params.Threads)), maxStretchMemoryKiB)
+	return params
+}
+
+func (g *PassphraseGuard) Info(_ context.Context) (*GuardInfo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return &GuardInfo{
+		Provider:       "passphrase",
+		Label:          fmt.Sprintf("Passphrase guard (%s, %d MiB, t=%d)", StretchKDFName, g.params.MemoryKiB/1024, g.params.Time),
+		KeyID:          g.keyID,
+		HardwareBacked: false,
+		Removable:      false,
+		ExportableRoot: true,
+	}, nil
+}
+
+func (g *PassphraseGuard) WrapDEK(_ context.Context, dek []byte, aad []byte) (*WrappedDEK, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.passphrase) == 0 {
+		return nil, status.Code_NotReady.Error("safe: passphraseGuard is closed")
+	}
+	wrapped := &WrappedDEK{
+		Version:  uint32(Const_SealedTomeVersion),
+		Provider: passphraseGuardProvider,
+		KeyID:    g.keyID,
+	}
+	if err := g.sealLocked(wrapped, dek, aad); err != nil {
+		return nil, err
+	}
+	return wrapped, nil
+}
+
+func (g *PassphraseGuard) UnwrapDEK(_ context.Context, wrapped *WrappedDEK, aad []byte) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.passphrase) == 0 {
+		return nil, status.Code_NotReady.Error("safe: passphraseGuard is closed")
+	}
+	if wrapped == nil {
+		return nil, status.Code_BadValue.Error("safe: nil WrappedDEK")
+	}
+	if wrapped.Provider != passphraseGuardProvider {
+		return nil, status.Code_BadValue.Errorf("safe: WrappedDEK provider mismatch: got %q, want %q", wrapped.Provider, passphraseGuardProvider)
+	}
+	if !bytesEqual(wrapped.KeyID, g.keyID) {
+		return nil, status.Code_BadValue.Error("safe: WrappedDEK KeyID mismatch")
+	}
+	if wrapped.KDF != StretchKDFName {
+		return nil, status.Code_BadValue.Errorf("safe: WrappedDEK KDF %q is not %s", wrapped.KDF, StretchKDFName)
+	}
+	stored, err := decodeStretchParams(wrapped.ProviderParams)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappingKey := StretchKey(g.passphrase, wrapped.Salt, stored)
+	defer Zero(wrappingKey)
+	return OpenAEAD(wrappingKey, wrapped.Nonce, wrapped.Cipherblob, aad)
+}
+
+// RewrapDEK re-wraps dek under the guard's current cost when that cost raises
+// wrapped's — higher in memory or iterations and lower in neither, so an
+// upgrade never trades one dimension away for the other.  Otherwise it
+// returns nil.
+func (g *PassphraseGuard) RewrapDEK(_ context.Context, wrapped *WrappedDEK, dek []byte, aad []byte) (*WrappedDEK, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.passphrase) == 0 {
+		return nil, status.Code_NotReady.Error("safe: passphraseGuard is closed")
+	}
+	stored, err := decodeStretchParams(wrapped.GetProviderParams())
+	if err != nil {
+		return nil, err
+	}
+	current := g.params
+	if current.MemoryKiB < stored.MemoryKiB || current.Time < stored.Time {
+		return nil, nil
+	}
+	if current.MemoryKiB == stored.MemoryKiB && current.Time == stored.Time {
+		return nil, nil
+	}
+	rewrapped := &WrappedDEK{
+		Version:  uint32(Const_SealedTomeVersion),
+		Provider: passphraseGuardProvider,
+		KeyID:    g.keyID,
+	}
+	if err := g.sealLocked(rewrapped, dek, aad); err != nil {
+		return nil, err
+	}
+	return rewrapped, nil
+}
+
+func (g *PassphraseGuard) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	Zero(g.passphrase)
+	g.passphrase = nil
+	return nil
+}
+
+// sealLocked wraps dek into wrapped under the guard's current cost and a fresh
+// salt.  Caller holds g.mu.
+func (g *PassphraseGuard) sealLocked(wrapped *WrappedDEK, dek, aad []byte) error {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(g.rand, salt); err != nil {
+		return status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	wrappingKey := StretchKey(g.passphrase, salt, g.params)
+	defer Zero(wrappingKey)
+
+	nonce, cipherblob, err := SealAEAD(g.rand, wrappingKey, dek, aad)
+	if err != nil {
+		return err
+	}
+	wrapped.KDF = StretchKDFName
+	wrapped.Cipher = CipherName
+	wrapped.Salt = salt
+	wrapped.Nonce = nonce
+	wrapped.ProviderParams = encodeStretchParams(g.params)
+	wrapped.Cipherblob = cipherblob
+	return nil
+}
+
+// CalibrateStretch returns the cost at which one StretchKey on this device takes
+// about target, never below DefaultStretchParams nor above the bounds UnwrapDEK
+// accepts.  Memory cost doubles first
+// (memory-hardness is what an attacker's hardware pays for), up to
+// CalibrateMaxMemoryKiB; iterations then scale to close the remaining gap.
+// Calibration itself spends roughly twice target.
+func CalibrateStretch(target time.Duration) StretchParams {
+	params := DefaultStretchParams
+	for {
+		elapsed := timeStretch(params)
+		if elapsed >= target {
+			return params
+		}
+		if params.MemoryKiB*2 <= CalibrateMaxMemoryKiB {
+			params.MemoryKiB *= 2
+			continue
+		}
+		scale := float64(target) / float64(max(elapsed, time.Microsecond))
+		params.Time = uint32(min(float64(params.Time)*scale+0.999, maxStretchTime))
+		return params
+	}
+}
+
+// timeStretch measures one StretchKey under params.
+func timeStretch(params StretchParams) time.Duration {
+	var probe [SaltSize]byte
+	start := time.Now()
+	Zero(StretchKey(probe[:], probe[:], params))
+	return time.Since(start)
+}
+
+// encodeStretchParams returns params in WrappedDEK.ProviderParams form:
+// MemoryKiB (4) ‖ Time (4) ‖ Threads (1) ‖ KeyLen (4), big-endian.
+func encodeStretchParams(params StretchParams) []byte {
+	buf := make([]byte, 0, stretchParamsSize)
+	buf = binary.BigEndian.AppendUint32(buf, params.MemoryKiB)
+	buf = binary.BigEndian.AppendUint32(buf, params.Time)
+	buf = append(buf, params.Threads)
+	return binary.BigEndian.AppendUint32(buf, params.KeyLen)
+}
+
+func decodeStretchParams(buf []byte) (StretchParams, error) {
+	if len(buf) != stretchParamsSize {
+		return StretchParams{}, status.Code_BadValue.Errorf("safe: stretch params must be %d bytes, got %d", stretchParamsSize, len(buf))
+	}
+	params := StretchParams{
+		MemoryKiB: binary.BigEndian.Uint32(buf[0:4]),
+		Time:      binary.BigEndian.Uint32(buf[4:8]),
+		Threads:   buf[8],
+		KeyLen:    binary.BigEndian.Uint32(buf[9:13]),
+	}
+	switch {
+	case params.KeyLen != DEKSize:
+		return StretchParams{}, status.Code_BadValue.Errorf("safe: stretch key length %d is not %d", params.KeyLen, DEKSize)
+	case params.Time < stretchFloor.Time || params.Time > maxStretchTime:
+		return StretchParams{}, status.Code_BadValue.Errorf("safe: stretch time cost %d is out of range", params.Time)
+	case params.Threads < stretchFloor.Threads || params.Threads > maxStretchThreads:
+		return StretchParams{}, status.Code_BadValue.Errorf("safe: stretch threads %d is out of range", params.Threads)
+	case params.MemoryKiB < max(stretchFloor.MemoryKiB, 8*uint32(params.Threads)) || params.MemoryKiB > maxStretchMemoryKiB:
+		return StretchParams{}, status.Code_BadValue.Errorf("safe: stretch memory cost %d KiB is out of range", params.MemoryKiB)
+	}
+	return params, nil
+}
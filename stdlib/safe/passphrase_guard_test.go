@@ -0,0 +1,202 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+	"google.golang.org/protobuf/proto"
+)
+
+// cheapStretch keeps tests fast; real guards use DefaultStretchParams or
+// CalibrateStretch.
+var cheapStretch = StretchParams{MemoryKiB: 64, Time: 1, Threads: 1}
+
+func init() {
+	stretchFloor = cheapStretch
+}
+
+func TestPassphraseGuard_WrapUnwrap(t *testing.T) {
+	ctx := context.Background()
+	guard := NewPassphraseGuard([]byte("correct horse"), []byte("member"), cheapStretch)
+	defer guard.Close()
+
+	dek, _ := GenerateDEK(RandReader)
+	aad := []byte("tome")
+	wrapped, err := guard.WrapDEK(ctx, dek, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, wrapped.KDF, StretchKDFName)
+	status.Require(t, len(wrapped.Salt), SaltSize)
+	stored, err := decodeStretchParams(wrapped.ProviderParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, stored, guard.Params())
+
+	got, err := guard.UnwrapDEK(ctx, wrapped, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(got, dek), true)
+
+	wrong := NewPassphraseGuard([]byte("battery staple"), []byte("member"), cheapStretch)
+	defer wrong.Close()
+	if _, err := wrong.UnwrapDEK(ctx, wrapped, aad); err == nil {
+		t.Fatal("unwrap under the wrong passphrase must fail")
+	}
+
+	// A doctored cost must not reach Argon2id.
+	doctored := proto.Clone(wrapped).(*WrappedDEK)
+	doctored.ProviderParams = encodeStretchParams(StretchParams{MemoryKiB: 1 << 30, Time: 1, Threads: 1, KeyLen: DEKSize})
+	if _, err := guard.UnwrapDEK(ctx, doctored, aad); status.GetCode(err) != status.Code_BadValue {
+		t.Fatalf("out-of-range memory cost must be refused; got %v", err)
+	}
+}
+
+// TestPassphraseGuard_UpgradeOnUnlock seals a tome at a low cost, then opens it
+// with the guard raised to a higher one: the wrap is upgraded in place and
+// persisted, and still opens.
+func TestPassphraseGuard_UpgradeOnUnlock(t *testing.T) {
+	ctx := context.Background()
+	aad := []byte("upgrade-test")
+	store := NewLocalTomeStore(filepath.Join(t.TempDir(), "member.tome"))
+	guard := NewPassphraseGuard([]byte("correct horse"), []byte("member"), cheapStretch)
+	defer guard.Close()
+
+	enc, err := OpenEnclave(ctx, store, guard, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyringID := tag.NewID()
+	kp := KeyPair{Pub: PubKey{KeyType: KeyType_SymmetricKey, Bytes: bytes.Repeat([]byte{0x2A}, 32)}, Prv: make([]byte, 32)}
+	if err := enc.ImportKey(ctx, keyringID, kp); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := store.Load(ctx)
+
+	raised := cheapStretch
+	raised.MemoryKiB *= 2
+	raised.Time++
+	guard.SetParams(raised)
+
+	enc, err = OpenEnclave(ctx, store, guard, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, _ := store.Load(ctx)
+	stored, err := decodeStretchParams(after.WrappedDEK.ProviderParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, stored, guard.Params())
+	status.Require(t, bytes.Equal(after.WrappedDEK.Salt, before.WrappedDEK.Salt), false)
+	status.Require(t, bytes.Equal(after.Cipherblob, before.Cipherblob), true) // same DEK, same tome
+	enc.Close(ctx)
+
+	// Unwrapping never touches the caller's WrappedDEK, and a wrap at the
+	// guard's cost is left alone.
+	sealed, _ := store.Load(ctx)
+	prior := proto.Clone(sealed.WrappedDEK).(*WrappedDEK)
+	dek, err := guard.UnwrapDEK(ctx, sealed.WrappedDEK, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Zero(dek)
+	status.Require(t, proto.Equal(sealed.WrappedDEK, prior), true)
+	rewrapped, err := guard.RewrapDEK(ctx, sealed.WrappedDEK, dek, aad)
+	status.Require(t, err, nil)
+	status.Require(t, rewrapped == nil, true)
+
+	// Nor is one whose cost the guard's exceeds in one dimension but not the
+	// other: an upgrade never lowers either.
+	for _, params := range []StretchParams{
+		{MemoryKiB: raised.MemoryKiB * 2, Time: raised.Time - 1, Threads: 1},
+		{MemoryKiB: raised.MemoryKiB / 2, Time: raised.Time + 1, Threads: 1},
+	} {
+		guard.SetParams(params)
+		rewrapped, err := guard.RewrapDEK(ctx, sealed.WrappedDEK, dek, aad)
+		status.Require(t, err, nil)
+		status.Require(t, rewrapped == nil, true)
+	}
+}
+
+// failingSave is a TomeStore whose Save always fails.
+type failingSave struct {
+	TomeStore
+}
+
+func (failingSave) Save(context.Context, *SealedTome) error {
+	return status.Code_StorageFailure.Error("read-only")
+}
+
+// TestPassphraseGuard_UpgradeSaveFails checks that OpenEnclave reports a
+// failure to persist an upgraded wrap, and leaves the stored tome as it was.
+func TestPassphraseGuard_UpgradeSaveFails(t *testing.T) {
+	ctx := context.Background()
+	aad := []byte("upgrade-test")
+	store := NewLocalTomeStore(filepath.Join(t.TempDir(), "member.tome"))
+	guard := NewPassphraseGuard([]byte("correct horse"), []byte("member"), cheapStretch)
+	defer guard.Close()
+
+	enc, err := OpenEnclave(ctx, store, guard, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := store.Load(ctx)
+
+	raised := cheapStretch
+	raised.Time++
+	guard.SetParams(raised)
+	_, err = OpenEnclave(ctx, failingSave{store}, guard, aad)
+	var failure *status.Status
+	if !errors.As(err, &failure) || failure.Code != status.Code_StorageFailure {
+		t.Fatalf("a failed upgrade save must fail the open; got %v", err)
+	}
+	after, _ := store.Load(ctx)
+	status.Require(t, proto.Equal(after, before), true)
+}
+
+// TestPassphraseGuard_StretchBounds checks that a guard never wraps below the
+// floor, and that a recorded cost below it or past the bounds is refused.
+func TestPassphraseGuard_StretchBounds(t *testing.T) {
+	defer func(floor StretchParams) { stretchFloor = floor }(stretchFloor)
+	stretchFloor = DefaultStretchParams
+
+	guard := NewPassphraseGuard([]byte("correct horse"), []byte("member"), cheapStretch)
+	defer guard.Close()
+	status.Require(t, guard.Params(), DefaultStretchParams)
+
+	for _, params := range []StretchParams{
+		{MemoryKiB: 64, Time: 1, Threads: 1},
+		{MemoryKiB: DefaultStretchParams.MemoryKiB, Time: 1 << 31, Threads: 1},
+		{MemoryKiB: DefaultStretchParams.MemoryKiB, Time: 2, Threads: 255},
+	} {
+		params.KeyLen = DEKSize
+		if _, err := decodeStretchParams(encodeStretchParams(params)); status.GetCode(err) != status.Code_BadValue {
+			t.Fatalf("cost %+v must be refused; got %v", params, err)
+		}
+	}
+}
+
+func TestCalibrateStretch(t *testing.T) {
+	status.Require(t, CalibrateStretch(0), DefaultStretchParams)
+
+	base := timeStretch(DefaultStretchParams)
+	params := CalibrateStretch(3 * base)
+	if params.MemoryKiB <= DefaultStretchParams.MemoryKiB || params.Time < DefaultStretchParams.Time {
+		t.Fatalf("calibrating to 3x the default cost must raise it; got %+v", params)
+	}
+	status.Require(t, params.KeyLen, DefaultStretchParams.KeyLen)
+}
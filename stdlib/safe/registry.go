@@ -18,28 +18,44 @@ import (
 // HashSpec carries a func field, so only its pointer has an identity Register can
 // dedup by.
 type Registry[K comparable, V any] struct {
-	label string // descriptor kind, woven into messages ("CryptoKit", "HashKit")
-	byID  map[K]*V
+	label    string // descriptor kind, woven into messages ("CryptoKit", "HashKit")
+	byID     map[K]*V
+	defaults map[K]bool // keys still holding a built-in default an explicit Register may replace
 }
 
 // newRegistry returns an empty Registry that labels its descriptors in messages.
 func newRegistry[K comparable, V any](label string) Registry[K, V] {
 	return Registry[K, V]{
-		label: label,
-		byID:  map[K]*V{},
+		label:    label,
+		byID:     map[K]*V{},
+		defaults: map[K]bool{},
 	}
 }
 
 // Register inserts val under key.  It must be called from init().  Re-registering
-// the same pointer is a no-op; a different *V under an in-use key is an error.
+// the same pointer is a no-op; a different *V under an in-use key is an error,
+// unless that key still holds a built-in default (registerDefault), which the
+// first explicit registration replaces.
 func (reg *Registry[K, V]) Register(key K, val *V) error {
-	if existing := reg.byID[key]; existing != nil && existing != val {
+	if existing := reg.byID[key]; existing != nil && existing != val && !reg.defaults[key] {
 		return status.Code_AlreadyRegistered.Errorf("%s %v is already registered", reg.label, key)
 	}
+	delete(reg.defaults, key)
 	reg.byID[key] = val
 	return nil
 }
 
+// registerDefault inserts val under key as a built-in default: it serves lookups
+// until an explicit Register for the same key replaces it.  Defaults register
+// from this package's init(), which runs before any importer's.
+func (reg *Registry[K, V]) registerDefault(key K, val *V) error {
+	if err := reg.Register(key, val); err != nil {
+		return err
+	}
+	reg.defaults[key] = true
+	return nil
+}
+
 // Get returns the *V registered under key, failing closed if none is.
 func (reg *Registry[K, V]) Get(key K) (*V, error) {
 	if val := reg.byID[key]; val != nil {
@@ -71,11 +87,21 @@ func CryptoKit(cryptoKitID CryptoKitID) (*Kit, error) { return gCryptoKits.Get(c
 
 // RegisterHashKit registers spec so it can be retrieved via GetHashKit / NewHashKit.
 // It must be called from init().  A kit must yield at least minHashKitDigest bytes.
+// An explicit registration replaces this package's built-in default for the same
+// ID (e.g. a SIMD BLAKE3 over the pure-Go one); a second explicit one is an error.
 func RegisterHashKit(spec *HashSpec) error {
+	if err := checkHashSpec(spec); err != nil {
+		return err
+	}
+	return gHashKits.Register(spec.ID, spec)
+}
+
+// checkHashSpec rejects a spec whose digest is too short to content-address with.
+func checkHashSpec(spec *HashSpec) error {
 	if spec.Size < minHashKitDigest {
 		return status.Code_BadRequest.Errorf("HashKit %v digest size %d < required %d", spec.ID, spec.Size, minHashKitDigest)
 	}
-	return gHashKits.Register(spec.ID, spec)
+	return nil
 }
 
 // GetHashKit fetches a registered HashSpec, failing closed if the kit's package
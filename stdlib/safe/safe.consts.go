@@ -7,13 +7,15 @@ package safe
 import "github.com/art-media-platform/amp.SDK/stdlib/tag"
 
 var Crypto = struct {
-	Poly25519 tag.Name
-	P256      tag.Name
-	Secp256k1 tag.Name
+	Poly25519      tag.Name
+	P256           tag.Name
+	Secp256k1      tag.Name
+	X25519MLKEM768 tag.Name
 }{
-	Poly25519: tag.Name{ID: tag.UID{0x629134B84734DA6F, 0xCC870AD1BB12E1E6}, Text: "amp.crypto.poly25519"}, // 32k-4uchjtnv9r-wt1sbu6xj5-sg6
-	P256:      tag.Name{ID: tag.UID{0x188DC92BB63ACCBD, 0xE22053F8FFA3A09F}, Text: "amp.crypto.p256"},      // 0sj-r4krejutky-y482mz3zu7-84z
-	Secp256k1: tag.Name{ID: tag.UID{0x1906FAF4F88AB854, 0x6328B92D89014254}, Text: "amp.crypto.secp256k1"}, // 0t0-vxg9y4br1b-66b5t5q4h2-hkn
+	Poly25519:      tag.Name{ID: tag.UID{0x629134B84734DA6F, 0xCC870AD1BB12E1E6}, Text: "amp.crypto.poly25519"},       // 32k-4uchjtnv9r-wt1sbu6xj5-sg6
+	P256:           tag.Name{ID: tag.UID{0x188DC92BB63ACCBD, 0xE22053F8FFA3A09F}, Text: "amp.crypto.p256"},            // 0sj-r4krejutky-y482mz3zu7-84z
+	Secp256k1:      tag.Name{ID: tag.UID{0x1906FAF4F88AB854, 0x6328B92D89014254}, Text: "amp.crypto.secp256k1"},       // 0t0-vxg9y4br1b-66b5t5q4h2-hkn
+	X25519MLKEM768: tag.Name{ID: tag.UID{0x7C1DB612AAD4C0A5, 0x59C92A44C2D0C0CE}, Text: "amp.crypto.x25519-mlkem768"}, // 3w3-qv15bqns2k-pmk9b8m1e1-h6f
 }
 
 // ─── Phrase — the canonical safe.Phrase vocabulary (SD-did-identity §12.1): a ───
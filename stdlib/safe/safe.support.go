@@ -2,10 +2,13 @@ package safe
 
 import (
 	"crypto/sha256"
+	"encoding"
 	"fmt"
 	"hash"
 
+	"github.com/art-media-platform/amp.SDK/stdlib/blake3"
 	"github.com/art-media-platform/amp.SDK/stdlib/encode"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
 	"github.com/art-media-platform/amp.SDK/stdlib/tag"
 	"golang.org/x/crypto/blake2s"
 	"golang.org/x/crypto/sha3"
@@ -116,6 +119,32 @@ type HashSpec struct {
 	ID   HashKitID
 	New  func() hash.Hash
 	Size int
+
+	// Parallel marks a tree-mode hash (BLAKE3): digests of independent inputs
+	// are cheap to fan across cores, so bulk hashers (e.g. blob grain runs) may.
+	Parallel bool
+}
+
+// MarshalState captures the hasher's running state (encoding.BinaryMarshaler)
+// so a long stream can checkpoint and later resume under UnmarshalState.
+func (kit HashKit) MarshalState() ([]byte, error) {
+	marshaler, ok := kit.Hasher.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, status.Code_Unimplemented.Errorf("safe: HashKit %v cannot checkpoint its state", kit.HashKitID)
+	}
+	return marshaler.MarshalBinary()
+}
+
+// UnmarshalState restores a state MarshalState captured under the same kit.
+func (kit HashKit) UnmarshalState(state []byte) error {
+	unmarshaler, ok := kit.Hasher.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return status.Code_Unimplemented.Errorf("safe: HashKit %v cannot resume a checkpoint", kit.HashKitID)
+	}
+	if err := unmarshaler.UnmarshalBinary(state); err != nil {
+		return status.Code_DataFailure.Wrap(err)
+	}
+	return nil
 }
 
 // NewHashKit returns a live HashKit (a fresh hasher) for the requested kit.
@@ -135,14 +164,25 @@ func NewHashKit(hashKitID HashKitID) (HashKit, error) {
 	return kit, nil
 }
 
-// The dependency-light content hashes register from this package's init().
-// BLAKE3 pulls a SIMD dependency and so registers from amp.planet (blank import).
+// The content hashes register from this package's init() as built-in defaults.
+// BLAKE3 is the pure-Go stdlib/blake3, so registering it pulls no SIMD dependency.
+//
+// Migration: BLAKE3 used to register only from amp.planet (blank import of a SIMD
+// implementation).  That registration still works unchanged — RegisterHashKit
+// lets it replace the default here, and both yield identical digests — but it
+// should set Parallel to keep bulk hashers fanning grains across cores.
 func init() {
 	mustRegisterHashKit(&HashSpec{
 		ID:   HashKitID_Blake2s_256,
 		New:  func() hash.Hash { h, _ := blake2s.New256(nil); return h },
 		Size: 32,
 	})
+	mustRegisterHashKit(&HashSpec{
+		ID:       HashKitID_Blake3_256,
+		New:      func() hash.Hash { return blake3.New() },
+		Size:     blake3.Size,
+		Parallel: true,
+	})
 	mustRegisterHashKit(&HashSpec{
 		ID:   HashKitID_SHA2_256,
 		New:  sha256.New,
@@ -155,10 +195,15 @@ func init() {
 	})
 }
 
-// mustRegisterHashKit panics if registration fails — an ID collision in init() is
-// a build-time programming error, not a runtime condition.
+// mustRegisterHashKit registers spec as a built-in default, panicking if that
+// fails — an ID collision in init() is a build-time programming error, not a
+// runtime condition.
 func mustRegisterHashKit(spec *HashSpec) {
-	if err := RegisterHashKit(spec); err != nil {
+	err := checkHashSpec(spec)
+	if err == nil {
+		err = gHashKits.registerDefault(spec.ID, spec)
+	}
+	if err != nil {
 		panic(err)
 	}
 }
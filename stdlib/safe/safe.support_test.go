@@ -6,6 +6,8 @@ import (
 	"math/big"
 	math_rand "math/rand"
 	"testing"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
 )
 
 var gTesting *testing.T
@@ -163,3 +165,24 @@ func testKitWithSizes(kit *Kit, keyLen, msgLen int) {
 		}
 	}
 }
+
+func TestRegistryReplacesDefault(t *testing.T) {
+	reg := newRegistry[HashKitID, HashSpec]("HashKit")
+	builtin, host, other := &HashSpec{ID: HashKitID_Blake3_256}, &HashSpec{ID: HashKitID_Blake3_256}, &HashSpec{ID: HashKitID_Blake3_256}
+
+	if err := reg.registerDefault(builtin.ID, builtin); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.Register(host.ID, host); err != nil {
+		t.Fatalf("explicit registration should replace the default: %v", err)
+	}
+	if got, _ := reg.Get(host.ID); got != host {
+		t.Fatal("lookup should resolve the explicit registration")
+	}
+	if err := reg.Register(host.ID, host); err != nil {
+		t.Fatalf("re-registering the same spec should be a no-op: %v", err)
+	}
+	if code := status.GetCode(reg.Register(other.ID, other)); code != status.Code_AlreadyRegistered {
+		t.Fatalf("second explicit registration: got %v, want AlreadyRegistered", code)
+	}
+}
@@ -0,0 +1,297 @@
+// Package secp256k1 registers the secp256k1 CryptoKit with the safe package.
+//
+// This kit provides:
+//   - Symmetric encryption: XChaCha20-Poly1305 (shared with Poly25519 for content interop)
+//   - Asymmetric encryption: ECDH-secp256k1 + HKDF + XChaCha20-Poly1305
+//   - Signing: recoverable ECDSA-secp256k1 over the EIP-191 personal-message hash (EVM wallet compatible)
+//
+// As with P-256, a single 32-byte scalar serves as both the signing private key
+// and the key-agreement private key, so the key an EVM wallet signs with can
+// also receive sealed content.  Public keys are emitted in the 33-byte SEC1
+// compressed form (the did:key encoding); the 65-byte uncompressed form is
+// accepted wherever a public key is an input.
+//
+// The kit signs and verifies PersonalMessageHash(msg) — what a wallet's
+// personal_sign covers — so a wallet holding the key can produce a kit
+// signature itself: personal_sign over the same bytes verifies here, and the
+// kit's own signature over them matches the wallet's byte for byte.
+//
+// Kit signatures are 65 bytes, r ‖ s ‖ v with v the recovery id (0 or 1) — the
+// layout Ethereum's ecrecover takes — with s normalized to the lower half of the
+// group order; a wallet's 27/28 v verifies too.  SignRecoverable and
+// RecoverPubKey expose the same format over a caller-computed hash.
+//
+// Import this package (typically via blank import) to register the kit:
+//
+//	import _ "github.com/art-media-platform/amp.SDK/stdlib/safe/secp256k1"
+package secp256k1
+
+import (
+	"encoding/hex"
+	"io"
+	"strconv"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// PrvKeySize is the raw big-endian scalar length.
+	PrvKeySize = 32
+
+	// PubKeySize is the SEC1 compressed public-key length: 0x02|0x03 || X.
+	PubKeySize = 33
+
+	// UncompressedPubKeySize is the SEC1 uncompressed public-key length: 0x04 || X || Y.
+	UncompressedPubKeySize = 65
+
+	// HashSize is the length of the hash a recoverable signature covers.
+	HashSize = 32
+
+	// SignatureSize is the recoverable r||s||v length (32 + 32 + 1).
+	SignatureSize = 65
+
+	// compactMagic is the recovery-code offset of a compact signature whose
+	// key is compressed (27 + 4); see ecdsa.SignCompact.
+	compactMagic = 27 + 4
+)
+
+func init() {
+	safe.RegisterCryptoKit(&kit)
+}
+
+var kit = safe.Kit{
+	ID: safe.Crypto.Secp256k1.ID,
+	Signing: &safe.SigningOps{
+		SignatureSize: SignatureSize,
+		Generate:      generateKey,
+		Sign:          sign,
+		Verify:        verify,
+	},
+	Encrypt: &safe.EncryptOps{
+		Generate: generateKey,
+		Seal:     seal,
+		Open:     open,
+	},
+}
+
+// generateKey produces a secp256k1 keypair from rng.  Scalars are drawn 32
+// bytes at a time until one lies in [1, N-1], so a deterministic rng (as
+// KeyPairFromPhrase supplies) yields a deterministic key.  The same scalar and
+// compressed public key serve signing and ECDH (kit-unified key model).
+func generateKey(rng io.Reader, kp *safe.KeyPair) error {
+	prv, err := secp256k1.GeneratePrivateKeyFromRand(rng)
+	if err != nil {
+		return status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	defer prv.Zero()
+	kp.Prv = prv.Serialize()
+	kp.Pub.Bytes = prv.PubKey().SerializeCompressed()
+	return nil
+}
+
+// seal encrypts msg for a peer using an ephemeral secp256k1 sender keypair.
+// No sender identity participates; the wrap is anonymous-sender.
+// Output: eph_pub (33) || nonce (24) || ciphertext+tag
+func seal(rng io.Reader, msg, peerPubKey []byte) ([]byte, error) {
+	peer, err := parsePubKey(peerPubKey)
+	if err != nil {
+		return nil, err
+	}
+	eph, err := secp256k1.GeneratePrivateKeyFromRand(rng)
+	if err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	defer eph.Zero()
+	ephPub := eph.PubKey().SerializeCompressed()
+
+	shared, err := ecdhDeriveKey(eph, ephPub, peer)
+	if err != nil {
+		return nil, err
+	}
+	defer safe.Zero(shared)
+
+	nonce, ct, err := safe.SealAEAD(rng, shared, msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(ephPub)+len(nonce)+len(ct))
+	out = append(out, ephPub...)
+	out = append(out, nonce...)
+	out = append(out, ct...)
+	return out, nil
+}
+
+func open(msg, prvKey []byte) ([]byte, error) {
+	if len(msg) < PubKeySize+safe.NonceSize {
+		return nil, status.Code_DecryptFailed.Error("ciphertext too short")
+	}
+	ephPub := msg[:PubKeySize]
+	nonce := msg[PubKeySize : PubKeySize+safe.NonceSize]
+	ct := msg[PubKeySize+safe.NonceSize:]
+
+	prv, err := parsePrvKey(prvKey)
+	if err != nil {
+		return nil, err
+	}
+	defer prv.Zero()
+	eph, err := parsePubKey(ephPub)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ecdhDeriveKey(prv, prv.PubKey().SerializeCompressed(), eph)
+	if err != nil {
+		return nil, err
+	}
+	defer safe.Zero(shared)
+	return safe.OpenAEAD(shared, nonce, ct, nil)
+}
+
+// ecdhDeriveKey computes the ECDH shared secret (the x-coordinate) and derives
+// a symmetric key via safe.DeriveSharedKey over both compressed public keys.
+func ecdhDeriveKey(prv *secp256k1.PrivateKey, prvPub []byte, peer *secp256k1.PublicKey) ([]byte, error) {
+	shared := secp256k1.GenerateSharedSecret(prv, peer)
+	defer safe.Zero(shared)
+	return safe.DeriveSharedKey("ECDH-secp256k1", prvPub, peer.SerializeCompressed(), shared)
+}
+
+func sign(msg []byte, signerPrvKey []byte) ([]byte, error) {
+	hash := PersonalMessageHash(msg)
+	return SignRecoverable(hash[:], signerPrvKey)
+}
+
+func verify(sig []byte, msg []byte, signerPubKey []byte) error {
+	hash := PersonalMessageHash(msg)
+	recovered, err := recoverKey(sig, hash[:])
+	if err != nil {
+		return err
+	}
+	signer, err := parsePubKey(signerPubKey)
+	if err != nil {
+		return err
+	}
+	if !signer.IsEqual(recovered) {
+		return status.Code_VerifySignatureFailed.Error("ECDSA-secp256k1 signature verification failed")
+	}
+	return nil
+}
+
+// SignRecoverable signs a 32-byte hash (RFC 6979 deterministic nonce),
+// returning the 65-byte r ‖ s ‖ v signature with low s and v in {0, 1}.
+func SignRecoverable(hash, signerPrvKey []byte) ([]byte, error) {
+	if len(hash) != HashSize {
+		return nil, status.Code_SigningFailed.Errorf("secp256k1 signs a %d-byte hash, got %d", HashSize, len(hash))
+	}
+	prv, err := parsePrvKey(signerPrvKey)
+	if err != nil {
+		return nil, err
+	}
+	defer prv.Zero()
+
+	compact := ecdsa.SignCompact(prv, hash, true)
+	recoveryID := compact[0] - compactMagic
+	if recoveryID > 1 {
+		// r overflowed the group order: ~2^-127 odds, and not expressible as an EVM v.
+		return nil, status.Code_SigningFailed.Error("secp256k1 signature has no EVM recovery id")
+	}
+	sig := make([]byte, SignatureSize)
+	copy(sig, compact[1:])
+	sig[64] = recoveryID
+	return sig, nil
+}
+
+// RecoverPubKey returns the compressed public key that produced sig over hash.
+// v may be 0/1 or the legacy 27/28 wallets emit; a high-s signature is refused
+// so that each (key, hash) has one valid signature.
+func RecoverPubKey(sig, hash []byte) ([]byte, error) {
+	pub, err := recoverKey(sig, hash)
+	if err != nil {
+		return nil, err
+	}
+	return pub.SerializeCompressed(), nil
+}
+
+func recoverKey(sig, hash []byte) (*secp256k1.PublicKey, error) {
+	if len(sig) != SignatureSize {
+		return nil, status.Code_BadKeyFormat.Errorf("secp256k1 signature must be %d bytes, got %d", SignatureSize, len(sig))
+	}
+	if len(hash) != HashSize {
+		return nil, status.Code_VerifySignatureFailed.Errorf("secp256k1 signature covers a %d-byte hash, got %d", HashSize, len(hash))
+	}
+	recoveryID := sig[64]
+	if recoveryID >= 27 {
+		recoveryID -= 27
+	}
+	if recoveryID > 1 {
+		return nil, status.Code_VerifySignatureFailed.Errorf("secp256k1 signature has bad recovery id %d", sig[64])
+	}
+	var s secp256k1.ModNScalar
+	if overflow := s.SetByteSlice(sig[32:64]); overflow || s.IsOverHalfOrder() {
+		return nil, status.Code_VerifySignatureFailed.Error("secp256k1 signature s is not canonical")
+	}
+
+	var compact [SignatureSize]byte
+	compact[0] = compactMagic + recoveryID
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact[:], hash)
+	if err != nil {
+		return nil, status.Code_VerifySignatureFailed.Wrap(err)
+	}
+	return pub, nil
+}
+
+// Keccak256 returns the legacy (pre-SHA-3) Keccak-256 digest of the given
+// parts — the hash Ethereum uses throughout.
+func Keccak256(parts ...[]byte) [32]byte {
+	hasher := sha3.NewLegacyKeccak256()
+	for _, part := range parts {
+		hasher.Write(part)
+	}
+	var digest [32]byte
+	hasher.Sum(digest[:0])
+	return digest
+}
+
+// PersonalMessageHash returns the EIP-191 hash a wallet's personal_sign covers:
+// Keccak-256("\x19Ethereum Signed Message:\n" ‖ len(msg) ‖ msg).
+func PersonalMessageHash(msg []byte) [32]byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return Keccak256([]byte(prefix), msg)
+}
+
+// Address returns the Ethereum address of a public key (compressed or
+// uncompressed, e.g. as RecoverPubKey returns) as lowercase "0x" hex — the form a wallet MemberID folds
+// ("eth:" + Address).
+func Address(pubKey []byte) (string, error) {
+	pub, err := parsePubKey(pubKey)
+	if err != nil {
+		return "", err
+	}
+	digest := Keccak256(pub.SerializeUncompressed()[1:])
+	return "0x" + hex.EncodeToString(digest[12:]), nil
+}
+
+func parsePrvKey(prvKey []byte) (*secp256k1.PrivateKey, error) {
+	if len(prvKey) != PrvKeySize {
+		return nil, status.Code_BadKeyFormat.Errorf("secp256k1 private key must be %d bytes, got %d", PrvKeySize, len(prvKey))
+	}
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(prvKey); overflow || scalar.IsZero() {
+		return nil, status.Code_BadKeyFormat.Error("secp256k1 private key is out of range")
+	}
+	return secp256k1.NewPrivateKey(&scalar), nil
+}
+
+func parsePubKey(pubKey []byte) (*secp256k1.PublicKey, error) {
+	if len(pubKey) != PubKeySize && len(pubKey) != UncompressedPubKeySize {
+		return nil, status.Code_BadKeyFormat.Errorf("secp256k1 public key must be %d or %d bytes, got %d", PubKeySize, UncompressedPubKeySize, len(pubKey))
+	}
+	pub, err := secp256k1.ParsePubKey(pubKey)
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+	return pub, nil
+}
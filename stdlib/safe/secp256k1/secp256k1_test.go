@@ -0,0 +1,191 @@
+package secp256k1_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe/secp256k1"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+func generate(t *testing.T, keyType safe.KeyType) safe.KeyPair {
+	t.Helper()
+	kit, err := safe.CryptoKit(safe.Crypto.Secp256k1.ID)
+	if err != nil {
+		t.Fatalf("GetKit: %v", err)
+	}
+	kp := safe.KeyPair{
+		Pub: safe.PubKey{CryptoKitID: safe.Crypto.Secp256k1.ID, KeyType: keyType},
+	}
+	if err := kit.Signing.Generate(rand.Reader, &kp); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	return kp
+}
+
+// TestSecp256k1_SignVerify signs through the kit and verifies through the
+// registry, as a TxMsg signature is checked.
+func TestSecp256k1_SignVerify(t *testing.T) {
+	kit, err := safe.CryptoKit(safe.Crypto.Secp256k1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp := generate(t, safe.KeyType_SigningKey)
+	status.Require(t, len(kp.Pub.Bytes), secp256k1.PubKeySize)
+	status.Require(t, len(kp.Prv), secp256k1.PrvKeySize)
+
+	digest := []byte("TxMsg signing digest")
+	sig, err := kit.Signing.Sign(digest, kp.Prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(sig), kit.Signing.SignatureSize)
+	if err := safe.VerifySignature(safe.Crypto.Secp256k1.ID, sig, digest, kp.Pub.Bytes); err != nil {
+		t.Fatalf("VerifySignature: %v", err)
+	}
+
+	// The signature recovers its signer over the personal-message hash.
+	hash := secp256k1.PersonalMessageHash(digest)
+	recovered, err := secp256k1.RecoverPubKey(sig, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(recovered, kp.Pub.Bytes), true)
+
+	bad := append([]byte{}, digest...)
+	bad[0] ^= 0xFF
+	if err := safe.VerifySignature(safe.Crypto.Secp256k1.ID, sig, bad, kp.Pub.Bytes); err == nil {
+		t.Fatal("Verify must reject a tampered digest")
+	}
+	other := generate(t, safe.KeyType_SigningKey)
+	if err := safe.VerifySignature(safe.Crypto.Secp256k1.ID, sig, digest, other.Pub.Bytes); err == nil {
+		t.Fatal("Verify must reject another signer's key")
+	}
+
+	// A flipped recovery id names a different key.
+	flipped := append([]byte{}, sig...)
+	flipped[64] ^= 1
+	if err := safe.VerifySignature(safe.Crypto.Secp256k1.ID, flipped, digest, kp.Pub.Bytes); err == nil {
+		t.Fatal("Verify must reject a flipped recovery id")
+	}
+}
+
+// TestSecp256k1_EthereumVector pins signing and recovery to the web3.js
+// accounts.sign example, an external vector: RFC 6979 makes the signature
+// deterministic, so it must match byte for byte.
+func TestSecp256k1_EthereumVector(t *testing.T) {
+	prv, _ := hex.DecodeString("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	want, _ := hex.DecodeString("b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c")
+
+	hash := secp256k1.PersonalMessageHash([]byte("Some data"))
+	sig, err := secp256k1.SignRecoverable(hash[:], prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, hex.EncodeToString(sig[:64]), hex.EncodeToString(want[:64]))
+	status.Require(t, sig[64]+27, want[64])
+
+	// The kit signs what personal_sign does, so it reproduces the wallet's
+	// signature, and verifies the wallet's own 27/28 form.
+	kit, err := safe.CryptoKit(safe.Crypto.Secp256k1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kitSig, err := kit.Signing.Sign([]byte("Some data"), prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, hex.EncodeToString(kitSig), hex.EncodeToString(sig))
+
+	// Recovery takes the wallet's 27/28 form as well.
+	pub, err := secp256k1.RecoverPubKey(want, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := secp256k1.Address(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, addr, "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+	if err := safe.VerifySignature(safe.Crypto.Secp256k1.ID, want, []byte("Some data"), pub); err != nil {
+		t.Fatalf("VerifySignature of a wallet signature: %v", err)
+	}
+
+	// The high-s twin of a valid signature is refused.
+	highS := append([]byte{}, sig...)
+	negateS(highS[32:64])
+	highS[64] ^= 1
+	if _, err := secp256k1.RecoverPubKey(highS, hash[:]); err == nil {
+		t.Fatal("RecoverPubKey must refuse a high-s signature")
+	}
+}
+
+// negateS replaces a big-endian s with N - s.
+func negateS(s []byte) {
+	order, _ := hex.DecodeString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141")
+	borrow := 0
+	for idx := len(s) - 1; idx >= 0; idx-- {
+		diff := int(order[idx]) - int(s[idx]) - borrow
+		borrow = 0
+		if diff < 0 {
+			diff += 256
+			borrow = 1
+		}
+		s[idx] = byte(diff)
+	}
+}
+
+func TestSecp256k1_SealOpen(t *testing.T) {
+	kit, err := safe.CryptoKit(safe.Crypto.Secp256k1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := generate(t, safe.KeyType_AsymmetricKey)
+	msg := []byte("a DEK sealed to a wallet key")
+
+	sealed, err := safe.SealFor(safe.Crypto.Secp256k1.ID, recipient.Pub.Bytes, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := kit.Encrypt.Open(sealed, recipient.Prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, string(opened), string(msg))
+
+	other := generate(t, safe.KeyType_AsymmetricKey)
+	if _, err := kit.Encrypt.Open(sealed, other.Prv); err == nil {
+		t.Fatal("Open must fail under another key")
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := kit.Encrypt.Open(sealed, recipient.Prv); err == nil {
+		t.Fatal("Open must fail on tampered ciphertext")
+	}
+}
+
+func TestSecp256k1_KeyPairFromPhrase(t *testing.T) {
+	phrase := safe.EncodePhrase(bytes.Repeat([]byte{0x5A}, 16))
+	spec := safe.KeySpec{
+		CryptoKitID: safe.Crypto.Secp256k1.ID,
+		KeyType:     safe.KeyType_SigningKey,
+	}
+	kp1, err := safe.KeyPairFromPhrase(phrase, spec, "wallet-sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp2, err := safe.KeyPairFromPhrase(phrase, spec, "wallet-sig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(kp1.Prv, kp2.Prv), true)
+	status.Require(t, bytes.Equal(kp1.Pub.Bytes, kp2.Pub.Bytes), true)
+
+	kp3, err := safe.KeyPairFromPhrase(phrase, spec, "device-link")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(kp1.Pub.Bytes, kp3.Pub.Bytes), false)
+}
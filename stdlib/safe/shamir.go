@@ -0,0 +1,182 @@
+package safe
+
+import (
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+// MaxShares is the most shares a secret can be split into: each share is a
+// distinct nonzero point of GF(256).
+const MaxShares = 255
+
+// Share is one point of a k-of-n Shamir split of a secret over GF(256).
+//
+// Any Threshold shares with distinct Index values reconstruct the secret; fewer
+// reveal nothing about it.  A share is self-describing, so a guardian holding
+// one need not remember the split's parameters.
+type Share struct {
+	Threshold uint8  // k: shares needed to reconstruct
+	Index     uint8  // x-coordinate, 1..MaxShares
+	Value     []byte // one polynomial evaluation per secret byte
+}
+
+// Bytes returns the share's wire form: Threshold (1) ‖ Index (1) ‖ Value.
+func (share Share) Bytes() []byte {
+	out := make([]byte, 0, 2+len(share.Value))
+	out = append(out, share.Threshold, share.Index)
+	return append(out, share.Value...)
+}
+
+// Phrase encodes the share as a checksummed Phrase, for a guardian who keeps
+// their share on paper.
+func (share Share) Phrase() Phrase {
+	buf := share.Bytes()
+	defer Zero(buf)
+	return EncodePhrase(buf)
+}
+
+// ParseShare decodes a share from its Bytes form, e.g. as a guardian's enclave
+// opens a sealed share (see SealShare).
+func ParseShare(buf []byte) (Share, error) {
+	if len(buf) < 3 {
+		return Share{}, status.Code_BadValue.Errorf("safe: share too short (%d bytes)", len(buf))
+	}
+	share := Share{
+		Threshold: buf[0],
+		Index:     buf[1],
+		Value:     append([]byte(nil), buf[2:]...),
+	}
+	if share.Threshold == 0 || share.Index == 0 {
+		return Share{}, status.Code_BadValue.Error("safe: share has a zero threshold or index")
+	}
+	return share, nil
+}
+
+// ShareFromPhrase decodes a share written down via Share.Phrase.
+func ShareFromPhrase(phrase Phrase) (Share, error) {
+	buf, err := DecodePhrase(phrase)
+	if err != nil {
+		return Share{}, err
+	}
+	defer Zero(buf)
+	return ParseShare(buf)
+}
+
+// SealShare seals a share to a guardian's public key under the given kit, so
+// it can be handed over any channel.  The guardian recovers it with
+// Enclave.OpenFromPub followed by ParseShare.
+func SealShare(guardianKit CryptoKitID, guardianPubKey []byte, share Share) ([]byte, error) {
+	buf := share.Bytes()
+	defer Zero(buf)
+	return SealFor(guardianKit, guardianPubKey, buf)
+}
+
+// SplitSecret splits secret into count shares, any threshold of which
+// reconstruct it.  Each secret byte is the constant term of its own random
+// polynomial of degree threshold-1; share i holds every polynomial at x = i.
+func SplitSecret(rng io.Reader, secret []byte, threshold, count int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, status.Code_BadValue.Error("safe: cannot split an empty secret")
+	}
+	if threshold < 1 || count < threshold || count > MaxShares {
+		return nil, status.Code_BadValue.Errorf("safe: invalid split %d of %d (need 1 <= k <= n <= %d)", threshold, count, MaxShares)
+	}
+
+	// coeffs[pos*threshold + deg] is the deg-th coefficient of byte pos's polynomial.
+	coeffs := make([]byte, len(secret)*threshold)
+	defer Zero(coeffs)
+	for pos, bite := range secret {
+		row := coeffs[pos*threshold : (pos+1)*threshold]
+		row[0] = bite
+		if _, err := io.ReadFull(rng, row[1:]); err != nil {
+			return nil, status.Code_KeyGenerationFailed.Wrap(err)
+		}
+	}
+
+	shares := make([]Share, count)
+	for idx := range shares {
+		x := byte(idx + 1)
+		value := make([]byte, len(secret))
+		for pos := range value {
+			row := coeffs[pos*threshold : (pos+1)*threshold]
+			var y byte // Horner, highest degree first
+			for deg := threshold - 1; deg >= 0; deg-- {
+				y = gfMul(y, x) ^ row[deg]
+			}
+			value[pos] = y
+		}
+		shares[idx] = Share{Threshold: uint8(threshold), Index: x, Value: value}
+	}
+	return shares, nil
+}
+
+// CombineShares reconstructs a secret from at least Threshold shares of one
+// split.  Shares of a different split, or a corrupted share, yield a wrong
+// secret rather than an error — callers verify the result (as the threshold
+// Guard does against its KeyID).
+func CombineShares(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, status.Code_BadValue.Error("safe: no shares to combine")
+	}
+	threshold := int(shares[0].Threshold)
+	size := len(shares[0].Value)
+	seen := make(map[uint8]bool, len(shares))
+	for _, share := range shares {
+		switch {
+		case share.Threshold == 0 || share.Index == 0:
+			return nil, status.Code_BadValue.Error("safe: share has a zero threshold or index")
+		case int(share.Threshold) != threshold || len(share.Value) != size:
+			return nil, status.Code_BadValue.Error("safe: shares come from different splits")
+		case seen[share.Index]:
+			return nil, status.Code_BadValue.Errorf("safe: duplicate share index %d", share.Index)
+		}
+		seen[share.Index] = true
+	}
+	if len(shares) < threshold {
+		return nil, status.Code_NotReady.Errorf("safe: have %d of %d shares", len(shares), threshold)
+	}
+
+	// Lagrange interpolation at x = 0 over the first threshold shares:
+	//   secret = Σ y_i · Π_{j≠i} x_j / (x_j - x_i)    (subtraction is XOR)
+	points := shares[:threshold]
+	secret := make([]byte, size)
+	for i, share := range points {
+		basis := byte(1)
+		for j, other := range points {
+			if i != j {
+				basis = gfMul(basis, gfMul(other.Index, gfInv(other.Index^share.Index)))
+			}
+		}
+		for pos, y := range share.Value {
+			secret[pos] ^= gfMul(y, basis)
+		}
+	}
+	return secret, nil
+}
+
+// gfMul multiplies in GF(2^8) modulo the AES polynomial x^8 + x^4 + x^3 + x + 1,
+// without data-dependent branches or table lookups.
+func gfMul(a, b byte) byte {
+	var product byte
+	for range 8 {
+		product ^= -(b & 1) & a
+		carry := -(a >> 7)
+		a = (a << 1) ^ (carry & 0x1B)
+		b >>= 1
+	}
+	return product
+}
+
+// gfInv returns the multiplicative inverse a^254 (and 0 for 0).
+func gfInv(a byte) byte {
+	a2 := gfMul(a, a)
+	a4 := gfMul(a2, a2)
+	a8 := gfMul(a4, a4)
+	a16 := gfMul(a8, a8)
+	a32 := gfMul(a16, a16)
+	a64 := gfMul(a32, a32)
+	a128 := gfMul(a64, a64)
+	// 254 = 128 + 64 + 32 + 16 + 8 + 4 + 2
+	return gfMul(gfMul(gfMul(a128, a64), gfMul(a32, a16)), gfMul(gfMul(a8, a4), a2))
+}
@@ -0,0 +1,345 @@
+package safe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+// ThresholdGuard implements Guard with a root secret that exists only as k-of-n
+// Shamir shares (see SplitSecret) — social recovery without a central escrow.
+//
+// NewThresholdGuard generates the secret and hands back its shares for
+// distribution to guardians, each as a Phrase or sealed to the guardian's
+// public key (SealShare).  To recover, OpenThresholdGuard takes whatever shares
+// have come back and AddShare takes more as they arrive; UnwrapDEK succeeds once
+// k good shares are held.  The secret wraps DEKs exactly as fileGuard's root
+// key does (HKDF with a fresh salt, then AEAD).
+//
+// The guard's KeyID is a fingerprint of the secret, so shares from another
+// split, or a corrupted share, are caught before any DEK is touched.  A
+// recovering guard keeps every share it is given until UnwrapDEK finds k of
+// them whose secret matches the WrappedDEK's KeyID, trying each k-subset in
+// turn — so a bad share among more than k costs nothing, and one among exactly
+// k is outvoted by the next good share AddShare brings in.  The search stops
+// after maxRecoverySubsets tries, so a flood of bad shares cannot stall
+// UnwrapDEK.  Only then are the shares dropped; until then WrapDEK refuses, as
+// the secret is unconfirmed.
+type ThresholdGuard struct {
+	mu        sync.Mutex
+	threshold int             // k; 0 until a share is held
+	count     int             // n; 0 if unknown (learned from a WrappedDEK on unwrap)
+	shares    map[uint8]Share // held shares by Index, until a secret is confirmed
+	secret    []byte          // confirmed root secret (zeroed on Close)
+	keyID     []byte          // fingerprint of secret
+	rand      io.Reader
+}
+
+var _ Guard = (*ThresholdGuard)(nil)
+
+const (
+	thresholdGuardProvider = "thresholdGuard"
+	thresholdGuardWrapInfo = "safe.thresholdGuard.WrapDEK"
+	thresholdGuardKeyInfo  = "safe.thresholdGuard.KeyID"
+	thresholdGuardKeyIDLen = 16
+
+	// maxRecoverySubsets caps the k-subsets confirm tries (each a CombineShares
+	// and a fingerprint), bounding an UnwrapDEK made under g.mu.
+	maxRecoverySubsets = 1 << 12
+)
+
+// NewThresholdGuard creates a ThresholdGuard over a fresh random secret split
+// into count shares, any threshold of which recover it.  The guard is ready for
+// use; the caller distributes the returned shares and then discards them.
+func NewThresholdGuard(threshold, count int) (*ThresholdGuard, []Share, error) {
+	secret := make([]byte, DEKSize)
+	if _, err := io.ReadFull(RandReader, secret); err != nil {
+		return nil, nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	shares, err := SplitSecret(RandReader, secret, threshold, count)
+	if err != nil {
+		Zero(secret)
+		return nil, nil, err
+	}
+	keyID, err := thresholdKeyID(secret)
+	if err != nil {
+		Zero(secret)
+		return nil, nil, err
+	}
+	guard := &ThresholdGuard{
+		threshold: threshold,
+		count:     count,
+		secret:    secret,
+		keyID:     keyID,
+		rand:      RandReader,
+	}
+	return guard, shares, nil
+}
+
+// OpenThresholdGuard creates a ThresholdGuard for recovery from the given
+// shares, which may number fewer than the threshold (or none): the guard holds
+// them, and any AddShare brings, until UnwrapDEK confirms a secret.
+func OpenThresholdGuard(shares ...Share) (*ThresholdGuard, error) {
+	guard := &ThresholdGuard{
+		shares: make(map[uint8]Share),
+		rand:   RandReader,
+	}
+	for _, share := range shares {
+		if err := guard.AddShare(share); err != nil {
+			guard.Close()
+			return nil, err
+		}
+	}
+	return guard, nil
+}
+
+// AddShare supplies one more share, held until UnwrapDEK confirms a secret
+// from k of the shares.  A repeated share, or any share once the secret is
+// confirmed, is a no-op.
+func (g *ThresholdGuard) AddShare(share Share) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.secret != nil {
+		return nil
+	}
+	if g.shares == nil {
+		return status.Code_NotReady.Error("safe: thresholdGuard is closed")
+	}
+	if share.Threshold == 0 || share.Index == 0 || len(share.Value) == 0 {
+		return status.Code_BadValue.Error("safe: malformed share")
+	}
+	if g.threshold != 0 && int(share.Threshold) != g.threshold {
+		return status.Code_BadValue.Errorf("safe: share needs %d of n, guard holds shares needing %d", share.Threshold, g.threshold)
+	}
+	if _, held := g.shares[share.Index]; held {
+		return nil
+	}
+	g.threshold = int(share.Threshold)
+	g.shares[share.Index] = Share{
+		Threshold: share.Threshold,
+		Index:     share.Index,
+		Value:     append([]byte(nil), share.Value...),
+	}
+	return nil
+}
+
+// confirm reconstructs the secret whose fingerprint is keyID from some k of the
+// held shares, trying each k-subset in Index order (at most maxRecoverySubsets
+// of them), then drops the shares.
+func (g *ThresholdGuard) confirm(keyID []byte) error {
+	held := make([]Share, 0, len(g.shares))
+	for _, share := range g.shares {
+		held = append(held, share)
+	}
+	slices.SortFunc(held, func(a, b Share) int { return int(a.Index) - int(b.Index) })
+
+	var secret []byte
+	tries, gaveUp := 0, false
+	subset := make([]Share, g.threshold)
+	forEachSubset(len(held), g.threshold, func(picks []int) bool {
+		if gaveUp = tries == maxRecoverySubsets; gaveUp {
+			return false
+		}
+		tries++
+		for i, pick := range picks {
+			subset[i] = held[pick]
+		}
+		candidate, err := CombineShares(subset)
+		if err != nil {
+			return true
+		}
+		if id, err := thresholdKeyID(candidate); err == nil && bytesEqual(id, keyID) {
+			secret = candidate
+			return false
+		}
+		Zero(candidate)
+		return true
+	})
+	if gaveUp {
+		return status.Code_BadRequest.Errorf("safe: gave up after %d subsets of the %d held shares; recover from fewer, trusted shares", tries, len(held))
+	}
+	if secret == nil {
+		return status.Code_DecryptFailed.Errorf("safe: no %d of the %d held shares reconstruct this WrappedDEK's secret", g.threshold, len(held))
+	}
+	for _, share := range held {
+		Zero(share.Value)
+	}
+	g.shares = nil
+	g.secret = secret
+	g.keyID = append([]byte(nil), keyID...)
+	return nil
+}
+
+// forEachSubset calls fn with each k-subset of [0, n) in lexicographic order
+// until fn returns false.
+func forEachSubset(n, k int, fn func(picks []int) bool) {
+	if k <= 0 || k > n {
+		return
+	}
+	picks := make([]int, k)
+	for i := range picks {
+		picks[i] = i
+	}
+	for fn(picks) {
+		i := k - 1
+		for i >= 0 && picks[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return
+		}
+		picks[i]++
+		for j := i + 1; j < k; j++ {
+			picks[j] = picks[j-1] + 1
+		}
+	}
+}
+
+// Ready reports whether the guard holds its secret or at least threshold
+// shares, i.e. whether UnwrapDEK can be attempted.
+func (g *ThresholdGuard) Ready() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.secret != nil || (g.threshold > 0 && len(g.shares) >= g.threshold)
+}
+
+func (g *ThresholdGuard) Info(_ context.Context) (*GuardInfo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	label := "Threshold guard"
+	switch {
+	case g.count > 0:
+		label = fmt.Sprintf("Threshold guard (%d of %d shares)", g.threshold, g.count)
+	case g.threshold > 0:
+		label = fmt.Sprintf("Threshold guard (%d of n shares)", g.threshold)
+	}
+	return &GuardInfo{
+		Provider:       "shamir",
+		Label:          label,
+		KeyID:          g.keyID,
+		HardwareBacked: false,
+		Removable:      false,
+		ExportableRoot: true,
+	}, nil
+}
+
+func (g *ThresholdGuard) WrapDEK(_ context.Context, dek []byte, aad []byte) (*WrappedDEK, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkReady(); err != nil {
+		return nil, err
+	}
+	if g.secret == nil {
+		return nil, status.Code_NotReady.Error("safe: thresholdGuard wraps only once an unwrap has confirmed its shares")
+	}
+
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(g.rand, salt); err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	wrappingKey, err := DeriveKey(g.secret, salt, []byte(thresholdGuardWrapInfo))
+	if err != nil {
+		return nil, err
+	}
+	defer Zero(wrappingKey)
+
+	nonce, cipherblob, err := SealAEAD(g.rand, wrappingKey, dek, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WrappedDEK{
+		Version:        uint32(Const_SealedTomeVersion),
+		Provider:       thresholdGuardProvider,
+		KeyID:          g.keyID,
+		KDF:            KDFName,
+		Cipher:         CipherName,
+		Salt:           salt,
+		Nonce:          nonce,
+		ProviderParams: []byte{byte(g.threshold), byte(g.count)}, // k, n
+		Cipherblob:     cipherblob,
+	}, nil
+}
+
+func (g *ThresholdGuard) UnwrapDEK(_ context.Context, wrapped *WrappedDEK, aad []byte) ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.checkReady(); err != nil {
+		return nil, err
+	}
+	if wrapped == nil {
+		return nil, status.Code_BadValue.Error("safe: nil WrappedDEK")
+	}
+	if wrapped.Provider != thresholdGuardProvider {
+		return nil, status.Code_BadValue.Errorf("safe: WrappedDEK provider mismatch: got %q, want %q", wrapped.Provider, thresholdGuardProvider)
+	}
+	if g.secret == nil {
+		if err := g.confirm(wrapped.KeyID); err != nil {
+			return nil, err
+		}
+	}
+	if !bytesEqual(wrapped.KeyID, g.keyID) {
+		return nil, status.Code_DecryptFailed.Error("safe: shares do not reconstruct this WrappedDEK's secret")
+	}
+
+	wrappingKey, err := DeriveKey(g.secret, wrapped.Salt, []byte(thresholdGuardWrapInfo))
+	if err != nil {
+		return nil, err
+	}
+	defer Zero(wrappingKey)
+
+	dek, err := OpenAEAD(wrappingKey, wrapped.Nonce, wrapped.Cipherblob, aad)
+	if err != nil {
+		return nil, err
+	}
+	if params := wrapped.ProviderParams; g.count == 0 && len(params) == 2 && int(params[0]) == g.threshold {
+		g.count = int(params[1])
+	}
+	return dek, nil
+}
+
+func (g *ThresholdGuard) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, share := range g.shares {
+		Zero(share.Value)
+	}
+	g.shares = nil
+	Zero(g.secret)
+	g.secret = nil
+	return nil
+}
+
+// checkReady returns Code_NotReady until threshold shares are held.
+func (g *ThresholdGuard) checkReady() error {
+	switch {
+	case g.secret != nil:
+		return nil
+	case g.shares == nil:
+		return status.Code_NotReady.Error("safe: thresholdGuard is closed")
+	case g.threshold == 0:
+		return status.Code_NotReady.Error("safe: thresholdGuard holds no shares")
+	case len(g.shares) < g.threshold:
+		return status.Code_NotReady.Errorf("safe: thresholdGuard has %d of %d shares", len(g.shares), g.threshold)
+	default:
+		return nil
+	}
+}
+
+// thresholdKeyID fingerprints a root secret; it reveals nothing about the secret.
+func thresholdKeyID(secret []byte) ([]byte, error) {
+	derived, err := DeriveSubKey(secret, thresholdGuardKeyInfo)
+	if err != nil {
+		return nil, err
+	}
+	return derived[:thresholdGuardKeyIDLen], nil
+}
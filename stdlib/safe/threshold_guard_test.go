@@ -0,0 +1,251 @@
+package safe_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+// TestSplitSecret_EverySubset checks that every k-subset of a 3-of-5 split
+// reconstructs the secret, and that k-1 shares are refused.
+func TestSplitSecret_EverySubset(t *testing.T) {
+	secret := make([]byte, safe.DEKSize)
+	rand.Read(secret)
+	shares, err := safe.SplitSecret(rand.Reader, secret, 3, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(shares), 5)
+
+	for a := range shares {
+		for b := a + 1; b < len(shares); b++ {
+			for c := b + 1; c < len(shares); c++ {
+				got, err := safe.CombineShares([]safe.Share{shares[c], shares[a], shares[b]})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, secret) {
+					t.Fatalf("shares %d,%d,%d reconstruct the wrong secret", a, b, c)
+				}
+			}
+		}
+	}
+
+	if _, err := safe.CombineShares(shares[:2]); status.GetCode(err) != status.Code_NotReady {
+		t.Fatalf("2 of 3 shares must be refused; got %v", err)
+	}
+	if _, err := safe.CombineShares([]safe.Share{shares[0], shares[0], shares[1]}); status.GetCode(err) != status.Code_BadValue {
+		t.Fatalf("a duplicate share must be refused; got %v", err)
+	}
+	if _, err := safe.SplitSecret(rand.Reader, secret, 4, 3); err == nil {
+		t.Fatal("k > n must be refused")
+	}
+}
+
+// TestThresholdGuard_SocialRecovery walks the recovery flow: an enclave sealed
+// under a 2-of-3 guard is reopened from one share kept as a Phrase plus one
+// sealed to a guardian's key.
+func TestThresholdGuard_SocialRecovery(t *testing.T) {
+	ctx := context.Background()
+	aad := []byte("threshold-test")
+	path := filepath.Join(t.TempDir(), "member.tome")
+
+	guard, shares, err := safe.NewThresholdGuard(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := safe.OpenEnclave(ctx, safe.NewLocalTomeStore(path), guard, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyringID := tag.NewID()
+	pub, err := enc.GenerateKey(ctx, keyringID, safe.KeySpec{
+		CryptoKitID: safe.Crypto.Poly25519.ID,
+		KeyType:     safe.KeyType_SigningKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	guard.Close()
+
+	// Guardian A wrote their share down.
+	phrase := safe.ParsePhrase(shares[0].Phrase().String())
+	fromPhrase, err := safe.ShareFromPhrase(phrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Guardian B holds theirs sealed to their own key.
+	kit, _ := safe.CryptoKit(safe.Crypto.Poly25519.ID)
+	guardian := safe.KeyPair{Pub: safe.PubKey{CryptoKitID: kit.ID, KeyType: safe.KeyType_AsymmetricKey}}
+	if err := kit.Encrypt.Generate(rand.Reader, &guardian); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := safe.SealShare(kit.ID, guardian.Pub.Bytes, shares[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := kit.Encrypt.Open(sealed, guardian.Prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSeal, err := safe.ParseShare(opened)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One share is not enough.
+	recovery, err := safe.OpenThresholdGuard(fromPhrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovery.Close()
+	status.Require(t, recovery.Ready(), false)
+	if _, err := safe.OpenEnclave(ctx, safe.NewLocalTomeStore(path), recovery, aad); err == nil {
+		t.Fatal("enclave must stay sealed with 1 of 2 shares")
+	}
+
+	// The second share unlocks it, and the key is back.
+	if err := recovery.AddShare(fromSeal); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, recovery.Ready(), true)
+	reopened, err := safe.OpenEnclave(ctx, safe.NewLocalTomeStore(path), recovery, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close(ctx)
+	ref := &safe.KeyRef{Type: safe.KeyType_SigningKey}
+	ref.SetKeyringID(keyringID)
+	got, err := reopened.FetchPubKey(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(got.Bytes, pub.Bytes), true)
+
+	info, err := recovery.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, info.Label, "Threshold guard (2 of 3 shares)")
+}
+
+// TestThresholdGuard_ForeignShares checks that shares of another split are
+// caught by the KeyID fingerprint rather than surfacing as an AEAD failure.
+func TestThresholdGuard_ForeignShares(t *testing.T) {
+	ctx := context.Background()
+	guard, _, err := safe.NewThresholdGuard(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guard.Close()
+	wrapped, err := guard.WrapDEK(ctx, make([]byte, safe.DEKSize), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, foreign, err := safe.NewThresholdGuard(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovery, err := safe.OpenThresholdGuard(foreign...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovery.Close()
+	if _, err := recovery.UnwrapDEK(ctx, wrapped, nil); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("foreign shares must be refused; got %v", err)
+	}
+}
+
+// TestThresholdGuard_BadShare checks that one corrupted share among the
+// returned ones cannot poison recovery: the guard keeps every share and finds
+// k that match the WrappedDEK's KeyID.
+func TestThresholdGuard_BadShare(t *testing.T) {
+	ctx := context.Background()
+	guard, shares, err := safe.NewThresholdGuard(2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guard.Close()
+	dek := bytes.Repeat([]byte{0x5a}, safe.DEKSize)
+	wrapped, err := guard.WrapDEK(ctx, dek, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := safe.Share{
+		Threshold: shares[0].Threshold,
+		Index:     shares[0].Index,
+		Value:     bytes.Clone(shares[0].Value),
+	}
+	bad.Value[0] ^= 1
+
+	// k+1 shares, one bad: a good k-subset is found straight away.
+	recovery, err := safe.OpenThresholdGuard(bad, shares[1], shares[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovery.Close()
+	got, err := recovery.UnwrapDEK(ctx, wrapped, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(got, dek), true)
+
+	// Exactly k with one bad fails, but the shares are kept: the next good
+	// share recovers.
+	late, err := safe.OpenThresholdGuard(bad, shares[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer late.Close()
+	if _, err := late.UnwrapDEK(ctx, wrapped, nil); status.GetCode(err) != status.Code_DecryptFailed {
+		t.Fatalf("a bad share must not reconstruct; got %v", err)
+	}
+	if _, err := late.WrapDEK(ctx, dek, nil); status.GetCode(err) != status.Code_NotReady {
+		t.Fatalf("an unconfirmed guard must not wrap; got %v", err)
+	}
+	if err := late.AddShare(shares[1]); err != nil {
+		t.Fatal(err)
+	}
+	got, err = late.UnwrapDEK(ctx, wrapped, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(got, dek), true)
+}
+
+// TestThresholdGuard_SubsetCap checks that a flood of bad shares ends the
+// k-subset search at its cap rather than stalling UnwrapDEK.
+func TestThresholdGuard_SubsetCap(t *testing.T) {
+	ctx := context.Background()
+	guard, shares, err := safe.NewThresholdGuard(3, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer guard.Close()
+	wrapped, err := guard.WrapDEK(ctx, make([]byte, safe.DEKSize), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range shares {
+		shares[i].Value[0] ^= 1
+	}
+	recovery, err := safe.OpenThresholdGuard(shares...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recovery.Close()
+	if _, err := recovery.UnwrapDEK(ctx, wrapped, nil); status.GetCode(err) != status.Code_BadRequest {
+		t.Fatalf("an unbounded subset search must be refused; got %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+// Package x25519mlkem768 registers the X25519MLKEM768 CryptoKit with the safe
+// package: a hybrid post-quantum suite for key wraps.
+//
+// This kit provides:
+//   - Asymmetric encryption (KeyType_AsymmetricKey): X25519 ECDH + ML-KEM-768
+//     (FIPS 203, crypto/mlkem) + HKDF + XChaCha20-Poly1305
+//   - Signing (KeyType_SigningKey): Ed25519, shared with Poly25519
+//
+// Epoch keys are sealed-box-wrapped to each member's AsymmetricKey, so a
+// recorded MemberEpoch stays sealed only as long as the wrap's key agreement
+// does.  Here the wrapping key is derived from BOTH shared secrets: an
+// adversary must break X25519 and ML-KEM-768 to unwrap, so traffic harvested
+// today survives a future quantum break of X25519, and a flaw in the younger
+// ML-KEM leaves the classical guarantee intact.  An epoch selects the suite by
+// naming safe.Crypto.X25519MLKEM768 in EpochTerms.CryptoKitID.
+//
+// Key and wire layouts:
+//
+//	AsymmetricKey pub = X25519 pub (32) ‖ ML-KEM-768 encapsulation key (1184)
+//	AsymmetricKey prv = X25519 scalar (32) ‖ ML-KEM-768 seed (64)
+//	sealed box        = eph X25519 pub (32) ‖ ML-KEM ciphertext (1088) ‖ nonce (24) ‖ ciphertext+tag
+//
+// The wrapping key is safe.DeriveSharedKey over ML-KEM secret ‖ X25519 secret,
+// binding the ephemeral X25519 key and the recipient's full hybrid public key
+// (the X-Wing combiner's inputs; ML-KEM's ciphertext is bound by the KEM
+// itself).  The X25519 half draws from the caller's rng; ML-KEM encapsulation
+// always draws from the system CSPRNG.
+//
+// Import this package (typically via blank import) to register the kit:
+//
+//	import _ "github.com/art-media-platform/amp.SDK/stdlib/safe/x25519mlkem768"
+package x25519mlkem768
+
+import (
+	"crypto/ecdh"
+	"crypto/mlkem"
+	"io"
+
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	_ "github.com/art-media-platform/amp.SDK/stdlib/safe/poly25519" // Signing is Poly25519's Ed25519
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+)
+
+const (
+	// X25519KeySize is the length of an X25519 public key or scalar.
+	X25519KeySize = 32
+
+	// PubKeySize is the hybrid AsymmetricKey public-key length.
+	PubKeySize = X25519KeySize + mlkem.EncapsulationKeySize768
+
+	// PrvKeySize is the hybrid AsymmetricKey private-key length.
+	PrvKeySize = X25519KeySize + mlkem.SeedSize
+
+	// SealOverhead is the sealed-box framing ahead of the AEAD ciphertext.
+	SealOverhead = X25519KeySize + mlkem.CiphertextSize768 + safe.NonceSize
+
+	kdfLabel = "X25519MLKEM768"
+)
+
+func init() {
+	poly, err := safe.CryptoKit(safe.Crypto.Poly25519.ID)
+	if err != nil {
+		panic(err)
+	}
+	kit.Signing = poly.Signing
+	safe.RegisterCryptoKit(&kit)
+}
+
+var kit = safe.Kit{
+	ID: safe.Crypto.X25519MLKEM768.ID,
+	Encrypt: &safe.EncryptOps{
+		Generate: generateEncKey,
+		Seal:     seal,
+		Open:     open,
+	},
+}
+
+// generateEncKey produces a hybrid keypair.  Both halves are read from rng, so
+// a deterministic rng (KeyPairFromPhrase) yields a deterministic keypair.
+func generateEncKey(rng io.Reader, kp *safe.KeyPair) error {
+	prv := make([]byte, PrvKeySize)
+	if _, err := io.ReadFull(rng, prv); err != nil {
+		return status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	xPrv, err := ecdh.X25519().NewPrivateKey(prv[:X25519KeySize])
+	if err != nil {
+		safe.Zero(prv)
+		return status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	dk, err := mlkem.NewDecapsulationKey768(prv[X25519KeySize:])
+	if err != nil {
+		safe.Zero(prv)
+		return status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	pub := make([]byte, 0, PubKeySize)
+	pub = append(pub, xPrv.PublicKey().Bytes()...)
+	pub = append(pub, dk.EncapsulationKey().Bytes()...)
+	kp.Prv = prv
+	kp.Pub.Bytes = pub
+	return nil
+}
+
+// seal encrypts msg for a peer's hybrid public key.  No sender identity
+// participates; the wrap is anonymous-sender.
+func seal(rng io.Reader, msg, peerPubKey []byte) ([]byte, error) {
+	if len(peerPubKey) != PubKeySize {
+		return nil, status.Code_BadKeyFormat.Errorf("X25519MLKEM768 public key must be %d bytes, got %d", PubKeySize, len(peerPubKey))
+	}
+	curve := ecdh.X25519()
+	peerX, err := curve.NewPublicKey(peerPubKey[:X25519KeySize])
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+	peerEK, err := mlkem.NewEncapsulationKey768(peerPubKey[X25519KeySize:])
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+
+	ephSeed := make([]byte, X25519KeySize)
+	defer safe.Zero(ephSeed)
+	if _, err := io.ReadFull(rng, ephSeed); err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	eph, err := curve.NewPrivateKey(ephSeed)
+	if err != nil {
+		return nil, status.Code_KeyGenerationFailed.Wrap(err)
+	}
+	xShared, err := eph.ECDH(peerX)
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+	defer safe.Zero(xShared)
+	kemShared, kemCT := peerEK.Encapsulate()
+	defer safe.Zero(kemShared)
+
+	ephPub := eph.PublicKey().Bytes()
+	key, err := combine(kemShared, xShared, ephPub, peerPubKey)
+	if err != nil {
+		return nil, err
+	}
+	defer safe.Zero(key)
+
+	nonce, ct, err := safe.SealAEAD(rng, key, msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, SealOverhead+len(ct))
+	out = append(out, ephPub...)
+	out = append(out, kemCT...)
+	out = append(out, nonce...)
+	out = append(out, ct...)
+	return out, nil
+}
+
+func open(msg, prvKey []byte) ([]byte, error) {
+	if len(prvKey) != PrvKeySize {
+		return nil, status.Code_BadKeyFormat.Errorf("X25519MLKEM768 private key must be %d bytes, got %d", PrvKeySize, len(prvKey))
+	}
+	if len(msg) < SealOverhead {
+		return nil, status.Code_DecryptFailed.Error("ciphertext too short")
+	}
+	ephPub := msg[:X25519KeySize]
+	kemCT := msg[X25519KeySize : X25519KeySize+mlkem.CiphertextSize768]
+	nonce := msg[X25519KeySize+mlkem.CiphertextSize768 : SealOverhead]
+	ct := msg[SealOverhead:]
+
+	curve := ecdh.X25519()
+	xPrv, err := curve.NewPrivateKey(prvKey[:X25519KeySize])
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+	dk, err := mlkem.NewDecapsulationKey768(prvKey[X25519KeySize:])
+	if err != nil {
+		return nil, status.Code_BadKeyFormat.Wrap(err)
+	}
+	eph, err := curve.NewPublicKey(ephPub)
+	if err != nil {
+		return nil, status.Code_DecryptFailed.Wrap(err)
+	}
+	xShared, err := xPrv.ECDH(eph)
+	if err != nil {
+		return nil, status.Code_DecryptFailed.Wrap(err)
+	}
+	defer safe.Zero(xShared)
+	kemShared, err := dk.Decapsulate(kemCT)
+	if err != nil {
+		return nil, status.Code_DecryptFailed.Wrap(err)
+	}
+	defer safe.Zero(kemShared)
+
+	pub := make([]byte, 0, PubKeySize)
+	pub = append(pub, xPrv.PublicKey().Bytes()...)
+	pub = append(pub, dk.EncapsulationKey().Bytes()...)
+	key, err := combine(kemShared, xShared, ephPub, pub)
+	if err != nil {
+		return nil, err
+	}
+	defer safe.Zero(key)
+	return safe.OpenAEAD(key, nonce, ct, nil)
+}
+
+// combine derives the wrapping key from both shared secrets.
+func combine(kemShared, xShared, ephPub, recipientPub []byte) ([]byte, error) {
+	shared := make([]byte, 0, len(kemShared)+len(xShared))
+	shared = append(shared, kemShared...)
+	shared = append(shared, xShared...)
+	defer safe.Zero(shared)
+	return safe.DeriveSharedKey(kdfLabel, ephPub, recipientPub, shared)
+}
@@ -0,0 +1,135 @@
+package x25519mlkem768_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/art-media-platform/amp.SDK/amp"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe"
+	"github.com/art-media-platform/amp.SDK/stdlib/safe/x25519mlkem768"
+	"github.com/art-media-platform/amp.SDK/stdlib/status"
+	"github.com/art-media-platform/amp.SDK/stdlib/tag"
+)
+
+var kitID = safe.Crypto.X25519MLKEM768.ID
+
+func TestHybrid_SealOpen(t *testing.T) {
+	kit, err := safe.CryptoKit(kitID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp := safe.KeyPair{Pub: safe.PubKey{CryptoKitID: kitID, KeyType: safe.KeyType_AsymmetricKey}}
+	if err := kit.Encrypt.Generate(rand.Reader, &kp); err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(kp.Pub.Bytes), x25519mlkem768.PubKeySize)
+	status.Require(t, len(kp.Prv), x25519mlkem768.PrvKeySize)
+
+	dek := []byte("epoch DEK, 32 bytes of secret..!")
+	sealed, err := safe.SealFor(kitID, kp.Pub.Bytes, dek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, len(sealed), x25519mlkem768.SealOverhead+len(dek)+16)
+	opened, err := kit.Encrypt.Open(sealed, kp.Prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, string(opened), string(dek))
+
+	// Each half of the private key is load-bearing: swapping either one out
+	// leaves the wrap sealed.
+	other := safe.KeyPair{Pub: safe.PubKey{CryptoKitID: kitID, KeyType: safe.KeyType_AsymmetricKey}}
+	if err := kit.Encrypt.Generate(rand.Reader, &other); err != nil {
+		t.Fatal(err)
+	}
+	wrongX := append(append([]byte{}, other.Prv[:x25519mlkem768.X25519KeySize]...), kp.Prv[x25519mlkem768.X25519KeySize:]...)
+	wrongKEM := append(append([]byte{}, kp.Prv[:x25519mlkem768.X25519KeySize]...), other.Prv[x25519mlkem768.X25519KeySize:]...)
+	for _, prv := range [][]byte{wrongX, wrongKEM} {
+		if _, err := kit.Encrypt.Open(sealed, prv); err == nil {
+			t.Fatal("open under a half-wrong key")
+		}
+	}
+
+	// A tampered ML-KEM ciphertext decapsulates to an unrelated secret.
+	sealed[x25519mlkem768.X25519KeySize+5] ^= 1
+	if _, err := kit.Encrypt.Open(sealed, kp.Prv); err == nil {
+		t.Fatal("open with a tampered KEM ciphertext")
+	}
+}
+
+func TestHybrid_KeyPairFromPhrase(t *testing.T) {
+	phrase := safe.EncodePhrase(bytes.Repeat([]byte{0x3C}, 16))
+	spec := safe.KeySpec{CryptoKitID: kitID, KeyType: safe.KeyType_AsymmetricKey}
+	kp1, err := safe.KeyPairFromPhrase(phrase, spec, "member-encrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kp2, err := safe.KeyPairFromPhrase(phrase, spec, "member-encrypt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(kp1.Pub.Bytes, kp2.Pub.Bytes), true)
+	status.Require(t, bytes.Equal(kp1.Prv, kp2.Prv), true)
+
+	// Signing stays Ed25519.
+	spec.KeyType = safe.KeyType_SigningKey
+	signer, err := safe.KeyPairFromPhrase(phrase, spec, "member-sign")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kit, _ := safe.CryptoKit(kitID)
+	digest := make([]byte, 32)
+	sig, err := kit.Signing.Sign(digest, signer.Prv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := safe.VerifySignature(safe.Crypto.Poly25519.ID, sig, digest, signer.Pub.Bytes); err != nil {
+		t.Fatalf("hybrid-kit signature is not Ed25519: %v", err)
+	}
+	if err := safe.VerifySignature(kitID, sig, digest, signer.Pub.Bytes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// An epoch that names the hybrid suite in its terms wraps its key to a member's
+// hybrid AsymmetricKey, and the member's enclave unwraps it.
+func TestHybrid_EpochTermsSelectsKit(t *testing.T) {
+	terms := &amp.EpochTerms{}
+	terms.SetCryptoKitID(kitID)
+	status.Require(t, terms.EffectiveCryptoKit(), kitID)
+
+	ctx := context.Background()
+	guard := safe.NewFileGuard([]byte("pass"), []byte("id"))
+	defer guard.Close()
+	enc, err := safe.OpenEnclave(ctx, safe.NewLocalTomeStore(filepath.Join(t.TempDir(), "member.tome")), guard, []byte("hybrid-test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer enc.Close(ctx)
+
+	keyringID := tag.NewID()
+	pub, err := enc.GenerateKey(ctx, keyringID, safe.KeySpec{
+		CryptoKitID: terms.EffectiveCryptoKit(),
+		KeyType:     safe.KeyType_AsymmetricKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dek := make([]byte, safe.DEKSize)
+	rand.Read(dek)
+	wrapped, err := safe.SealFor(terms.EffectiveCryptoKit(), pub.Bytes, dek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := &safe.KeyRef{Type: safe.KeyType_AsymmetricKey}
+	ref.SetKeyringID(keyringID)
+	unwrapped, err := enc.OpenFromPub(ref, wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Require(t, bytes.Equal(unwrapped, dek), true)
+}
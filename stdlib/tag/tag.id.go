@@ -679,6 +679,35 @@ func (id UID) Base16() string {
 	return suffix
 }
 
+// UID_ParseBase16 parses the render produced by UID.Base16 ("0x"-prefixed hex
+// of up to 32 digits, or "0" for the zero UID).  Digits may be either case.
+func UID_ParseBase16(text string) (UID, error) {
+	if text == "0" {
+		return UID{}, nil
+	}
+	digits, ok := strings.CutPrefix(text, "0x")
+	if !ok || len(digits) == 0 || len(digits) > 32 {
+		return UID{}, ErrUnrecognizedFormat
+	}
+	var id UID
+	for _, char := range []byte(digits) {
+		var nibble byte
+		switch {
+		case '0' <= char && char <= '9':
+			nibble = char - '0'
+		case 'A' <= char && char <= 'F':
+			nibble = char - 'A' + 10
+		case 'a' <= char && char <= 'f':
+			nibble = char - 'a' + 10
+		default:
+			return UID{}, ErrUnrecognizedFormat
+		}
+		id[0] = id[0]<<4 | id[1]>>60
+		id[1] = id[1]<<4 | uint64(nibble)
+	}
+	return id, nil
+}
+
 func (id UID) String() string {
 	return id.Base32()
 }
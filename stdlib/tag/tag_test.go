@@ -112,6 +112,16 @@ func TestTag(t *testing.T) {
 	if b16 := tid.Base16(); b16 != "0xF777777777777777123456789ABCDEF0" {
 		t.Errorf("tag.UID.Base16() failed: got %v", b16)
 	}
+	for _, id := range []tag.UID{tid, {0, 1}, {1, 0}, {}} {
+		if back, err := tag.UID_ParseBase16(id.Base16()); err != nil || back != id {
+			t.Errorf("tag.UID_ParseBase16(%v) failed: got %v, %v", id.Base16(), back, err)
+		}
+	}
+	for _, bad := range []string{"", "0x", "F777", "0xG1", "0x" + strings.Repeat("F", 33)} {
+		if _, err := tag.UID_ParseBase16(bad); err == nil {
+			t.Errorf("tag.UID_ParseBase16(%q) accepted a malformed render", bad)
+		}
+	}
 }
 
 func TestBase32Grouping(t *testing.T) {